@@ -0,0 +1,102 @@
+package keeper
+
+import (
+	"context"
+	"errors"
+
+	"fdsc/x/datastore/types"
+
+	"cosmossdk.io/collections"
+	errorsmod "cosmossdk.io/errors"
+)
+
+// StoreFragment stores a received CSU fragment under its content id and links its
+// (session_id, path, index) fragment id to it. Identical bytes are stored once; every
+// newly linked position increments the content's link_count. Links are never removed.
+//
+// A packet without data is a dedup reference: content_id must already be stored here.
+// duplicate is true if the position was already linked to the same content (redelivery).
+func (k Keeper) StoreFragment(ctx context.Context, pkt *types.FragmentPacket) (contentID string, duplicate bool, err error) {
+	fragmentID := types.MakeFragmentID(pkt.SessionId, pkt.Path, pkt.Index)
+
+	contentID = pkt.ContentId
+	if len(pkt.Data) > 0 {
+		computed := types.MakeContentID(pkt.Data)
+		if contentID != "" && contentID != computed {
+			return "", false, errorsmod.Wrapf(types.ErrFragmentConflict, "content_id %s does not match the data", contentID)
+		}
+		contentID = computed
+	} else if !types.IsContentID(contentID) {
+		return "", false, errorsmod.Wrap(types.ErrUnknownContent, "fragment packet has neither data nor a content_id")
+	}
+
+	// fragments stored before content addressing live under their fragment id
+	legacy, err := k.Fragment.Get(ctx, fragmentID)
+	if err == nil {
+		if types.MakeContentID(legacy.Data) == contentID {
+			return contentID, true, nil
+		}
+		return "", false, errorsmod.Wrapf(types.ErrFragmentConflict, "fragment %s", fragmentID)
+	} else if !errors.Is(err, collections.ErrNotFound) {
+		return "", false, err
+	}
+
+	linked, err := k.FragmentRefs.Get(ctx, fragmentID)
+	if err == nil {
+		if linked == contentID {
+			return contentID, true, nil
+		}
+		return "", false, errorsmod.Wrapf(types.ErrFragmentConflict, "fragment %s", fragmentID)
+	} else if !errors.Is(err, collections.ErrNotFound) {
+		return "", false, err
+	}
+
+	frag, err := k.Fragment.Get(ctx, contentID)
+	switch {
+	case err == nil:
+		// fragments created by MsgCreateFragment before content-shaped ids were reserved may sit at this id:
+		// only link to content whose bytes match the id
+		if types.MakeContentID(frag.Data) != contentID {
+			return "", false, errorsmod.Wrapf(types.ErrFragmentConflict, "stored fragment %s does not match its content id", contentID)
+		}
+	case errors.Is(err, collections.ErrNotFound):
+		if len(pkt.Data) == 0 {
+			return "", false, errorsmod.Wrapf(types.ErrUnknownContent, "content %s is not stored", contentID)
+		}
+		frag = types.Fragment{
+			FragmentId: contentID,
+			Data:       pkt.Data,
+			Creator:    "ibc-sender",
+
+			// CSU metadata of the first upload
+			RootProof: pkt.RootProof,
+			SessionId: pkt.SessionId,
+			Path:      pkt.Path,
+			Index:     pkt.Index,
+		}
+	default:
+		return "", false, err
+	}
+
+	frag.LinkCount++
+	if err := k.Fragment.Set(ctx, contentID, frag); err != nil {
+		return "", false, err
+	}
+	if err := k.FragmentRefs.Set(ctx, fragmentID, contentID); err != nil {
+		return "", false, err
+	}
+	return contentID, false, nil
+}
+
+// ResolveFragment returns a fragment by content id or by (session_id, path, index) fragment id.
+func (k Keeper) ResolveFragment(ctx context.Context, id string) (types.Fragment, error) {
+	frag, err := k.Fragment.Get(ctx, id)
+	if err == nil || !errors.Is(err, collections.ErrNotFound) {
+		return frag, err
+	}
+	contentID, err := k.FragmentRefs.Get(ctx, id)
+	if err != nil {
+		return types.Fragment{}, err
+	}
+	return k.Fragment.Get(ctx, contentID)
+}
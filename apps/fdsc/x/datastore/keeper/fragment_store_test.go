@@ -0,0 +1,93 @@
+package keeper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"fdsc/x/datastore/types"
+)
+
+func TestStoreFragment(t *testing.T) {
+	f := initFixture(t)
+	data := []byte("fragment bytes")
+	contentID := types.MakeContentID(data)
+
+	linkCount := func(id string) uint64 {
+		frag, err := f.keeper.Fragment.Get(f.ctx, id)
+		require.NoError(t, err)
+		return frag.LinkCount
+	}
+
+	// first upload stores the bytes under the content id
+	got, duplicate, err := f.keeper.StoreFragment(f.ctx, &types.FragmentPacket{SessionId: "s1", Path: "a.txt", Index: 0, Data: data, RootProof: "root"})
+	require.NoError(t, err)
+	require.Equal(t, contentID, got)
+	require.False(t, duplicate)
+	require.Equal(t, uint64(1), linkCount(contentID))
+	linked, err := f.keeper.FragmentRefs.Get(f.ctx, types.MakeFragmentID("s1", "a.txt", 0))
+	require.NoError(t, err)
+	require.Equal(t, contentID, linked)
+
+	// redelivery of the same position is not counted again
+	_, duplicate, err = f.keeper.StoreFragment(f.ctx, &types.FragmentPacket{SessionId: "s1", Path: "a.txt", Index: 0, Data: data})
+	require.NoError(t, err)
+	require.True(t, duplicate)
+	require.Equal(t, uint64(1), linkCount(contentID))
+
+	// identical bytes at another position and a dedup reference share the content
+	_, duplicate, err = f.keeper.StoreFragment(f.ctx, &types.FragmentPacket{SessionId: "s2", Path: "b.txt", Index: 3, Data: data})
+	require.NoError(t, err)
+	require.False(t, duplicate)
+	_, duplicate, err = f.keeper.StoreFragment(f.ctx, &types.FragmentPacket{SessionId: "s3", Path: "c.txt", Index: 1, ContentId: contentID})
+	require.NoError(t, err)
+	require.False(t, duplicate)
+	require.Equal(t, uint64(3), linkCount(contentID))
+
+	// the content keeps the CSU metadata of its first upload
+	frag, err := f.keeper.ResolveFragment(f.ctx, types.MakeFragmentID("s3", "c.txt", 1))
+	require.NoError(t, err)
+	require.Equal(t, data, frag.Data)
+	require.Equal(t, "s1", frag.SessionId)
+	require.Equal(t, "root", frag.RootProof)
+
+	cases := []struct {
+		name string
+		pkt  types.FragmentPacket
+		want error
+	}{
+		{"reference to unknown content", types.FragmentPacket{SessionId: "s4", Path: "x", ContentId: types.MakeContentID([]byte("other"))}, types.ErrUnknownContent},
+		{"neither data nor content id", types.FragmentPacket{SessionId: "s4", Path: "x"}, types.ErrUnknownContent},
+		{"content id does not match the data", types.FragmentPacket{SessionId: "s4", Path: "x", Data: []byte("other"), ContentId: contentID}, types.ErrFragmentConflict},
+		{"position already linked to other content", types.FragmentPacket{SessionId: "s1", Path: "a.txt", Index: 0, Data: []byte("other")}, types.ErrFragmentConflict},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := f.keeper.StoreFragment(f.ctx, &tc.pkt)
+			require.ErrorIs(t, err, tc.want)
+		})
+	}
+	require.Equal(t, uint64(3), linkCount(contentID))
+}
+
+func TestStoreFragmentLegacyRecords(t *testing.T) {
+	f := initFixture(t)
+	data := []byte("legacy bytes")
+
+	// fragments stored before content addressing live under their fragment id
+	legacyID := types.MakeFragmentID("old", "a.txt", 0)
+	require.NoError(t, f.keeper.Fragment.Set(f.ctx, legacyID, types.Fragment{FragmentId: legacyID, Data: data}))
+	_, duplicate, err := f.keeper.StoreFragment(f.ctx, &types.FragmentPacket{SessionId: "old", Path: "a.txt", Data: data})
+	require.NoError(t, err)
+	require.True(t, duplicate)
+	_, _, err = f.keeper.StoreFragment(f.ctx, &types.FragmentPacket{SessionId: "old", Path: "a.txt", Data: []byte("other")})
+	require.ErrorIs(t, err, types.ErrFragmentConflict)
+
+	// a record at a content id whose bytes do not hash to it is never linked
+	squatted := types.MakeContentID([]byte("wanted"))
+	require.NoError(t, f.keeper.Fragment.Set(f.ctx, squatted, types.Fragment{FragmentId: squatted, Data: []byte("junk"), Creator: f.creator}))
+	_, _, err = f.keeper.StoreFragment(f.ctx, &types.FragmentPacket{SessionId: "s1", Path: "b.txt", Data: []byte("wanted")})
+	require.ErrorIs(t, err, types.ErrFragmentConflict)
+	_, _, err = f.keeper.StoreFragment(f.ctx, &types.FragmentPacket{SessionId: "s1", Path: "b.txt", ContentId: squatted})
+	require.ErrorIs(t, err, types.ErrFragmentConflict)
+}
@@ -19,6 +19,11 @@ func (k Keeper) InitGenesis(ctx context.Context, genState types.GenesisState) er
 			return err
 		}
 	}
+	for _, ref := range genState.FragmentRefs {
+		if err := k.FragmentRefs.Set(ctx, ref.FragmentId, ref.ContentId); err != nil {
+			return err
+		}
+	}
 
 	return k.Params.Set(ctx, genState.Params)
 }
@@ -42,6 +47,12 @@ func (k Keeper) ExportGenesis(ctx context.Context) (*types.GenesisState, error)
 	}); err != nil {
 		return nil, err
 	}
+	if err := k.FragmentRefs.Walk(ctx, nil, func(fragmentID, contentID string) (stop bool, err error) {
+		genesis.FragmentRefs = append(genesis.FragmentRefs, types.FragmentRef{FragmentId: fragmentID, ContentId: contentID})
+		return false, nil
+	}); err != nil {
+		return nil, err
+	}
 
 	return genesis, nil
 }
@@ -29,6 +29,8 @@ type Keeper struct {
 
 	bankKeeper types.BankKeeper
 	Fragment   collections.Map[string, types.Fragment]
+	// FragmentRefs maps MakeFragmentID(session_id, path, index) to the content id the fragment is stored under.
+	FragmentRefs collections.Map[string, string]
 }
 
 func NewKeeper(
@@ -52,11 +54,13 @@ func NewKeeper(
 		addressCodec: addressCodec,
 		authority:    authority,
 
-		bankKeeper:  bankKeeper,
-		ibcKeeperFn: ibcKeeperFn,
-		Port:        collections.NewItem(sb, types.PortKey, "port", collections.StringValue),
-		Params:      collections.NewItem(sb, types.ParamsKey, "params", codec.CollValue[types.Params](cdc)),
-		Fragment:    collections.NewMap(sb, types.FragmentKey, "fragment", collections.StringKey, codec.CollValue[types.Fragment](cdc))}
+		bankKeeper:   bankKeeper,
+		ibcKeeperFn:  ibcKeeperFn,
+		Port:         collections.NewItem(sb, types.PortKey, "port", collections.StringValue),
+		Params:       collections.NewItem(sb, types.ParamsKey, "params", codec.CollValue[types.Params](cdc)),
+		Fragment:     collections.NewMap(sb, types.FragmentKey, "fragment", collections.StringKey, codec.CollValue[types.Fragment](cdc)),
+		FragmentRefs: collections.NewMap(sb, types.FragmentRefKey, "fragment_refs", collections.StringKey, collections.StringValue),
+	}
 
 	schema, err := sb.Build()
 	if err != nil {
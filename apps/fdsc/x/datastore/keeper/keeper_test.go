@@ -0,0 +1,57 @@
+package keeper
+
+import (
+	"testing"
+
+	"cosmossdk.io/core/address"
+	storetypes "cosmossdk.io/store/types"
+	"github.com/cosmos/cosmos-sdk/codec"
+	addresscodec "github.com/cosmos/cosmos-sdk/codec/address"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/runtime"
+	"github.com/cosmos/cosmos-sdk/testutil"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	ibckeeper "github.com/cosmos/ibc-go/v10/modules/core/keeper"
+
+	"fdsc/x/datastore/types"
+)
+
+// fixture is an in-memory datastore keeper. The IBC and bank keepers are nil.
+type fixture struct {
+	ctx          sdk.Context
+	keeper       Keeper
+	msgServer    types.MsgServer
+	addressCodec address.Codec
+
+	creator string
+}
+
+func initFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	registry := codectypes.NewInterfaceRegistry()
+	types.RegisterInterfaces(registry)
+	cdc := codec.NewProtoCodec(registry)
+	addressCodec := addresscodec.NewBech32Codec(sdk.GetConfig().GetBech32AccountAddrPrefix())
+
+	storeKey := storetypes.NewKVStoreKey(types.StoreKey)
+	ctx := testutil.DefaultContextWithDB(t, storeKey, storetypes.NewTransientStoreKey("transient_test")).Ctx
+
+	k := NewKeeper(
+		runtime.NewKVStoreService(storeKey),
+		cdc,
+		addressCodec,
+		authtypes.NewModuleAddress(types.GovModuleName),
+		func() *ibckeeper.Keeper { return nil },
+		nil,
+	)
+
+	return &fixture{
+		ctx:          ctx,
+		keeper:       k,
+		msgServer:    NewMsgServerImpl(k),
+		addressCodec: addressCodec,
+		creator:      sdk.AccAddress([]byte("creator_____________")).String(),
+	}
+}
@@ -17,6 +17,11 @@ func (k msgServer) CreateFragment(ctx context.Context, msg *types.MsgCreateFragm
 		return nil, errorsmod.Wrap(sdkerrors.ErrInvalidAddress, fmt.Sprintf("invalid address: %s", err))
 	}
 
+	// content ids and CSU fragment ids share the 64-hex shape and are written only by StoreFragment
+	if types.IsContentID(msg.FragmentId) {
+		return nil, errorsmod.Wrap(types.ErrFragmentConflict, "ids of the content id shape are reserved for CSU fragments")
+	}
+
 	// Check if the value already exists
 	ok, err := k.Fragment.Has(ctx, msg.FragmentId)
 	if err != nil {
@@ -57,6 +62,12 @@ func (k msgServer) UpdateFragment(ctx context.Context, msg *types.MsgUpdateFragm
 	if msg.Creator != val.Creator {
 		return nil, errorsmod.Wrap(sdkerrors.ErrUnauthorized, "incorrect owner")
 	}
+	if err := checkUnreferenced(val); err != nil {
+		return nil, err
+	}
+	if err := checkContentAddress(msg.FragmentId, msg.Data); err != nil {
+		return nil, err
+	}
 
 	var fragment = types.Fragment{
 		Creator:    msg.Creator,
@@ -90,6 +101,9 @@ func (k msgServer) DeleteFragment(ctx context.Context, msg *types.MsgDeleteFragm
 	if msg.Creator != val.Creator {
 		return nil, errorsmod.Wrap(sdkerrors.ErrUnauthorized, "incorrect owner")
 	}
+	if err := checkUnreferenced(val); err != nil {
+		return nil, err
+	}
 
 	if err := k.Fragment.Remove(ctx, msg.FragmentId); err != nil {
 		return nil, errorsmod.Wrap(sdkerrors.ErrLogic, "failed to remove fragment")
@@ -97,3 +111,21 @@ func (k msgServer) DeleteFragment(ctx context.Context, msg *types.MsgDeleteFragm
 
 	return &types.MsgDeleteFragmentResponse{}, nil
 }
+
+// checkUnreferenced rejects changes to a fragment that CSU uploads link to (link_count > 0).
+// A manual fragment whose bytes match its content id may have been adopted by StoreFragment.
+func checkUnreferenced(frag types.Fragment) error {
+	if frag.LinkCount > 0 {
+		return errorsmod.Wrapf(types.ErrFragmentInUse, "fragment %s has %d CSU links", frag.FragmentId, frag.LinkCount)
+	}
+	return nil
+}
+
+// checkContentAddress rejects ids shaped like a content id whose data does not hash to it,
+// so that fragments created before ids of that shape were reserved cannot be rewritten to other content.
+func checkContentAddress(fragmentID string, data []byte) error {
+	if types.IsContentID(fragmentID) && types.MakeContentID(data) != fragmentID {
+		return errorsmod.Wrap(types.ErrFragmentConflict, "a content id must be the sha256 of the data")
+	}
+	return nil
+}
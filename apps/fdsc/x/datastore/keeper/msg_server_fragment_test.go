@@ -0,0 +1,86 @@
+package keeper
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+
+	"fdsc/x/datastore/types"
+)
+
+func TestCreateFragmentRejectsContentShapedIDs(t *testing.T) {
+	f := initFixture(t)
+	data := []byte("manual")
+
+	for _, id := range []string{
+		types.MakeContentID(data),              // even with matching bytes
+		types.MakeContentID([]byte("other")),   // content address of other bytes
+		types.MakeFragmentID("s1", "a.txt", 0), // CSU position id
+	} {
+		_, err := f.msgServer.CreateFragment(f.ctx, &types.MsgCreateFragment{Creator: f.creator, FragmentId: id, Data: data})
+		require.ErrorIs(t, err, types.ErrFragmentConflict, id)
+		has, err := f.keeper.Fragment.Has(f.ctx, id)
+		require.NoError(t, err)
+		require.False(t, has)
+	}
+
+	_, err := f.msgServer.CreateFragment(f.ctx, &types.MsgCreateFragment{Creator: f.creator, FragmentId: "my-fragment", Data: data})
+	require.NoError(t, err)
+	_, err = f.msgServer.CreateFragment(f.ctx, &types.MsgCreateFragment{Creator: f.creator, FragmentId: "my-fragment", Data: data})
+	require.ErrorIs(t, err, sdkerrors.ErrInvalidRequest)
+}
+
+func TestUpdateAndDeleteFragment(t *testing.T) {
+	f := initFixture(t)
+
+	_, err := f.msgServer.CreateFragment(f.ctx, &types.MsgCreateFragment{Creator: f.creator, FragmentId: "my-fragment", Data: []byte("v1")})
+	require.NoError(t, err)
+
+	someoneElse := sdk.AccAddress([]byte("someone_else________")).String()
+	_, err = f.msgServer.UpdateFragment(f.ctx, &types.MsgUpdateFragment{Creator: someoneElse, FragmentId: "my-fragment", Data: []byte("v2")})
+	require.ErrorIs(t, err, sdkerrors.ErrUnauthorized)
+	_, err = f.msgServer.DeleteFragment(f.ctx, &types.MsgDeleteFragment{Creator: someoneElse, FragmentId: "my-fragment"})
+	require.ErrorIs(t, err, sdkerrors.ErrUnauthorized)
+
+	_, err = f.msgServer.UpdateFragment(f.ctx, &types.MsgUpdateFragment{Creator: f.creator, FragmentId: "my-fragment", Data: []byte("v2")})
+	require.NoError(t, err)
+	frag, err := f.keeper.Fragment.Get(f.ctx, "my-fragment")
+	require.NoError(t, err)
+	require.Equal(t, []byte("v2"), frag.Data)
+
+	_, err = f.msgServer.DeleteFragment(f.ctx, &types.MsgDeleteFragment{Creator: f.creator, FragmentId: "my-fragment"})
+	require.NoError(t, err)
+	_, err = f.msgServer.DeleteFragment(f.ctx, &types.MsgDeleteFragment{Creator: f.creator, FragmentId: "my-fragment"})
+	require.ErrorIs(t, err, sdkerrors.ErrKeyNotFound)
+}
+
+func TestReferencedFragmentCannotBeChanged(t *testing.T) {
+	f := initFixture(t)
+	data := []byte("shared")
+	contentID := types.MakeContentID(data)
+
+	// a manual fragment created at its content id before such ids were reserved is adopted by CSU uploads
+	require.NoError(t, f.keeper.Fragment.Set(f.ctx, contentID, types.Fragment{FragmentId: contentID, Data: data, Creator: f.creator}))
+	_, _, err := f.keeper.StoreFragment(f.ctx, &types.FragmentPacket{SessionId: "s1", Path: "a.txt", ContentId: contentID})
+	require.NoError(t, err)
+
+	_, err = f.msgServer.UpdateFragment(f.ctx, &types.MsgUpdateFragment{Creator: f.creator, FragmentId: contentID, Data: data})
+	require.ErrorIs(t, err, types.ErrFragmentInUse)
+	_, err = f.msgServer.DeleteFragment(f.ctx, &types.MsgDeleteFragment{Creator: f.creator, FragmentId: contentID})
+	require.ErrorIs(t, err, types.ErrFragmentInUse)
+
+	frag, err := f.keeper.ResolveFragment(f.ctx, types.MakeFragmentID("s1", "a.txt", 0))
+	require.NoError(t, err)
+	require.Equal(t, data, frag.Data)
+	require.Equal(t, uint64(1), frag.LinkCount)
+
+	// without references the creator may still rewrite it, but only to bytes matching the id
+	unreferenced := types.MakeContentID([]byte("alone"))
+	require.NoError(t, f.keeper.Fragment.Set(f.ctx, unreferenced, types.Fragment{FragmentId: unreferenced, Data: []byte("alone"), Creator: f.creator}))
+	_, err = f.msgServer.UpdateFragment(f.ctx, &types.MsgUpdateFragment{Creator: f.creator, FragmentId: unreferenced, Data: []byte("changed")})
+	require.ErrorIs(t, err, types.ErrFragmentConflict)
+	_, err = f.msgServer.DeleteFragment(f.ctx, &types.MsgDeleteFragment{Creator: f.creator, FragmentId: unreferenced})
+	require.NoError(t, err)
+}
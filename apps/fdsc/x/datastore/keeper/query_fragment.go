@@ -37,7 +37,8 @@ func (q queryServer) GetFragment(ctx context.Context, req *types.QueryGetFragmen
 		return nil, status.Error(codes.InvalidArgument, "invalid request")
 	}
 
-	val, err := q.k.Fragment.Get(ctx, req.FragmentId)
+	// fragment_id は content_id でも (session_id, path, index) 由来の id でも構いません
+	val, err := q.k.ResolveFragment(ctx, req.FragmentId)
 	if err != nil {
 		if errors.Is(err, collections.ErrNotFound) {
 			return nil, status.Error(codes.NotFound, "not found")
@@ -1,11 +1,8 @@
 package datastore
 
 import (
-	"bytes"
-	"errors"
 	"fmt"
 
-	"cosmossdk.io/collections"
 	errorsmod "cosmossdk.io/errors"
 
 	"fdsc/x/datastore/keeper"
@@ -127,48 +124,20 @@ func (im IBCModule) OnRecvPacket(
 	case *types.DatastorePacketData_FragmentPacket:
 		fragment := packet.FragmentPacket
 
-		// GWC送信の (session_id, path, index) から、FDSC保存用 fragment_id を決定
-		fragmentIdStr := types.MakeFragmentID(fragment.SessionId, fragment.Path, fragment.Index)
-
-		// 既に保存済みの場合はチェック
-		exists, err := im.keeper.Fragment.Has(ctx, fragmentIdStr)
+		// 断片は content_id (sha256(data)) で保存し、(session_id, path, index) の fragment_id から参照を張ります。
+		// data のないパケットは既に保存済みの content_id への参照です。
+		contentID, duplicate, err := im.keeper.StoreFragment(ctx, fragment)
 		if err != nil {
-			return channeltypes.NewErrorAcknowledgement(fmt.Errorf("failed to check fragment existence: %w", err))
-		}
-		if exists {
-			existing, err := im.keeper.Fragment.Get(ctx, fragmentIdStr)
-			if err != nil {
-				if !errors.Is(err, collections.ErrNotFound) {
-					return channeltypes.NewErrorAcknowledgement(fmt.Errorf("failed to load existing fragment: %w", err))
-				}
-			} else {
-				if bytes.Equal(existing.Data, fragment.Data) {
-					ctx.Logger().Info("Duplicate fragment received", "id", fragmentIdStr)
-					return channeltypes.NewResultAcknowledgement([]byte{byte(1)})
-				}
-				return channeltypes.NewErrorAcknowledgement(fmt.Errorf("fragment conflict: %s", fragmentIdStr))
-			}
+			return channeltypes.NewErrorAcknowledgement(err)
 		}
-
-		// 保存データを作成
-		val := types.Fragment{
-			FragmentId: fragmentIdStr,
-			Data:       fragment.Data,
-			Creator:    "ibc-sender",
-
-			// CSU metadata
-			RootProof: fragment.RootProof,
-			SessionId: fragment.SessionId,
-			Path:      fragment.Path,
-			Index:     fragment.Index,
-		}
-
-		if err := im.keeper.Fragment.Set(ctx, fragmentIdStr, val); err != nil {
-			return channeltypes.NewErrorAcknowledgement(fmt.Errorf("failed to save fragment: %w", err))
+		if duplicate {
+			ctx.Logger().Info("Duplicate fragment received", "content_id", contentID, "session_id", fragment.SessionId, "path", fragment.Path, "index", fragment.Index)
+			return channeltypes.NewResultAcknowledgement([]byte{byte(1)})
 		}
 
 		ctx.Logger().Info("Fragment Saved",
-			"id", fragmentIdStr,
+			"content_id", contentID,
+			"dedup", len(fragment.Data) == 0,
 			"session_id", fragment.SessionId,
 			"path", fragment.Path,
 			"index", fragment.Index,
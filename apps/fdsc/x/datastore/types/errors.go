@@ -9,6 +9,9 @@ import (
 // x/datastore module sentinel errors
 var (
 	ErrInvalidSigner        = errors.Register(ModuleName, 1100, "expected gov account as only signer for proposal message")
+	ErrFragmentConflict     = errors.Register(ModuleName, 1101, "fragment conflict")
+	ErrUnknownContent       = errors.Register(ModuleName, 1102, "unknown fragment content")
+	ErrFragmentInUse        = errors.Register(ModuleName, 1103, "fragment is referenced by CSU uploads")
 	ErrInvalidPacketTimeout = errors.Register(ModuleName, 1500, "invalid packet timeout")
 	ErrInvalidVersion       = errors.Register(ModuleName, 1501, "invalid version")
 )
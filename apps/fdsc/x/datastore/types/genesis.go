@@ -23,11 +23,36 @@ func (gs GenesisState) Validate() error {
 
 	for _, elem := range gs.FragmentMap {
 		index := fmt.Sprint(elem.FragmentId)
+		if index == "" {
+			return fmt.Errorf("fragment with empty fragment_id")
+		}
 		if _, ok := fragmentIndexMap[index]; ok {
 			return fmt.Errorf("duplicated index for fragment")
 		}
+		// CSU fragments are stored under their content id (or, before content addressing, under the id
+		// derived from session_id/path/index); any other key would make them unreachable from MDSC manifests.
+		if elem.SessionId != "" && index != MakeFragmentID(elem.SessionId, elem.Path, elem.Index) && index != MakeContentID(elem.Data) {
+			return fmt.Errorf("fragment %s does not match its content or session_id/path/index", index)
+		}
 		fragmentIndexMap[index] = struct{}{}
 	}
 
+	refs := make(map[string]struct{})
+	for _, ref := range gs.FragmentRefs {
+		if ref.FragmentId == "" {
+			return fmt.Errorf("fragment ref with empty fragment_id")
+		}
+		if _, ok := refs[ref.FragmentId]; ok {
+			return fmt.Errorf("duplicated fragment ref %s", ref.FragmentId)
+		}
+		if _, ok := fragmentIndexMap[ref.FragmentId]; ok {
+			return fmt.Errorf("fragment ref %s shadows a stored fragment", ref.FragmentId)
+		}
+		if _, ok := fragmentIndexMap[ref.ContentId]; !ok {
+			return fmt.Errorf("fragment ref %s points at unknown content %s", ref.FragmentId, ref.ContentId)
+		}
+		refs[ref.FragmentId] = struct{}{}
+	}
+
 	return gs.Params.Validate()
 }
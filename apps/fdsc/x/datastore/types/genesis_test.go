@@ -25,6 +25,29 @@ func TestGenesisState_Validate(t *testing.T) {
 				PortId:      types.PortID,
 				FragmentMap: []types.Fragment{{FragmentId: "0"}, {FragmentId: "1"}}},
 			valid: true,
+		}, {
+			desc: "valid CSU fragment",
+			genState: &types.GenesisState{
+				PortId: types.PortID,
+				FragmentMap: []types.Fragment{{
+					FragmentId: types.MakeFragmentID("owner-1", "index.html", 0),
+					Data:       []byte("<html></html>"),
+					SessionId:  "owner-1",
+					Path:       "index.html",
+					Index:      0,
+				}}},
+			valid: true,
+		}, {
+			desc: "CSU fragment under a foreign id",
+			genState: &types.GenesisState{
+				PortId: types.PortID,
+				FragmentMap: []types.Fragment{{
+					FragmentId: "0",
+					SessionId:  "owner-1",
+					Path:       "index.html",
+					Index:      0,
+				}}},
+			valid: false,
 		}, {
 			desc: "duplicated fragment",
 			genState: &types.GenesisState{
@@ -38,6 +61,32 @@ func TestGenesisState_Validate(t *testing.T) {
 				},
 			},
 			valid: false,
+		}, {
+			desc: "content addressed fragment with a ref",
+			genState: &types.GenesisState{
+				PortId: types.PortID,
+				FragmentMap: []types.Fragment{{
+					FragmentId: types.MakeContentID([]byte("<html></html>")),
+					Data:       []byte("<html></html>"),
+					SessionId:  "owner-1",
+					Path:       "index.html",
+					Index:      0,
+					LinkCount:  1,
+				}},
+				FragmentRefs: []types.FragmentRef{{
+					FragmentId: types.MakeFragmentID("owner-1", "index.html", 0),
+					ContentId:  types.MakeContentID([]byte("<html></html>")),
+				}}},
+			valid: true,
+		}, {
+			desc: "fragment ref to unknown content",
+			genState: &types.GenesisState{
+				PortId: types.PortID,
+				FragmentRefs: []types.FragmentRef{{
+					FragmentId: types.MakeFragmentID("owner-1", "index.html", 0),
+					ContentId:  types.MakeContentID([]byte("<html></html>")),
+				}}},
+			valid: false,
 		},
 	}
 	for _, tc := range tests {
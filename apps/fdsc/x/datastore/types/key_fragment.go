@@ -4,3 +4,6 @@ import "cosmossdk.io/collections"
 
 // FragmentKey is the prefix to retrieve all Fragment
 var FragmentKey = collections.NewPrefix("fragment/value/")
+
+// FragmentRefKey is the prefix of the (session_id, path, index) fragment id -> content id links
+var FragmentRefKey = collections.NewPrefix("fragment/ref/")
@@ -25,3 +25,25 @@ func MakeFragmentID(sessionID, path string, index uint64) string {
 	sum := sha256.Sum256(payload)
 	return hex.EncodeToString(sum[:])
 }
+
+// MakeContentID returns the content address fragments are stored under.
+// MUST stay identical to gwc/x/gateway/types.MakeContentID:
+//
+//	content_id = hex( sha256( data ) )
+func MakeContentID(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// IsContentID reports whether s has the shape of a content id (64 lowercase hex characters).
+func IsContentID(s string) bool {
+	if len(s) != 2*sha256.Size {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -45,6 +45,7 @@ import (
 	icahostkeeper "github.com/cosmos/ibc-go/v10/modules/apps/27-interchain-accounts/host/keeper"
 	ibctransferkeeper "github.com/cosmos/ibc-go/v10/modules/apps/transfer/keeper"
 	ibckeeper "github.com/cosmos/ibc-go/v10/modules/core/keeper"
+	"github.com/spf13/cast"
 
 	gatewaykeeper "gwc/x/gateway/keeper"
 	gatewayserver "gwc/x/gateway/server"
@@ -251,7 +252,10 @@ func (app *App) RegisterAPIRoutes(apiSvr *api.Server, apiConfig config.APIConfig
 	uploadDir := "./tmp/uploads"
 	tusBasePath := "/upload/tus-stream/"
 
-	tusHandler, err := gatewayserver.NewTusHandler(apiSvr.ClientCtx, app.GatewayKeeper, uploadDir, tusBasePath)
+	// gwc.decompression_limit: Executor が展開するアップロードの合計サイズ上限（バイト、未設定なら 100MB）
+	decompressionLimit := cast.ToInt64(app.appOpts.Get("gwc.decompression_limit"))
+
+	tusHandler, err := gatewayserver.NewTusHandler(apiSvr.ClientCtx, app.GatewayKeeper, uploadDir, tusBasePath, decompressionLimit)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to init TUS: %v", err))
 	}
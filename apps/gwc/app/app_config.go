@@ -83,7 +83,8 @@ var (
 		{Account: nft.ModuleName},
 		{Account: ibctransfertypes.ModuleName, Permissions: []string{authtypes.Minter, authtypes.Burner}},
 		{Account: icatypes.ModuleName},
-		{Account: gatewaymoduletypes.ModuleName, Permissions: []string{authtypes.Minter, authtypes.Burner, authtypes.Staking}}}
+		{Account: gatewaymoduletypes.ModuleName, Permissions: []string{authtypes.Minter, authtypes.Burner, authtypes.Staking}},
+		{Account: gatewaymoduletypes.StorageRewardPoolName}}
 
 	// blocked account addresses
 	blockAccAddrs = []string{
@@ -93,6 +94,7 @@ var (
 		stakingtypes.BondedPoolName,
 		stakingtypes.NotBondedPoolName,
 		nft.ModuleName,
+		gatewaymoduletypes.StorageRewardPoolName,
 		// We allow the following module accounts to receive funds:
 		// govtypes.ModuleName
 	}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"gwc/x/gateway/types"
+)
+
+// CmdComputeRootProof はアップロード（ZIP / tar / tar.gz / 単一ファイル）からRootProofと期待断片数・総バイト数を
+// オフラインで計算します。
+// イレイジャーコーディングのセッションではパリティシャードも含めて、圧縮セッションでは圧縮後の断片で計算するため、
+// commit-root-proof に渡す値をこのコマンドで求めてください。
+func CmdComputeRootProof() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compute-root-proof [upload-file] [fragment-size]",
+		Short: "Compute the RootProof, fragment count and total bytes of an upload (zip, tar, tar.gz or a single file) for commit-root-proof (offline)",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fragSize, err := strconv.Atoi(args[1])
+			if err != nil {
+				return err
+			}
+			erasureData, err := cmd.Flags().GetUint32(flagErasureDataShards)
+			if err != nil {
+				return err
+			}
+			erasureParity, err := cmd.Flags().GetUint32(flagErasureParityShards)
+			if err != nil {
+				return err
+			}
+			if (erasureData == 0) != (erasureParity == 0) {
+				return fmt.Errorf("--%s and --%s must be set together", flagErasureDataShards, flagErasureParityShards)
+			}
+			compression, err := cmd.Flags().GetString(flagCompression)
+			if err != nil {
+				return err
+			}
+			limit, err := cmd.Flags().GetInt64(flagDecompressionLimit)
+			if err != nil {
+				return err
+			}
+			format, err := cmd.Flags().GetString(flagArchiveFormat)
+			if err != nil {
+				return err
+			}
+			targetPath, err := cmd.Flags().GetString(flagTargetPath)
+			if err != nil {
+				return err
+			}
+
+			archive, err := types.OpenArchive(args[0], types.ArchiveSpec{Format: format, TargetPath: targetPath})
+			if err != nil {
+				return err
+			}
+			// Executor と同じストリーム処理（1 パス目）で計算するため、アーカイブ全体をメモリに読み込みません
+			stream, err := types.OpenArchiveStream(archive, types.FragmentLayout{
+				FragmentSize:        fragSize,
+				Compression:         compression,
+				ErasureDataShards:   int(erasureData),
+				ErasureParityShards: int(erasureParity),
+			}, limit)
+			if err != nil {
+				return err
+			}
+			defer stream.Close()
+
+			out, err := json.MarshalIndent(map[string]any{
+				"root_proof_hex":          stream.RootProofHex,
+				"expected_fragment_count": stream.FragmentCount,
+				"expected_total_bytes":    stream.TotalBytes,
+			}, "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(out))
+			return nil
+		},
+	}
+	cmd.Flags().Uint32(flagErasureDataShards, 0, "Reed-Solomon data shards per stripe (k) of the session")
+	cmd.Flags().Uint32(flagErasureParityShards, 0, "Reed-Solomon parity shards per stripe (m) of the session")
+	cmd.Flags().String(flagCompression, "", "compression codec of the session (gzip or zstd)")
+	cmd.Flags().Int64(flagDecompressionLimit, types.DefaultDecompressionLimit, "maximum total decompressed size of the upload in bytes (must not exceed the gateway's gwc.decompression_limit)")
+	cmd.Flags().String(flagArchiveFormat, types.ArchiveFormatZip, "upload format: zip, tar, tar.gz or file (same as the TUS archive_format metadata)")
+	cmd.Flags().String(flagTargetPath, "", "path of the file in the site when --format=file")
+	return cmd
+}
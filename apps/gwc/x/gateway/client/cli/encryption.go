@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"gwc/x/gateway/types"
+)
+
+const (
+	flagContentKey   = "content-key"
+	flagReaderKey    = "reader-key"
+	flagReaderPubkey = "reader-pubkey"
+)
+
+// CmdGenReaderKey は暗号化プロジェクトの読者用 X25519 鍵ペアを生成します（オフライン）。
+// 公開鍵を init-session --reader-pubkey に渡すと、その読者向けにコンテンツ鍵がラップされます。
+func CmdGenReaderKey() *cobra.Command {
+	return &cobra.Command{
+		Use:   "gen-reader-key",
+		Short: "Generate an X25519 reader key pair for encrypted projects (offline)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+			if err != nil {
+				return err
+			}
+			out, err := json.MarshalIndent(map[string]string{
+				"private_key": hex.EncodeToString(priv.Bytes()),
+				"public_key":  hex.EncodeToString(priv.PublicKey().Bytes()),
+			}, "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(out))
+			return nil
+		},
+	}
+}
+
+// CmdEncryptZip はZIP内の各ファイルをプロジェクトのコンテンツ鍵でチャンクごとに暗号化します（オフライン）。
+// 出力ZIPをアップロードし、compute-root-proof もこのZIPに対して実行してください。
+func CmdEncryptZip() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "encrypt-zip [zip-file] [output-zip] [fragment-size]",
+		Short: "Encrypt every file of a ZIP chunk-wise with the project content key for an encrypted session (offline)",
+		Long:  "Encrypts every file of a ZIP chunk-wise with the project content key. If --content-key is omitted a new key is generated and printed; keep it to encrypt later versions and to wrap it for readers.",
+		Args:  cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			zipBytes, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			fragSize, err := strconv.Atoi(args[2])
+			if err != nil {
+				return err
+			}
+			key, err := readContentKeyFlag(cmd)
+			if err != nil {
+				return err
+			}
+			generated := key == nil
+			if generated {
+				key = make([]byte, types.ContentKeySize)
+				if _, err := rand.Read(key); err != nil {
+					return err
+				}
+			}
+
+			encrypted, err := types.EncryptZip(zipBytes, key, fragSize)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(args[1], encrypted, 0o600); err != nil {
+				return err
+			}
+
+			res := map[string]string{"output": args[1], "cipher_suite": types.CipherSuiteAES256GCMChunkV1}
+			if generated {
+				res["content_key"] = hex.EncodeToString(key)
+			}
+			out, err := json.MarshalIndent(res, "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(out))
+			return nil
+		},
+	}
+	cmd.Flags().String(flagContentKey, "", "hex project content key (32 bytes); generated if empty")
+	return cmd
+}
+
+// readContentKeyFlag parses --content-key; it returns nil if the flag is empty.
+func readContentKeyFlag(cmd *cobra.Command) ([]byte, error) {
+	raw, err := cmd.Flags().GetString(flagContentKey)
+	if err != nil || raw == "" {
+		return nil, err
+	}
+	key, err := hex.DecodeString(raw)
+	if err != nil || len(key) != types.ContentKeySize {
+		return nil, fmt.Errorf("--%s must be %d hex-encoded bytes", flagContentKey, types.ContentKeySize)
+	}
+	return key, nil
+}
+
+// readEncryptionFlags returns the cipher suite and wrapped keys for init-session from
+// --content-key and --reader-pubkey. The content key itself never leaves the client.
+func readEncryptionFlags(cmd *cobra.Command) (string, []types.WrappedKey, error) {
+	key, err := readContentKeyFlag(cmd)
+	if err != nil {
+		return "", nil, err
+	}
+	readers, err := cmd.Flags().GetStringSlice(flagReaderPubkey)
+	if err != nil {
+		return "", nil, err
+	}
+	if key == nil {
+		if len(readers) > 0 {
+			return "", nil, fmt.Errorf("--%s requires --%s", flagReaderPubkey, flagContentKey)
+		}
+		return "", nil, nil
+	}
+
+	wrapped := make([]types.WrappedKey, 0, len(readers))
+	for _, r := range readers {
+		pub, err := hex.DecodeString(r)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid reader public key %q: %w", r, err)
+		}
+		wk, err := types.WrapContentKey(key, pub)
+		if err != nil {
+			return "", nil, err
+		}
+		wrapped = append(wrapped, wk)
+	}
+	return types.CipherSuiteAES256GCMChunkV1, wrapped, nil
+}
+
+// resolveContentKey returns the content key for decryption: --content-key, or the manifest entry
+// unwrapped with --reader-key.
+func resolveContentKey(cmd *cobra.Command, wrapped []types.WrappedKey) ([]byte, error) {
+	key, err := readContentKeyFlag(cmd)
+	if err != nil || key != nil {
+		return key, err
+	}
+	raw, err := cmd.Flags().GetString(flagReaderKey)
+	if err != nil {
+		return nil, err
+	}
+	if raw == "" {
+		return nil, fmt.Errorf("the file is encrypted: pass --%s or --%s", flagContentKey, flagReaderKey)
+	}
+	priv, err := hex.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s: %w", flagReaderKey, err)
+	}
+	wk, ok := types.FindWrappedKey(wrapped, priv)
+	if !ok {
+		return nil, fmt.Errorf("the manifest has no wrapped key for this reader")
+	}
+	return types.UnwrapContentKey(wk, priv)
+}
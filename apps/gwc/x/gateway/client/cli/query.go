@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -11,6 +12,11 @@ import (
 	"gwc/x/gateway/types"
 )
 
+const (
+	flagStatus = "status"
+	flagState  = "state"
+)
+
 // GetQueryCmd returns the cli query commands for this module
 func GetQueryCmd(queryRoute string) *cobra.Command {
 	// Use all query commands under the subdirectory
@@ -29,9 +35,18 @@ func GetQueryCmd(queryRoute string) *cobra.Command {
 	// 【追加】セッション関連のクエリコマンド
 	cmd.AddCommand(CmdSession())
 	cmd.AddCommand(CmdSessionsByOwner())
+	cmd.AddCommand(CmdSessions())
+	cmd.AddCommand(CmdOwnerUsage())
+	cmd.AddCommand(CmdDepositInfo())
+	cmd.AddCommand(CmdExecutors())
+	cmd.AddCommand(CmdSessionFragments())
+	cmd.AddCommand(CmdFragmentContents())
 
 	// 追加: ダウンロードコマンド
 	cmd.AddCommand(CmdDownload())
+	cmd.AddCommand(CmdComputeRootProof())
+	cmd.AddCommand(CmdEncryptZip())
+	cmd.AddCommand(CmdGenReaderKey())
 
 	return cmd
 }
@@ -150,9 +165,15 @@ func CmdSessionsByOwner() *cobra.Command {
 				return err
 			}
 
+			state, err := readSessionStateFlag(cmd)
+			if err != nil {
+				return err
+			}
+
 			params := &types.QuerySessionsByOwnerRequest{
 				Owner:      args[0],
 				Pagination: pageReq,
+				State:      state,
 			}
 
 			res, err := queryClient.SessionsByOwner(cmd.Context(), params)
@@ -164,7 +185,235 @@ func CmdSessionsByOwner() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().String(flagState, "", "session state to include (init,root_committed,distributing,finalizing,closed_success,closed_failed)")
 	flags.AddQueryFlagsToCmd(cmd)
 	flags.AddPaginationFlagsToCmd(cmd, "sessions")
 	return cmd
-}
\ No newline at end of file
+}
+
+// 全セッションを状態で絞り込んで取得するコマンド（運用者向け）
+func CmdSessions() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "query all sessions (filter with --state distributing,finalizing,...)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			queryClient := types.NewQueryClient(clientCtx)
+
+			pageReq, err := client.ReadPageRequest(cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			state, err := readSessionStateFlag(cmd)
+			if err != nil {
+				return err
+			}
+
+			res, err := queryClient.Sessions(cmd.Context(), &types.QuerySessionsRequest{
+				State:      state,
+				Pagination: pageReq,
+			})
+			if err != nil {
+				return err
+			}
+
+			return clientCtx.PrintProto(res)
+		},
+	}
+
+	cmd.Flags().String(flagState, "", "session state to include (init,root_committed,distributing,finalizing,closed_success,closed_failed)")
+	flags.AddQueryFlagsToCmd(cmd)
+	flags.AddPaginationFlagsToCmd(cmd, "all-sessions")
+	return cmd
+}
+
+// 所有者の使用量と per-owner 上限を取得するコマンド
+func CmdOwnerUsage() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "owner-usage [owner-address]",
+		Short: "query an owner's open sessions and rolling-window usage against the per-owner quotas",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			queryClient := types.NewQueryClient(clientCtx)
+
+			res, err := queryClient.OwnerUsage(cmd.Context(), &types.QueryOwnerUsageRequest{Owner: args[0]})
+			if err != nil {
+				return err
+			}
+
+			return clientCtx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+	return cmd
+}
+
+// デポジット単価とエスクロー・報酬プール残高を取得するコマンド
+func CmdDepositInfo() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "deposit-info",
+		Short: "shows the storage deposit price and the escrow / reward pool balances",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			queryClient := types.NewQueryClient(clientCtx)
+
+			res, err := queryClient.DepositInfo(cmd.Context(), &types.QueryDepositInfoRequest{})
+			if err != nil {
+				return err
+			}
+
+			return clientCtx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+	return cmd
+}
+
+// executor レジストリを取得するコマンド
+func CmdExecutors() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "executors",
+		Short: "shows the CSU executor registry",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			queryClient := types.NewQueryClient(clientCtx)
+
+			pageReq, err := client.ReadPageRequest(cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			res, err := queryClient.Executors(cmd.Context(), &types.QueryExecutorsRequest{Pagination: pageReq})
+			if err != nil {
+				return err
+			}
+
+			return clientCtx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+	flags.AddPaginationFlagsToCmd(cmd, "executors")
+	return cmd
+}
+
+// readSessionStateFlag は --state を SessionState に変換します（未指定は UNSPECIFIED = 全状態）。
+func readSessionStateFlag(cmd *cobra.Command) (types.SessionState, error) {
+	raw, err := cmd.Flags().GetString(flagState)
+	if err != nil {
+		return types.SessionState_SESSION_STATE_UNSPECIFIED, err
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return types.SessionState_SESSION_STATE_UNSPECIFIED, nil
+	}
+	v, ok := types.SessionState_value["SESSION_STATE_"+strings.ToUpper(raw)]
+	if !ok {
+		return types.SessionState_SESSION_STATE_UNSPECIFIED, fmt.Errorf("unknown session state: %s", raw)
+	}
+	return types.SessionState(v), nil
+}
+
+// セッションの断片配送記録をステータスで絞り込んで取得するコマンド
+func CmdSessionFragments() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "session-fragments [session-id]",
+		Short: "query per-fragment delivery records of a session (filter with --status pending,acked,error,timed_out)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			queryClient := types.NewQueryClient(clientCtx)
+
+			pageReq, err := client.ReadPageRequest(cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			statusesRaw, err := cmd.Flags().GetStringSlice(flagStatus)
+			if err != nil {
+				return err
+			}
+			statuses := make([]types.FragmentStatus, 0, len(statusesRaw))
+			for _, s := range statusesRaw {
+				name := "FRAGMENT_STATUS_" + strings.ToUpper(strings.TrimSpace(s))
+				v, ok := types.FragmentStatus_value[name]
+				if !ok {
+					return fmt.Errorf("unknown fragment status: %s", s)
+				}
+				statuses = append(statuses, types.FragmentStatus(v))
+			}
+
+			params := &types.QuerySessionFragmentsRequest{
+				SessionId:  args[0],
+				Statuses:   statuses,
+				Pagination: pageReq,
+			}
+
+			res, err := queryClient.SessionFragments(cmd.Context(), params)
+			if err != nil {
+				return err
+			}
+
+			return clientCtx.PrintProto(res)
+		},
+	}
+
+	cmd.Flags().StringSlice(flagStatus, nil, "comma separated fragment statuses to include (pending,acked,error,timed_out)")
+	flags.AddQueryFlagsToCmd(cmd)
+	flags.AddPaginationFlagsToCmd(cmd, "session-fragments")
+	return cmd
+}
+
+// content_id ごとに断片を保持している FDSC チャネルを取得するコマンド（dedup 確認用）
+func CmdFragmentContents() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fragment-contents [content-id]...",
+		Short: "query which FDSC channels hold fragments with the given content ids (hex sha256 of the bytes)",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			queryClient := types.NewQueryClient(clientCtx)
+
+			res, err := queryClient.FragmentContents(cmd.Context(), &types.QueryFragmentContentsRequest{ContentIds: args})
+			if err != nil {
+				return err
+			}
+
+			return clientCtx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+	return cmd
+}
@@ -29,11 +29,19 @@ type ManifestResponse struct {
 	Manifest struct {
 		Files map[string]struct {
 			MimeType  string `json:"mime_type"`
+			Size      uint64 `json:"size,string"`
 			Fragments []struct {
 				FdscId     string `json:"fdsc_id"`
 				FragmentId string `json:"fragment_id"`
 			} `json:"fragments"`
+			ErasureDataShards   uint32 `json:"erasure_data_shards"`
+			ErasureParityShards uint32 `json:"erasure_parity_shards"`
+			CipherSuite         string `json:"cipher_suite"`
+			FragmentSize        uint64 `json:"fragment_size,string"`
+			Compression         string `json:"compression"`
 		} `json:"files"`
+		FragmentSize uint64             `json:"fragment_size,string"`
+		WrappedKeys  []types.WrappedKey `json:"wrapped_keys"`
 	} `json:"manifest"`
 }
 
@@ -73,14 +81,14 @@ func CmdDownload() *cobra.Command {
 			queryClient := types.NewQueryClient(clientCtx)
 
 			// GWCからストレージエンドポイント（MDSC/FDSCのURL）一覧を取得
-			res, err := queryClient.StorageEndpoints(context.Background(), &types.QueryStorageEndpointsRequest{})
+			storageInfos, err := types.QueryAllStorageEndpoints(context.Background(), queryClient)
 			if err != nil {
 				return fmt.Errorf("failed to query storage endpoints: %w", err)
 			}
 
 			// エンドポイントをマップ化（ChainIDとChannelIDの両方で引けるようにする）
 			endpointMap := make(map[string]string)
-			for _, info := range res.StorageInfos {
+			for _, info := range storageInfos {
 				if info.ChainId != "" {
 					endpointMap[info.ChainId] = info.ApiEndpoint
 				}
@@ -89,7 +97,7 @@ func CmdDownload() *cobra.Command {
 
 			// MDSCのエンドポイントを特定
 			var mdscURL string
-			for _, info := range res.StorageInfos {
+			for _, info := range storageInfos {
 				if info.ConnectionType == "mdsc" {
 					mdscURL = info.ApiEndpoint
 					break
@@ -141,56 +149,103 @@ func CmdDownload() *cobra.Command {
 			totalFragments := len(fileInfo.Fragments)
 			fmt.Printf("📦 Found %d fragments. Downloading...\n", totalFragments)
 
-			// --- 3. FDSCから断片を並列ダウンロード ---
-			chunks := make([][]byte, totalFragments)
-			var wg sync.WaitGroup
-			errChan := make(chan error, totalFragments)
-
-			for i, frag := range fileInfo.Fragments {
-				wg.Add(1)
-				go func(idx int, fragID, fdscID string) {
-					defer wg.Done()
-
-					// FDSCのエンドポイント解決
-					fdscURL, ok := endpointMap[fdscID]
-					if !ok {
-						errChan <- fmt.Errorf("endpoint for %s not found in registry", fdscID)
-						return
-					}
-
-					fragUrl := fmt.Sprintf("%s/fdsc/datastore/v1/fragment/%s", fdscURL, fragID)
+			// ファイルを格納したセッションの fragment_size（古いマニフェストではマニフェスト全体の値）
+			fragmentSize := fileInfo.FragmentSize
+			if fragmentSize == 0 {
+				fragmentSize = mResp.Manifest.FragmentSize
+			}
 
-					// Fragmentデータの取得
-					fResp, err := http.Get(fragUrl)
-					if err != nil {
-						errChan <- fmt.Errorf("failed to fetch fragment %s: %w", fragID, err)
-						return
-					}
-					defer fResp.Body.Close()
+			// 暗号化ファイルは取得前に鍵を解決しておきます
+			var contentKey []byte
+			if fileInfo.CipherSuite != "" {
+				if err := types.ValidateCipherSuite(fileInfo.CipherSuite); err != nil {
+					return err
+				}
+				if contentKey, err = resolveContentKey(cmd, mResp.Manifest.WrappedKeys); err != nil {
+					return err
+				}
+			}
 
-					var fr FragmentResponse
-					if err := json.NewDecoder(fResp.Body).Decode(&fr); err != nil {
-						errChan <- fmt.Errorf("failed to decode fragment %s: %w", fragID, err)
-						return
+			// --- 3. FDSCから断片を並列ダウンロード ---
+			var chunks [][]byte
+			if k, m := int(fileInfo.ErasureDataShards), int(fileInfo.ErasureParityShards); k > 0 && m > 0 {
+				// イレイジャーコーディングされたファイル: 取得できた任意の k シャードからストライプごとに復元します
+				fmt.Printf("🧩 Erasure-coded file (%d+%d). Reconstructing...\n", k, m)
+				shards := types.CollectErasureShards(fileInfo.Size, fragmentSize, k, m, 16, func(index uint64) ([]byte, error) {
+					if index >= uint64(totalFragments) {
+						return nil, fmt.Errorf("shard %d is missing from the manifest", index)
 					}
-
-					// Base64デコード
-					data, err := base64.StdEncoding.DecodeString(fr.Fragment.Data)
+					frag := fileInfo.Fragments[index]
+					data, err := downloadFragment(endpointMap, frag.FdscId, frag.FragmentId)
 					if err != nil {
-						errChan <- fmt.Errorf("failed to base64 decode fragment %s: %w", fragID, err)
-						return
+						fmt.Printf("   ⚠️ shard %d unavailable: %v\n", index, err)
+						return nil, err
 					}
+					return data, nil
+				})
+				data, err := types.ReconstructErasureFile(shards, fileInfo.Size, fragmentSize, k, m)
+				if err != nil {
+					return fmt.Errorf("failed to reconstruct erasure-coded file: %w", err)
+				}
+				chunks = [][]byte{data}
+			} else {
+				chunks = make([][]byte, totalFragments)
+				var wg sync.WaitGroup
+				errChan := make(chan error, totalFragments)
+
+				for i, frag := range fileInfo.Fragments {
+					wg.Add(1)
+					go func(idx int, fragID, fdscID string) {
+						defer wg.Done()
+
+						data, err := downloadFragment(endpointMap, fdscID, fragID)
+						if err != nil {
+							errChan <- err
+							return
+						}
+
+						chunks[idx] = data
+						fmt.Printf("   ✅ Fetched fragment %d/%d\n", idx+1, totalFragments)
+					}(i, frag.FragmentId, frag.FdscId)
+				}
+
+				wg.Wait()
+				close(errChan)
 
-					chunks[idx] = data
-					fmt.Printf("   ✅ Fetched fragment %d/%d\n", idx+1, totalFragments)
-				}(i, frag.FragmentId, frag.FdscId)
+				if len(errChan) > 0 {
+					return <-errChan
+				}
 			}
 
-			wg.Wait()
-			close(errChan)
+			// 暗号化ファイルは結合した暗号文をチャンクごとに復号します
+			if contentKey != nil {
+				var ciphertext []byte
+				for _, chunk := range chunks {
+					ciphertext = append(ciphertext, chunk...)
+				}
+				plain, err := types.DecryptFileContent(contentKey, filename, ciphertext, int(fragmentSize))
+				if err != nil {
+					return fmt.Errorf("failed to decrypt %s: %w", filename, err)
+				}
+				chunks = [][]byte{plain}
+				fmt.Println("🔓 Decrypted")
+			}
 
-			if len(errChan) > 0 {
-				return <-errChan
+			// 圧縮ファイルは結合した断片（gzip メンバー / zstd フレームの連結）を展開します
+			if fileInfo.Compression != "" {
+				if err := types.ValidateCompression(fileInfo.Compression); err != nil {
+					return err
+				}
+				var stored []byte
+				for _, chunk := range chunks {
+					stored = append(stored, chunk...)
+				}
+				plain, err := types.DecompressFileContent(fileInfo.Compression, stored)
+				if err != nil {
+					return fmt.Errorf("failed to decompress %s: %w", filename, err)
+				}
+				chunks = [][]byte{plain}
+				fmt.Printf("🗜️ Decompressed (%s)\n", fileInfo.Compression)
 			}
 
 			// --- 4. 結合と保存 ---
@@ -228,7 +283,39 @@ func CmdDownload() *cobra.Command {
 
 	cmd.Flags().String(FlagOutput, ".", "Directory to save the downloaded file")
 	cmd.Flags().String(FlagProject, "", "Project name containing the file (required)")
+	cmd.Flags().String(flagContentKey, "", "hex project content key for encrypted files")
+	cmd.Flags().String(flagReaderKey, "", "hex X25519 reader private key; unwraps the content key from the manifest")
 	flags.AddQueryFlagsToCmd(cmd)
 
 	return cmd
-}
\ No newline at end of file
+}
+
+// downloadFragment はFDSCから1つの断片を取得し、Base64デコードしたデータを返します
+func downloadFragment(endpointMap map[string]string, fdscID, fragID string) ([]byte, error) {
+	// FDSCのエンドポイント解決
+	fdscURL, ok := endpointMap[fdscID]
+	if !ok {
+		return nil, fmt.Errorf("endpoint for %s not found in registry", fdscID)
+	}
+
+	fragUrl := fmt.Sprintf("%s/fdsc/datastore/v1/fragment/%s", fdscURL, fragID)
+
+	// Fragmentデータの取得
+	fResp, err := http.Get(fragUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch fragment %s: %w", fragID, err)
+	}
+	defer fResp.Body.Close()
+
+	var fr FragmentResponse
+	if err := json.NewDecoder(fResp.Body).Decode(&fr); err != nil {
+		return nil, fmt.Errorf("failed to decode fragment %s: %w", fragID, err)
+	}
+
+	// Base64デコード
+	data, err := base64.StdEncoding.DecodeString(fr.Fragment.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to base64 decode fragment %s: %w", fragID, err)
+	}
+	return data, nil
+}
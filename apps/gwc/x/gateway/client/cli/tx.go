@@ -5,15 +5,19 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"gwc/x/gateway/types"
 
+	"cosmossdk.io/x/feegrant"
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/client/flags"
 	"github.com/cosmos/cosmos-sdk/client/tx"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/authz"
 )
 
 var (
@@ -22,6 +26,25 @@ var (
 
 const (
 	flagPacketTimeoutTimestamp = "packet-timeout-timestamp"
+	flagUploadTokenHash        = "upload-token-hash"
+	flagMimeOverride           = "mime-override"
+	flagEmptyFile              = "empty-file"
+	flagDeclaredBytes          = "declared-bytes"
+	flagExecutor               = "executor"
+	flagMoniker                = "moniker"
+	flagMaxBytes               = "max-bytes"
+	flagMaxMsgs                = "max-msgs"
+	flagFeeSpendLimit          = "fee-spend-limit"
+	flagNoFeegrant             = "no-feegrant"
+	flagPlacement              = "placement"
+	flagCapacityWeight         = "capacity-weight"
+	flagReplicationFactor      = "replication-factor"
+	flagErasureDataShards      = "erasure-data-shards"
+	flagErasureParityShards    = "erasure-parity-shards"
+	flagCompression            = "compression"
+	flagDecompressionLimit     = "decompression-limit"
+	flagArchiveFormat          = "format"
+	flagTargetPath             = "target-path"
 )
 
 // GetTxCmd returns the transaction commands for this module
@@ -38,11 +61,16 @@ func GetTxCmd() *cobra.Command {
 	cmd.AddCommand(CmdInitSession())
 	cmd.AddCommand(CmdCommitRootProof())
 	cmd.AddCommand(CmdDistributeBatch())
+	cmd.AddCommand(CmdRedistributeFragments())
 	cmd.AddCommand(CmdFinalizeAndCloseSession())
 	cmd.AddCommand(CmdAbortAndCloseSession())
+	cmd.AddCommand(CmdCancelSession())
+	cmd.AddCommand(CmdExtendSessionDeadline())
+	cmd.AddCommand(CmdGrantSession())
 
 	// existing
 	cmd.AddCommand(CmdRegisterStorage())
+	cmd.AddCommand(CmdSetExecutor())
 	cmd.AddCommand(CmdUpdateParams())
 
 	return cmd
@@ -53,7 +81,7 @@ func GetTxCmd() *cobra.Command {
 func CmdInitSession() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "init-session [fragment-size] [deadline-unix] [num-fdsc-chains]",
-		Short: "Initialize a new CSU session (returns session_id and session_upload_token)",
+		Short: "Initialize a new CSU session (returns session_id; the upload token is generated by the client)",
 		Args:  cobra.ExactArgs(3),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			clientCtx, err := client.GetClientTxContext(cmd)
@@ -77,11 +105,76 @@ func CmdInitSession() *cobra.Command {
 				return err
 			}
 
+			// hex(sha256(token)) of a client-generated upload token (required; the token itself never goes on-chain)
+			tokenHash, err := cmd.Flags().GetString(flagUploadTokenHash)
+			if err != nil {
+				return err
+			}
+
+			// total bytes to upload (basis of the storage deposit)
+			declaredBytes, err := cmd.Flags().GetUint64(flagDeclaredBytes)
+			if err != nil {
+				return err
+			}
+
+			// preferred executor (optional; empty = chosen by the chain)
+			preferredExecutor, err := cmd.Flags().GetString(flagExecutor)
+			if err != nil {
+				return err
+			}
+
+			// fragment placement strategy (round-robin / weighted / path-hash / lru)
+			placement, err := readPlacementFlag(cmd)
+			if err != nil {
+				return err
+			}
+
+			// number of FDSC chains each fragment is stored on
+			replicationFactor, err := cmd.Flags().GetUint32(flagReplicationFactor)
+			if err != nil {
+				return err
+			}
+
+			// Reed-Solomon erasure coding (k data + m parity shards per stripe; 0+0 = plain chunks)
+			erasureData, err := cmd.Flags().GetUint32(flagErasureDataShards)
+			if err != nil {
+				return err
+			}
+			erasureParity, err := cmd.Flags().GetUint32(flagErasureParityShards)
+			if err != nil {
+				return err
+			}
+
+			// client-side encryption: the content key is only used locally to wrap it for each reader
+			cipherSuite, wrappedKeys, err := readEncryptionFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			// per-fragment compression codec (gzip / zstd; empty = raw)
+			compression, err := cmd.Flags().GetString(flagCompression)
+			if err != nil {
+				return err
+			}
+
 			msg := types.MsgInitSession{
-				Owner:         clientCtx.GetFromAddress().String(),
-				FragmentSize:  fragSize,
-				DeadlineUnix:  deadlineUnix,
-				NumFdscChains: uint32(numFdscChains),
+				Owner:              clientCtx.GetFromAddress().String(),
+				FragmentSize:       fragSize,
+				DeadlineUnix:       deadlineUnix,
+				NumFdscChains:      uint32(numFdscChains),
+				UploadTokenHashHex: tokenHash,
+				DeclaredTotalBytes: declaredBytes,
+				PreferredExecutor:  preferredExecutor,
+				PlacementStrategy:  placement,
+				ReplicationFactor:  replicationFactor,
+
+				ErasureDataShards:   erasureData,
+				ErasureParityShards: erasureParity,
+
+				CipherSuite: cipherSuite,
+				WrappedKeys: wrappedKeys,
+
+				Compression: compression,
 			}
 			if err := msg.ValidateBasic(); err != nil {
 				return err
@@ -89,26 +182,67 @@ func CmdInitSession() *cobra.Command {
 			return tx.GenerateOrBroadcastTxCLI(clientCtx, cmd.Flags(), &msg)
 		},
 	}
+	cmd.Flags().String(flagUploadTokenHash, "", "hex(sha256(token)) of a locally generated upload token (required); send the token itself as TUS upload_token")
+	cmd.Flags().Uint64(flagDeclaredBytes, 0, "total bytes to upload; sizes the storage deposit (required when the deposit price is non-zero)")
+	cmd.Flags().String(flagExecutor, "", "preferred executor address; if empty the chain assigns an enabled executor")
+	cmd.Flags().String(flagPlacement, "round-robin", "fragment placement strategy: round-robin, weighted, path-hash or lru")
+	cmd.Flags().Uint32(flagReplicationFactor, 1, "number of distinct FDSC chains each fragment is stored on (max: params.max_replication_factor)")
+	cmd.Flags().Uint32(flagErasureDataShards, 0, "Reed-Solomon data shards per stripe (k); requires --erasure-parity-shards and replication factor 1")
+	cmd.Flags().Uint32(flagErasureParityShards, 0, "Reed-Solomon parity shards per stripe (m); any k of the k+m shards rebuild a stripe")
+	cmd.Flags().String(flagContentKey, "", "hex project content key; marks the session as encrypted (upload the output of encrypt-zip)")
+	cmd.Flags().StringSlice(flagReaderPubkey, nil, "hex X25519 public keys of the readers the content key is wrapped for (see gen-reader-key)")
+	cmd.Flags().String(flagCompression, "", "compress every fragment with this codec (gzip or zstd); compute the RootProof with the same --compression")
 	flags.AddTxFlagsToCmd(cmd)
 	return cmd
 }
 
-// commit-root-proof [session-id] [root-proof-hex]
+// readPlacementFlag parses --placement into a PlacementStrategy.
+func readPlacementFlag(cmd *cobra.Command) (types.PlacementStrategy, error) {
+	raw, err := cmd.Flags().GetString(flagPlacement)
+	if err != nil {
+		return types.PlacementStrategy_PLACEMENT_STRATEGY_UNSPECIFIED, err
+	}
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", "round-robin":
+		return types.PlacementStrategy_PLACEMENT_STRATEGY_ROUND_ROBIN, nil
+	case "weighted":
+		return types.PlacementStrategy_PLACEMENT_STRATEGY_WEIGHTED, nil
+	case "path-hash":
+		return types.PlacementStrategy_PLACEMENT_STRATEGY_PATH_HASH, nil
+	case "lru":
+		return types.PlacementStrategy_PLACEMENT_STRATEGY_LEAST_RECENTLY_USED, nil
+	default:
+		return types.PlacementStrategy_PLACEMENT_STRATEGY_UNSPECIFIED, fmt.Errorf("unknown placement strategy: %s", raw)
+	}
+}
+
+// commit-root-proof [session-id] [root-proof-hex] [expected-fragment-count] [expected-total-bytes]
 func CmdCommitRootProof() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "commit-root-proof [session-id] [root-proof-hex]",
-		Short: "Commit RootProof for a session",
-		Args:  cobra.ExactArgs(2),
+		Use:   "commit-root-proof [session-id] [root-proof-hex] [expected-fragment-count] [expected-total-bytes]",
+		Short: "Commit RootProof (and the fragment count / total bytes it covers) for a session",
+		Args:  cobra.ExactArgs(4),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			clientCtx, err := client.GetClientTxContext(cmd)
 			if err != nil {
 				return err
 			}
 
+			fragCount, err := strconv.ParseUint(args[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			totalBytes, err := strconv.ParseUint(args[3], 10, 64)
+			if err != nil {
+				return err
+			}
+
 			msg := types.MsgCommitRootProof{
-				Owner:        clientCtx.GetFromAddress().String(),
-				SessionId:    args[0],
-				RootProofHex: args[1],
+				Owner:                 clientCtx.GetFromAddress().String(),
+				SessionId:             args[0],
+				RootProofHex:          args[1],
+				ExpectedFragmentCount: fragCount,
+				ExpectedTotalBytes:    totalBytes,
 			}
 			if err := msg.ValidateBasic(); err != nil {
 				return err
@@ -120,8 +254,9 @@ func CmdCommitRootProof() *cobra.Command {
 	return cmd
 }
 
-// distribute-batch [session-id] [items.json]
-func CmdDistributeBatch() *cobra.Command {
+// readDistributeItems loads DistributeItems from an items.json file:
+// {"items":[{"path","index","fragment_bytes_base64","fragment_proof","file_size","file_proof","target_fdsc_channel"}]}
+func readDistributeItems(path string) ([]types.DistributeItem, error) {
 	type itemJSON struct {
 		Path                string            `json:"path"`
 		Index               uint64            `json:"index"`
@@ -129,11 +264,47 @@ func CmdDistributeBatch() *cobra.Command {
 		FragmentProof       types.MerkleProof `json:"fragment_proof"`
 		FileSize            uint64            `json:"file_size"`
 		FileProof           types.MerkleProof `json:"file_proof"`
+		TargetFdscChannel   string            `json:"target_fdsc_channel"`
 	}
 	type body struct {
 		Items []itemJSON `json:"items"`
 	}
 
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc body
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Items) == 0 {
+		return nil, fmt.Errorf("items.json has no items")
+	}
+
+	items := make([]types.DistributeItem, 0, len(doc.Items))
+	for _, it := range doc.Items {
+		fragBytes, err := decodeBase64(it.FragmentBytesBase64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fragment_bytes_base64: %w", err)
+		}
+		fragProof := it.FragmentProof
+		fileProof := it.FileProof
+		items = append(items, types.DistributeItem{
+			Path:              it.Path,
+			Index:             it.Index,
+			FragmentBytes:     fragBytes,
+			FragmentProof:     &fragProof,
+			FileSize:          it.FileSize,
+			FileProof:         &fileProof,
+			TargetFdscChannel: it.TargetFdscChannel,
+		})
+	}
+	return items, nil
+}
+
+// distribute-batch [session-id] [items.json]
+func CmdDistributeBatch() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "distribute-batch [session-id] [items.json]",
 		Short: "Distribute fragments to FDSC (executor signer). Proofs are supplied via json.",
@@ -144,35 +315,44 @@ func CmdDistributeBatch() *cobra.Command {
 				return err
 			}
 
-			b, err := os.ReadFile(args[1])
+			items, err := readDistributeItems(args[1])
 			if err != nil {
 				return err
 			}
-			var doc body
-			if err := json.Unmarshal(b, &doc); err != nil {
+
+			msg := types.MsgDistributeBatch{
+				Executor:  clientCtx.GetFromAddress().String(),
+				SessionId: args[0],
+				Items:     items,
+			}
+			if err := msg.ValidateBasic(); err != nil {
 				return err
 			}
-			if len(doc.Items) == 0 {
-				return fmt.Errorf("items.json has no items")
+			return tx.GenerateOrBroadcastTxCLI(clientCtx, cmd.Flags(), &msg)
+		},
+	}
+	flags.AddTxFlagsToCmd(cmd)
+	return cmd
+}
+
+// redistribute-fragments [session-id] [items.json]
+func CmdRedistributeFragments() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "redistribute-fragments [session-id] [items.json]",
+		Short: "Re-send failed (error ack / timed out) fragments to FDSC (executor signer). Same json format as distribute-batch.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
 			}
 
-			items := make([]types.DistributeItem, 0, len(doc.Items))
-			for _, it := range doc.Items {
-				fragBytes, err := decodeBase64(it.FragmentBytesBase64)
-				if err != nil {
-					return fmt.Errorf("invalid fragment_bytes_base64: %w", err)
-				}
-				items = append(items, types.DistributeItem{
-					Path:          it.Path,
-					Index:         it.Index,
-					FragmentBytes: fragBytes,
-					FragmentProof: &it.FragmentProof,
-					FileSize:      it.FileSize,
-					FileProof:     &it.FileProof,
-				})
+			items, err := readDistributeItems(args[1])
+			if err != nil {
+				return err
 			}
 
-			msg := types.MsgDistributeBatch{
+			msg := types.MsgRedistributeFragments{
 				Executor:  clientCtx.GetFromAddress().String(),
 				SessionId: args[0],
 				Items:     items,
@@ -187,51 +367,43 @@ func CmdDistributeBatch() *cobra.Command {
 	return cmd
 }
 
-// finalize-and-close [session-id] [manifest.json]
+// finalize-and-close [session-id] [project-name] [version]
 func CmdFinalizeAndCloseSession() *cobra.Command {
-	type manifestJSON struct {
-		ProjectName  string                    `json:"project_name"`
-		Version      string                    `json:"version"`
-		Files        []types.ManifestFileEntry `json:"files"`
-		RootProof    string                    `json:"root_proof"`
-		FragmentSize uint64                    `json:"fragment_size"`
-		Owner        string                    `json:"owner"`
-		SessionId    string                    `json:"session_id"`
-	}
-
 	cmd := &cobra.Command{
-		Use:   "finalize-and-close [session-id] [manifest.json]",
-		Short: "Send manifest to MDSC and close on MDSC ACK (executor signer)",
-		Args:  cobra.ExactArgs(2),
+		Use:   "finalize-and-close [session-id] [project-name] [version]",
+		Short: "Build the manifest on-chain, send it to MDSC and close on MDSC ACK (executor signer)",
+		Args:  cobra.ExactArgs(3),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			clientCtx, err := client.GetClientTxContext(cmd)
 			if err != nil {
 				return err
 			}
 
-			b, err := os.ReadFile(args[1])
+			rawOverrides, err := cmd.Flags().GetStringArray(flagMimeOverride)
 			if err != nil {
 				return err
 			}
-			var mj manifestJSON
-			if err := json.Unmarshal(b, &mj); err != nil {
-				return err
+			overrides := make([]types.MimeTypeOverride, 0, len(rawOverrides))
+			for _, raw := range rawOverrides {
+				p, m, ok := strings.Cut(raw, "=")
+				if !ok {
+					return fmt.Errorf("invalid --%s %q (expected path=mime/type)", flagMimeOverride, raw)
+				}
+				overrides = append(overrides, types.MimeTypeOverride{Path: p, MimeType: m})
 			}
 
-			manifest := types.ManifestPacket{
-				ProjectName:  mj.ProjectName,
-				Version:      mj.Version,
-				Files:        mj.Files,
-				RootProof:    mj.RootProof,
-				FragmentSize: mj.FragmentSize,
-				Owner:        mj.Owner,
-				SessionId:    mj.SessionId,
+			emptyFiles, err := cmd.Flags().GetStringArray(flagEmptyFile)
+			if err != nil {
+				return err
 			}
 
 			msg := types.MsgFinalizeAndCloseSession{
-				Executor:  clientCtx.GetFromAddress().String(),
-				SessionId: args[0],
-				Manifest:  manifest,
+				Executor:      clientCtx.GetFromAddress().String(),
+				SessionId:     args[0],
+				ProjectName:   args[1],
+				Version:       args[2],
+				MimeOverrides: overrides,
+				EmptyFiles:    emptyFiles,
 			}
 			if err := msg.ValidateBasic(); err != nil {
 				return err
@@ -239,6 +411,8 @@ func CmdFinalizeAndCloseSession() *cobra.Command {
 			return tx.GenerateOrBroadcastTxCLI(clientCtx, cmd.Flags(), &msg)
 		},
 	}
+	cmd.Flags().StringArray(flagMimeOverride, nil, "override the MIME type of a path (path=mime/type); repeatable")
+	cmd.Flags().StringArray(flagEmptyFile, nil, "path of a zero-byte file to include in the manifest; repeatable")
 	flags.AddTxFlagsToCmd(cmd)
 	return cmd
 }
@@ -270,6 +444,170 @@ func CmdAbortAndCloseSession() *cobra.Command {
 	return cmd
 }
 
+// cancel-session [session-id] [reason]
+func CmdCancelSession() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cancel-session [session-id] [reason]",
+		Short: "Cancel an in-flight session, refund its deposit and revoke the CSU grants (owner signer)",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			msg := types.MsgCancelSession{
+				Owner:     clientCtx.GetFromAddress().String(),
+				SessionId: args[0],
+			}
+			if len(args) > 1 {
+				msg.Reason = args[1]
+			}
+			if err := msg.ValidateBasic(); err != nil {
+				return err
+			}
+			return tx.GenerateOrBroadcastTxCLI(clientCtx, cmd.Flags(), &msg)
+		},
+	}
+	flags.AddTxFlagsToCmd(cmd)
+	return cmd
+}
+
+// extend-deadline [session-id] [new-deadline-unix]
+func CmdExtendSessionDeadline() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "extend-deadline [session-id] [new-deadline-unix]",
+		Short: "Extend the deadline of an in-flight session (owner signer, bounded by max_session_duration_seconds)",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			newDeadline, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid new-deadline-unix: %w", err)
+			}
+
+			msg := types.MsgExtendSessionDeadline{
+				Owner:           clientCtx.GetFromAddress().String(),
+				SessionId:       args[0],
+				NewDeadlineUnix: newDeadline,
+			}
+			if err := msg.ValidateBasic(); err != nil {
+				return err
+			}
+			return tx.GenerateOrBroadcastTxCLI(clientCtx, cmd.Flags(), &msg)
+		},
+	}
+	flags.AddTxFlagsToCmd(cmd)
+	return cmd
+}
+
+// grant-session [session-id]
+// 所有者からセッションの executor への SessionBoundAuthorization 一式と feegrant を 1 Tx で付与します。
+func CmdGrantSession() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "grant-session [session-id]",
+		Short: "Grant the session executor session-bound authz for every CSU message plus a feegrant, in one tx (owner signer)",
+		Long: `Grant the session executor a SessionBoundAuthorization for each executor message
+(DistributeBatch, RedistributeFragments, FinalizeAndCloseSession, AbortAndCloseSession) and a BasicAllowance feegrant.
+All grants expire at the session deadline. --max-bytes / --max-msgs set the payload byte and message budgets of the
+DistributeBatch and RedistributeFragments grants (0 = unlimited). The feegrant is skipped if one already exists.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+			owner := clientCtx.GetFromAddress()
+
+			res, err := types.NewQueryClient(clientCtx).Session(cmd.Context(), &types.QuerySessionRequest{SessionId: args[0]})
+			if err != nil {
+				return err
+			}
+			sess := res.Session
+			if sess.Owner != owner.String() {
+				return fmt.Errorf("session %s is owned by %s, not %s", sess.SessionId, sess.Owner, owner.String())
+			}
+			executor, err := sdk.AccAddressFromBech32(sess.Executor)
+			if err != nil {
+				return fmt.Errorf("invalid session executor: %w", err)
+			}
+			expiration := time.Unix(sess.DeadlineUnix, 0)
+			if !expiration.After(time.Now()) {
+				return fmt.Errorf("session deadline already passed: %d", sess.DeadlineUnix)
+			}
+
+			maxBytes, err := cmd.Flags().GetUint64(flagMaxBytes)
+			if err != nil {
+				return err
+			}
+			maxMsgs, err := cmd.Flags().GetUint64(flagMaxMsgs)
+			if err != nil {
+				return err
+			}
+
+			var msgs []sdk.Msg
+			for _, msgTypeURL := range types.CSUAuthorizedMsgTypeURLs() {
+				a := &types.SessionBoundAuthorization{
+					SessionId:   sess.SessionId,
+					MsgTypeUrl:  msgTypeURL,
+					ExpiresUnix: sess.DeadlineUnix,
+				}
+				if msgTypeURL == types.MsgTypeURLDistributeBatch || msgTypeURL == types.MsgTypeURLRedistributeFragments {
+					a.BytesRemaining = maxBytes
+					a.MsgsRemaining = maxMsgs
+				}
+				grant, err := authz.NewMsgGrant(owner, executor, a, &expiration)
+				if err != nil {
+					return err
+				}
+				msgs = append(msgs, grant)
+			}
+
+			noFeegrant, err := cmd.Flags().GetBool(flagNoFeegrant)
+			if err != nil {
+				return err
+			}
+			if !noFeegrant {
+				// 既存の feegrant があると MsgGrantAllowance は失敗するため付与しません
+				existing, err := feegrant.NewQueryClient(clientCtx).Allowance(cmd.Context(), &feegrant.QueryAllowanceRequest{
+					Granter: owner.String(),
+					Grantee: executor.String(),
+				})
+				if err == nil && existing.Allowance != nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "feegrant %s -> %s already exists; skipping\n", owner.String(), executor.String())
+				} else {
+					spendLimitStr, err := cmd.Flags().GetString(flagFeeSpendLimit)
+					if err != nil {
+						return err
+					}
+					spendLimit, err := sdk.ParseCoinsNormalized(spendLimitStr)
+					if err != nil {
+						return fmt.Errorf("invalid --%s: %w", flagFeeSpendLimit, err)
+					}
+					allowance := &feegrant.BasicAllowance{SpendLimit: spendLimit, Expiration: &expiration}
+					grant, err := feegrant.NewMsgGrantAllowance(allowance, owner, executor)
+					if err != nil {
+						return err
+					}
+					msgs = append(msgs, grant)
+				}
+			}
+
+			return tx.GenerateOrBroadcastTxCLI(clientCtx, cmd.Flags(), msgs...)
+		},
+	}
+	flags.AddTxFlagsToCmd(cmd)
+	cmd.Flags().Uint64(flagMaxBytes, 0, "payload byte budget of the DistributeBatch / RedistributeFragments grants (0 = unlimited)")
+	cmd.Flags().Uint64(flagMaxMsgs, 0, "message budget of the DistributeBatch / RedistributeFragments grants (0 = unlimited)")
+	cmd.Flags().String(flagFeeSpendLimit, "", "feegrant spend limit (e.g. 1000000stake); empty = unlimited")
+	cmd.Flags().Bool(flagNoFeegrant, false, "do not include a feegrant")
+	return cmd
+}
+
 // --- existing cmds ---
 
 func CmdRegisterStorage() *cobra.Command {
@@ -283,11 +621,17 @@ func CmdRegisterStorage() *cobra.Command {
 				return err
 			}
 
+			capacityWeight, err := cmd.Flags().GetUint64(flagCapacityWeight)
+			if err != nil {
+				return err
+			}
+
 			info := types.StorageInfo{
 				ChannelId:      args[0],
 				ChainId:        args[1],
 				ApiEndpoint:    args[2],
 				ConnectionType: args[3],
+				CapacityWeight: capacityWeight,
 			}
 
 			msg := types.MsgRegisterStorage{
@@ -301,6 +645,40 @@ func CmdRegisterStorage() *cobra.Command {
 			return tx.GenerateOrBroadcastTxCLI(clientCtx, cmd.Flags(), &msg)
 		},
 	}
+	cmd.Flags().Uint64(flagCapacityWeight, 0, "relative capacity of the storage chain for weighted placement (0 = keep current / 1)")
+	flags.AddTxFlagsToCmd(cmd)
+	return cmd
+}
+
+// set-executor [address] [enabled]: executor レジストリの登録・有効化・無効化（authority のみ）
+func CmdSetExecutor() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set-executor [address] [enabled]",
+		Short: "Register, enable or disable a CSU executor (authority only)",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			enabled, err := strconv.ParseBool(args[1])
+			if err != nil {
+				return fmt.Errorf("enabled must be true or false: %w", err)
+			}
+			moniker, err := cmd.Flags().GetString(flagMoniker)
+			if err != nil {
+				return err
+			}
+
+			msg := types.NewMsgSetExecutor(clientCtx.GetFromAddress().String(), args[0], enabled, moniker)
+			if err := msg.ValidateBasic(); err != nil {
+				return err
+			}
+			return tx.GenerateOrBroadcastTxCLI(clientCtx, cmd.Flags(), msg)
+		},
+	}
+	cmd.Flags().String(flagMoniker, "", "human readable name of the executor")
 	flags.AddTxFlagsToCmd(cmd)
 	return cmd
 }
@@ -0,0 +1,56 @@
+package executor
+
+import (
+	"context"
+	"time"
+
+	"gwc/x/gateway/types"
+
+	"github.com/cosmos/cosmos-sdk/client"
+)
+
+// dedupableContents は、セッションの FDSC チャネルのうち replicas 個以上が既に保持している content_id を返します。
+// これらの断片はバイト列を送らず、content_id だけの参照として配布できます。
+func dedupableContents(clientCtx client.Context, contentIDs []string, channels []string, replicas int) (map[string]bool, error) {
+	allowed := make(map[string]bool, len(channels))
+	for _, ch := range channels {
+		allowed[ch] = true
+	}
+
+	var ids []string
+	seen := make(map[string]bool, len(contentIDs))
+	for _, id := range contentIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	queryClient := types.NewQueryClient(clientCtx)
+	holders := make(map[string]int)
+	for i := 0; i < len(ids); i += types.MaxFragmentContentsQuery {
+		end := i + types.MaxFragmentContentsQuery
+		if end > len(ids) {
+			end = len(ids)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		res, err := queryClient.FragmentContents(ctx, &types.QueryFragmentContentsRequest{ContentIds: ids[i:end]})
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		for _, loc := range res.Locations {
+			if allowed[loc.ChannelId] {
+				holders[loc.ContentId]++
+			}
+		}
+	}
+
+	out := make(map[string]bool)
+	for id, n := range holders {
+		if n >= replicas {
+			out[id] = true
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gwc/x/gateway/types"
+)
+
+// previousManifest は MDSC が保持しているプロジェクトのマニフェストのうち、差分判定に使う項目です。
+type previousManifest struct {
+	Files map[string]struct {
+		Size                uint64            `json:"size,string"`
+		FileRoot            string            `json:"file_root"`
+		Fragments           []json.RawMessage `json:"fragments"`
+		ErasureDataShards   uint32            `json:"erasure_data_shards"`
+		ErasureParityShards uint32            `json:"erasure_parity_shards"`
+		CipherSuite         string            `json:"cipher_suite"`
+		FragmentSize        uint64            `json:"fragment_size,string"`
+		Compression         string            `json:"compression"`
+	} `json:"files"`
+	FragmentSize uint64 `json:"fragment_size,string"`
+}
+
+// fetchPreviousManifest は MDSC から現在のマニフェストを取得します。プロジェクトが未登録なら nil を返します。
+func fetchPreviousManifest(storageInfos []*types.StorageInfo, projectName string) (*previousManifest, error) {
+	var mdscURL string
+	for _, info := range storageInfos {
+		if info.ConnectionType == "mdsc" && info.ApiEndpoint != "" {
+			mdscURL = strings.TrimSuffix(info.ApiEndpoint, "/")
+			break
+		}
+	}
+	if mdscURL == "" {
+		return nil, fmt.Errorf("MDSC endpoint not found")
+	}
+
+	manifestURL := fmt.Sprintf("%s/mdsc/metastore/v1/manifest/%s", mdscURL, url.PathEscape(projectName))
+	httpClient := &http.Client{Timeout: 15 * time.Second}
+	resp, err := httpClient.Get(manifestURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("manifest query failed (status: %d)", resp.StatusCode)
+	}
+
+	var mResp struct {
+		Manifest previousManifest `json:"manifest"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&mResp); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	return &mResp.Manifest, nil
+}
+
+// reusableFiles は前バージョンから変更のないファイルを返します。
+// file_root / サイズに加えて、断片の形（fragment_size・イレイジャーコーディング・暗号スイート・圧縮）が
+// このセッションと同じファイルだけを対象にします（MDSC も受信時に同じ条件で照合します）。
+func reusableFiles(prev *previousManifest, files []types.CSUFileProofData, session types.Session) []types.ReusedFile {
+	if prev == nil {
+		return nil
+	}
+	var out []types.ReusedFile
+	for _, f := range files {
+		stored, ok := prev.Files[f.Path]
+		if !ok || len(stored.Fragments) == 0 {
+			continue
+		}
+		storedFragmentSize := stored.FragmentSize
+		if storedFragmentSize == 0 {
+			storedFragmentSize = prev.FragmentSize
+		}
+		if stored.FileRoot != f.FileRoot || stored.Size != f.FileSize ||
+			storedFragmentSize != session.FragmentSize ||
+			stored.ErasureDataShards != session.ErasureDataShards ||
+			stored.ErasureParityShards != session.ErasureParityShards ||
+			stored.CipherSuite != session.CipherSuite ||
+			stored.Compression != session.Compression {
+			continue
+		}
+		out = append(out, types.ReusedFile{
+			Path:          f.Path,
+			FileSize:      f.FileSize,
+			FileRoot:      f.FileRoot,
+			FileProof:     f.FileProof,
+			FragmentCount: f.FragmentCount,
+		})
+	}
+	return out
+}
@@ -2,7 +2,6 @@ package executor
 
 import (
 	"context"
-	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"mime"
@@ -24,8 +23,11 @@ import (
 
 const MaxFragmentsPerBatch = 50
 
-// ExecuteSessionUpload はZIPファイルの解凍、断片化、各ストレージへの配布、およびマニフェストの登録を一括して実行します。
-func ExecuteSessionUpload(clientCtx client.Context, sessionID string, zipFilePath string, projectName string, version string) error {
+// ExecuteSessionUpload はアップロード（ZIP / tar / tar.gz / 単一ファイル。spec で指定）の展開、断片化、
+// 各ストレージへの配布、およびマニフェストの登録を一括して実行します。
+// アップロードはディスク上から 2 パスで読み出し（types.ArchiveStream）、展開後の合計サイズは decompressionLimit
+// （0 なら types.DefaultDecompressionLimit）までに制限します。
+func ExecuteSessionUpload(clientCtx client.Context, sessionID string, uploadPath string, spec types.ArchiveSpec, projectName string, version string, decompressionLimit int64) error {
 	fmt.Printf("[Executor] 🚀 セッション処理を開始します: ID=%s\n", sessionID)
 
 	queryClient := types.NewQueryClient(clientCtx)
@@ -45,7 +47,7 @@ func ExecuteSessionUpload(clientCtx client.Context, sessionID string, zipFilePat
 
 	// 2. 有効なすべての FDSC 情報を動的に取得
 	fmt.Printf("[Executor] 🔍 ストレージエンドポイントを解決中...\n")
-	resStorage, err := queryClient.StorageEndpoints(ctx, &types.QueryStorageEndpointsRequest{})
+	storageInfos, channelHealth, err := types.QueryStorageTopology(ctx, queryClient)
 	if err != nil {
 		return fmt.Errorf("ストレージエンドポイントのクエリに失敗しました: %w", err)
 	}
@@ -56,7 +58,7 @@ func ExecuteSessionUpload(clientCtx client.Context, sessionID string, zipFilePat
 	}
 	var datastores []fdscInfo
 
-	for _, info := range resStorage.StorageInfos {
+	for _, info := range storageInfos {
 		if info.ConnectionType == "datastore" && info.ChannelId != "" {
 			datastores = append(datastores, fdscInfo{
 				chainId:   info.ChainId,
@@ -81,42 +83,113 @@ func ExecuteSessionUpload(clientCtx client.Context, sessionID string, zipFilePat
 		datastores = datastores[:session.NumFdscChains]
 	}
 
+	// 3. オンチェーンの既定ルーティングと同じヘルス判定で degraded なチャネルを除外
+	channelIDs := make([]string, 0, len(datastores))
+	for _, ds := range datastores {
+		channelIDs = append(channelIDs, ds.channelId)
+	}
+	routable := types.RoutableChannels(channelIDs, channelHealth, time.Now().Unix())
+	if len(routable) < len(datastores) {
+		routableDatastores := make([]fdscInfo, 0, len(routable))
+		for _, ds := range datastores {
+			for _, ch := range routable {
+				if ds.channelId == ch {
+					routableDatastores = append(routableDatastores, ds)
+					break
+				}
+			}
+		}
+		fmt.Printf("[Executor] ⚠️ degraded なFDSCチャネルを除外します (使用: %d / %d)\n", len(routableDatastores), len(datastores))
+		datastores = routableDatastores
+	}
+
 	for _, ds := range datastores {
 		fmt.Printf("[Executor] ✅ 使用するFDSC: %s (Channel: %s)\n", ds.chainId, ds.channelId)
 	}
 	// -------------------------------------------------------
 
-	// 3. ZIPファイルの読み込み
-	zipBytes, err := os.ReadFile(zipFilePath)
-	if err != nil {
+	// 3. アップロードの 1 パス目: ディスク上のアーカイブを 1 度読み、断片のハッシュから Merkle Tree を構築します。
+	// アーカイブ全体や断片のバイト列はメモリに保持しません（断片は 2 パス目でバッチごとに読み直します）
+	layout := session.FragmentLayout()
+	if layout.FragmentSize <= 0 {
+		layout.FragmentSize = 1024 * 1024
+	}
+	if decompressionLimit <= 0 {
+		decompressionLimit = types.DefaultDecompressionLimit
+	}
+	if _, err := os.Stat(uploadPath); err != nil {
 		return abortSession(clientCtx, &session, "FAILED_READ_ZIP")
 	}
 
-	fragmentSize := int(session.FragmentSize)
-	if fragmentSize <= 0 {
-		fragmentSize = 1024 * 1024
+	// 暗号化セッションでは ZIP はオーナーが暗号化済み（types.EncryptZip）です。Executor は鍵を持たないため
+	// 復号はせず、各断片が暗号化チャンクの形をしているかだけを確認し、暗号文のまま配布します
+	if session.IsEncrypted() {
+		fmt.Printf("[Executor] 🔐 暗号化セッション (%s, 読者鍵: %d)\n", session.CipherSuite, len(session.WrappedKeys))
+	}
+	// 圧縮セッションでは各ファイルを圧縮チャンク（1 チャンク = 1 断片）に分割します。
+	// オーナーも compute-root-proof --compression で同じ断片から RootProof を計算しています
+	if session.IsCompressed() {
+		fmt.Printf("[Executor] 🗜️ 断片を圧縮します (%s)\n", session.Compression)
+	}
+	// イレイジャーコーディングのセッションでは、各ファイルの断片を k データ + m パリティのシャード列に置き換えます
+	if session.IsErasureCoded() {
+		fmt.Printf("[Executor] 🧩 Reed-Solomon 符号化します (%d+%d)\n", session.ErasureDataShards, session.ErasureParityShards)
 	}
 
-	fmt.Printf("[Executor] 📦 ZIP処理中... fragment_size=%d\n", fragmentSize)
-	files, err := types.ProcessZipAndSplit(zipBytes, fragmentSize)
+	// 4. CSU Proof の構築
+	format := spec.Format
+	if format == "" {
+		format = types.ArchiveFormatZip
+	}
+	fmt.Printf("[Executor] 📦 %s 処理中... fragment_size=%d, 展開上限=%d\n", format, layout.FragmentSize, decompressionLimit)
+	fmt.Printf("[Executor] 🌳 Merkle Tree を構築中 (1 パス目)...\n")
+	archive, err := types.OpenArchive(uploadPath, spec)
 	if err != nil {
+		fmt.Printf("[Executor] ❌ アップロードを開けません: %v\n", err)
 		return abortSession(clientCtx, &session, "INVALID_ZIP_CONTENT")
 	}
-
-	// 4. CSU Proof の構築
-	fmt.Printf("[Executor] 🌳 Merkle Tree を構築中...\n")
-	proofData, err := types.BuildCSUProofs(files)
+	stream, err := types.OpenArchiveStream(archive, layout, decompressionLimit)
 	if err != nil {
-		return abortSession(clientCtx, &session, "PROOF_GENERATION_FAILED")
+		fmt.Printf("[Executor] ❌ アップロードの処理に失敗しました: %v\n", err)
+		return abortSession(clientCtx, &session, "INVALID_ZIP_CONTENT")
 	}
+	defer stream.Close()
 
-	if proofData.RootProofHex != session.RootProofHex {
-		fmt.Printf("[Executor] ❌ RootProof 不一致! OnChain=%s, Computed=%s\n", session.RootProofHex, proofData.RootProofHex)
+	if stream.RootProofHex != session.RootProofHex {
+		fmt.Printf("[Executor] ❌ RootProof 不一致! OnChain=%s, Computed=%s\n", session.RootProofHex, stream.RootProofHex)
 		return abortSession(clientCtx, &session, "ROOT_PROOF_MISMATCH")
 	}
 
+	if stream.FragmentCount != session.ExpectedFragmentCount || stream.TotalBytes != session.ExpectedTotalBytes {
+		fmt.Printf("[Executor] ❌ 断片数/総バイト数 不一致! OnChain=%d/%d, Computed=%d/%d\n",
+			session.ExpectedFragmentCount, session.ExpectedTotalBytes, stream.FragmentCount, stream.TotalBytes)
+		return abortSession(clientCtx, &session, "EXPECTED_TOTALS_MISMATCH")
+	}
+
+	// 差分デプロイ: MDSC の現在のマニフェストと file_root を比較し、変更のないファイルは配布せず再利用します。
+	// RootProof と期待総数は新しいツリー全体に対するもので、再利用ファイルは Finalize で file_proof により証明します
+	fileProofs := stream.FileProofs()
+	var reusedFiles []types.ReusedFile
+	prevManifest, err := fetchPreviousManifest(storageInfos, projectName)
+	if err != nil {
+		fmt.Printf("[Executor] ⚠️ 前バージョンのマニフェストを取得できません。全ファイルを配布します: %v\n", err)
+	} else {
+		reusedFiles = reusableFiles(prevManifest, fileProofs, session)
+	}
+	reusedPaths := make(map[string]bool, len(reusedFiles))
+	var reusedFragments uint64
+	for _, f := range reusedFiles {
+		reusedPaths[f.Path] = true
+		reusedFragments += f.FragmentCount
+	}
+	distributed := func(path string) bool { return !reusedPaths[path] }
+	if len(reusedPaths) > 0 {
+		fmt.Printf("[Executor] ♻️ 前バージョンから変更のないファイル: %d / %d (断片 %d 件を省略)\n",
+			len(reusedFiles), len(fileProofs), reusedFragments)
+	}
+
 	executorAddr := strings.Trim(session.Executor, "\"")
-	totalItems := len(proofData.Fragments)
+	totalItems := int(stream.FragmentCount - reusedFragments)
 	fmt.Printf("[Executor] 📤 配布対象断片数: %d\n", totalItems)
 
 	cleanOwner := strings.Trim(session.Owner, "\"")
@@ -128,29 +201,32 @@ func ExecuteSessionUpload(clientCtx client.Context, sessionID string, zipFilePat
 	var txfBatch tx.Factory
 	txfInitialized := false
 
-	// 5. 断片データの配布
-	for i := 0; i < totalItems; i += MaxFragmentsPerBatch {
-		end := i + MaxFragmentsPerBatch
-		if end > totalItems {
-			end = totalItems
-		}
+	// round-robin 以外の配置戦略（weighted / path-hash / lru）はチェーン側の状態を使うため、送信先はチェーンに任せます。
+	// イレイジャーコーディングではストライプ内のシャードを別々のFDSCに置く必要があるため、同様にチェーンに任せます
+	preassignTargets := !session.IsErasureCoded() &&
+		(session.PlacementStrategy == types.PlacementStrategy_PLACEMENT_STRATEGY_UNSPECIFIED ||
+			session.PlacementStrategy == types.PlacementStrategy_PLACEMENT_STRATEGY_ROUND_ROBIN)
 
-		batchItems := make([]types.DistributeItem, 0, end-i)
-		for j, frag := range proofData.Fragments[i:end] {
-			dsIdx := (i + j) % len(datastores)
-			targetDS := datastores[dsIdx]
-
-			batchItems = append(batchItems, types.DistributeItem{
-				Path:              frag.Path,
-				Index:             frag.Index,
-				FragmentBytes:     frag.FragmentBytes,
-				FragmentProof:     frag.FragmentProof,
-				FileSize:          frag.FileSize,
-				FileProof:         frag.FileProof,
-				TargetFdscChannel: targetDS.channelId,
-			})
+	// 既にFDSCが保持している断片は content_id の参照だけを送ります（イレイジャーコーディングでは全シャードを送信）
+	var dedup map[string]bool
+	if !session.IsErasureCoded() {
+		dedup, err = dedupableContents(clientCtx, stream.ContentIDs(distributed), channelIDs, session.ReplicaCount())
+		if err != nil {
+			fmt.Printf("[Executor] ⚠️ dedup 索引の取得に失敗しました。全断片を送信します: %v\n", err)
+			dedup = nil
 		}
+	}
+	var dedupItems int
 
+	// 5. 断片データの配布（2 パス目）: アーカイブを読み直し、MaxFragmentsPerBatch 件ずつ送信します
+	var sent int
+	// 送信側の失敗（Factory 準備 / Tx 送信）とアーカイブの読み出し失敗とで扱いを分けます
+	var factoryErr, txErr error
+	batchItems := make([]types.DistributeItem, 0, MaxFragmentsPerBatch)
+	sendBatch := func() error {
+		if len(batchItems) == 0 {
+			return nil
+		}
 		msg := &types.MsgDistributeBatch{
 			Executor:  executorAddr,
 			SessionId: sessionID,
@@ -161,7 +237,8 @@ func ExecuteSessionUpload(clientCtx client.Context, sessionID string, zipFilePat
 			fmt.Printf("[Executor] 🧪 初回バッチのガス見積もりを実行中...\n")
 			f, err := prepareFactory(clientCtx, executorAddr, ownerAddr, msg)
 			if err != nil {
-				return fmt.Errorf("Factory準備エラー: %w", err)
+				factoryErr = fmt.Errorf("Factory準備エラー: %w", err)
+				return factoryErr
 			}
 			txfBatch = f
 			txfInitialized = true
@@ -169,60 +246,102 @@ func ExecuteSessionUpload(clientCtx client.Context, sessionID string, zipFilePat
 			txfBatch = txfBatch.WithSequence(txfBatch.Sequence() + 1)
 		}
 
-		fmt.Printf("[Executor] 📡 バッチ送信中 %d-%d...\n", i, end)
+		fmt.Printf("[Executor] 📡 バッチ送信中 %d-%d...\n", sent, sent+len(batchItems))
 		txRes, err := broadcastAndConfirm(clientCtx, txfBatch, msg)
 		if err != nil {
-			return abortSession(clientCtx, &session, "DISTRIBUTE_TX_FAILED")
+			txErr = err
+			return err
 		}
 		fmt.Printf("[Executor] ✅ バッチ送信成功 TxHash: %s\n", txRes.TxHash)
+		sent += len(batchItems)
+		batchItems = make([]types.DistributeItem, 0, MaxFragmentsPerBatch)
+		return nil
 	}
 
-	// 6. マニフェストファイル情報の構築
-	var manifestFiles []types.ManifestFileEntry
-	fragmentsByPath := make(map[string][]*types.PacketFragmentMapping)
-
-	for i, frag := range proofData.Fragments {
-		calculatedID := calculateFragmentID(sessionID, frag.Path, frag.Index)
-		dsIdx := i % len(datastores)
-		actualFdscID := datastores[dsIdx].chainId
+	err = stream.ForEachFragment(distributed, func(frag types.CSUFragmentProofData) error {
+		targetChannel := ""
+		if preassignTargets {
+			targetChannel = datastores[(sent+len(batchItems))%len(datastores)].channelId
+		}
 
-		mapping := &types.PacketFragmentMapping{
-			FdscId:     actualFdscID,
-			FragmentId: calculatedID,
+		item := types.DistributeItem{
+			Path:              frag.Path,
+			Index:             frag.Index,
+			FragmentBytes:     frag.FragmentBytes,
+			FragmentProof:     frag.FragmentProof,
+			FileSize:          frag.FileSize,
+			FileProof:         frag.FileProof,
+			TargetFdscChannel: targetChannel,
+		}
+		if contentID := types.MakeContentID(frag.FragmentBytes); dedup[contentID] {
+			// 送信先はチェーンが保持チャネルから選びます
+			item.FragmentBytes = nil
+			item.ContentId = contentID
+			item.TargetFdscChannel = ""
+			dedupItems++
+		}
+		batchItems = append(batchItems, item)
+		if len(batchItems) < MaxFragmentsPerBatch {
+			return nil
 		}
-		fragmentsByPath[frag.Path] = append(fragmentsByPath[frag.Path], mapping)
+		return sendBatch()
+	})
+	if err == nil {
+		err = sendBatch()
+	}
+	switch {
+	case factoryErr != nil:
+		return factoryErr
+	case txErr != nil:
+		return abortSession(clientCtx, &session, "DISTRIBUTE_TX_FAILED")
+	case err != nil:
+		fmt.Printf("[Executor] ❌ 断片の読み出しに失敗しました: %v\n", err)
+		return abortSession(clientCtx, &session, "INVALID_ZIP_CONTENT")
+	}
+
+	if dedupItems > 0 {
+		fmt.Printf("[Executor] ♻️ 既存断片の参照で配布: %d / %d\n", dedupItems, totalItems)
+	}
+
+	// 5b. ACK を待ち、失敗した断片は再送する（参照が拒否された断片はバイト列付きで再送されます）。
+	// 再送する断片はアーカイブから読み直します
+	readFragment := func(ref fragRef) (*types.CSUFragmentProofData, error) {
+		return stream.Fragment(ref.path, ref.index)
+	}
+	if err := redistributeFailedFragments(clientCtx, &session, executorAddr, ownerAddr, readFragment); err != nil {
+		fmt.Printf("[Executor] ❌ 断片の配送に失敗しました: %v\n", err)
+		return abortSession(clientCtx, &session, "DISTRIBUTE_TX_FAILED")
 	}
 
-	for _, file := range files {
+	// 6. MIME タイプの上書き指定
+	// マニフェストの断片配置はチェーンが ACK 記録から組み立てるため、
+	// クライアントはチェーン既定値と異なる MIME タイプのみを指定します。
+	var mimeOverrides []types.MimeTypeOverride
+	for _, file := range stream.Files {
 		mimeType := mime.TypeByExtension(filepath.Ext(file.Filename))
-		if mimeType == "" {
-			mimeType = "application/octet-stream"
+		if mimeType == "" || mimeType == types.DefaultMimeType(file.Path) {
+			continue
+		}
+		mimeOverrides = append(mimeOverrides, types.MimeTypeOverride{Path: file.Path, MimeType: mimeType})
+	}
+
+	// 空ファイルは断片を持たず RootProof にも含まれないため、パスのみを Finalize で申告します
+	var emptyFiles []string
+	for _, file := range stream.Files {
+		if file.FragmentCount == 0 {
+			emptyFiles = append(emptyFiles, file.Path)
 		}
-		fileRoot := calculateFileRoot(file.Path, file.Chunks)
-		manifestFiles = append(manifestFiles, types.ManifestFileEntry{
-			Path: file.Path,
-			Metadata: types.FileMetadata{
-				MimeType:  mimeType,
-				FileSize:  uint64(len(file.Content)),
-				Fragments: fragmentsByPath[file.Path],
-				FileRoot:  fileRoot,
-			},
-		})
 	}
 
 	// 7. セッションの終了とマニフェストの確定
 	finalizeMsg := &types.MsgFinalizeAndCloseSession{
-		Executor:  executorAddr,
-		SessionId: sessionID,
-		Manifest: types.ManifestPacket{
-			ProjectName:  projectName,
-			Version:      version,
-			RootProof:    proofData.RootProofHex,
-			FragmentSize: session.FragmentSize,
-			Owner:        cleanOwner,
-			SessionId:    sessionID,
-			Files:        manifestFiles,
-		},
+		Executor:      executorAddr,
+		SessionId:     sessionID,
+		ProjectName:   projectName,
+		Version:       version,
+		MimeOverrides: mimeOverrides,
+		ReusedFiles:   reusedFiles,
+		EmptyFiles:    emptyFiles,
 	}
 
 	txfFinalize, err := prepareFactory(clientCtx, executorAddr, ownerAddr, finalizeMsg)
@@ -240,26 +359,39 @@ func ExecuteSessionUpload(clientCtx client.Context, sessionID string, zipFilePat
 	return nil
 }
 
-func calculateFragmentID(sessionID, path string, index uint64) string {
-	payload := []byte(fmt.Sprintf("FDSC_FRAG_ID:%s:%s:%d", sessionID, path, index))
-	sum := sha256.Sum256(payload)
-	return hex.EncodeToString(sum[:])
+// HasExecutorKey は executor の署名鍵をこのゲートウェイが保持しているか（prepareFactory と同じ解決順で）確認します。
+// 鍵が無いセッションはアップロードを受け付けても executor が署名できないため、TUS の受付前に拒否するために使います。
+func HasExecutorKey(clientCtx client.Context, executor string) error {
+	addr, err := sdk.AccAddressFromBech32(executor)
+	if err != nil {
+		return fmt.Errorf("invalid executor address %q: %w", executor, err)
+	}
+	_, _, err = resolveKey(clientCtx, addr)
+	return err
 }
 
-func calculateFileRoot(path string, chunks [][]byte) string {
-	if len(chunks) == 0 {
-		return ""
+// resolveKey は clientCtx のキーリング、無ければ HomeDir（未設定なら $HOME/.gwc）の test バックエンドから鍵を探します。
+// 後者で見つかった場合はそのキーリングを設定した clientCtx を返します。
+func resolveKey(clientCtx client.Context, addr sdk.AccAddress) (client.Context, *keyring.Record, error) {
+	if clientCtx.Keyring != nil {
+		if rec, err := clientCtx.Keyring.KeyByAddress(addr); err == nil {
+			return clientCtx, rec, nil
+		}
+	}
+	homeDir := clientCtx.HomeDir
+	if homeDir == "" {
+		homeDir = os.ExpandEnv("$HOME/.gwc")
+	}
+	kb, err := keyring.New(sdk.KeyringServiceName(), keyring.BackendTest, homeDir, nil, clientCtx.Codec)
+	if err != nil {
+		return clientCtx, nil, fmt.Errorf("鍵の解決に失敗しました: %w", err)
 	}
-	var leaves []string
-	for i, chunk := range chunks {
-		chunkHash := sha256.Sum256(chunk)
-		chunkHashHex := hex.EncodeToString(chunkHash[:])
-		rawLeaf := fmt.Sprintf("FRAG:%s:%d:%s", path, i, chunkHashHex)
-		leafHash := sha256.Sum256([]byte(rawLeaf))
-		leafHex := hex.EncodeToString(leafHash[:])
-		leaves = append(leaves, leafHex)
+	rec, err := kb.KeyByAddress(addr)
+	if err != nil {
+		return clientCtx, nil, fmt.Errorf("鍵の解決に失敗しました: %w", err)
 	}
-	return types.NewMerkleTree(leaves).Root()
+	clientCtx.Keyring = kb
+	return clientCtx, rec, nil
 }
 
 func prepareFactory(clientCtx client.Context, fromAddr string, feeGranter sdk.AccAddress, msg sdk.Msg) (tx.Factory, error) {
@@ -267,23 +399,9 @@ func prepareFactory(clientCtx client.Context, fromAddr string, feeGranter sdk.Ac
 	if err != nil {
 		return tx.Factory{}, err
 	}
-	krRec, err := clientCtx.Keyring.KeyByAddress(fromAcc)
+	clientCtx, krRec, err := resolveKey(clientCtx, fromAcc)
 	if err != nil {
-		homeDir := clientCtx.HomeDir
-		if homeDir == "" {
-			homeDir = os.ExpandEnv("$HOME/.gwc")
-		}
-		kb, errK := keyring.New(sdk.KeyringServiceName(), keyring.BackendTest, homeDir, nil, clientCtx.Codec)
-		if errK == nil {
-			if rec, errRec := kb.KeyByAddress(fromAcc); errRec == nil {
-				krRec = rec
-				err = nil
-				clientCtx.Keyring = kb
-			}
-		}
-	}
-	if err != nil {
-		return tx.Factory{}, fmt.Errorf("鍵の解決に失敗しました: %w", err)
+		return tx.Factory{}, err
 	}
 	txf, err := tx.NewFactoryCLI(clientCtx, &pflag.FlagSet{})
 	if err != nil {
@@ -0,0 +1,28 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	moduletestutil "github.com/cosmos/cosmos-sdk/types/module/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasExecutorKey(t *testing.T) {
+	cdc := moduletestutil.MakeTestEncodingConfig().Codec
+	kr := keyring.NewInMemory(cdc)
+	rec, _, err := kr.NewMnemonic("executor", keyring.English, sdk.FullFundraiserPath, keyring.DefaultBIP39Passphrase, hd.Secp256k1)
+	require.NoError(t, err)
+	held, err := rec.GetAddress()
+	require.NoError(t, err)
+
+	// HomeDir points at an empty directory so the test-backend fallback finds nothing
+	clientCtx := client.Context{}.WithCodec(cdc).WithKeyring(kr).WithHomeDir(t.TempDir())
+
+	require.NoError(t, HasExecutorKey(clientCtx, held.String()))
+	require.ErrorContains(t, HasExecutorKey(clientCtx, sdk.AccAddress([]byte("other_executor______")).String()), "鍵の解決に失敗しました")
+	require.ErrorContains(t, HasExecutorKey(clientCtx, "not-an-address"), "invalid executor address")
+}
@@ -0,0 +1,147 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gwc/x/gateway/types"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+)
+
+const (
+	// オンチェーンの fragmentTimeoutSeconds (600s) + リレー遅延の余裕
+	ackWaitTimeout  = 11 * time.Minute
+	ackPollInterval = 5 * time.Second
+)
+
+type fragRef struct {
+	path  string
+	index uint64
+}
+
+// listSessionFragments はページングしながら指定ステータスの断片配送記録をすべて取得します。
+func listSessionFragments(clientCtx client.Context, sessionID string, statuses ...types.FragmentStatus) ([]types.FragmentDelivery, error) {
+	queryClient := types.NewQueryClient(clientCtx)
+	var out []types.FragmentDelivery
+	var nextKey []byte
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		res, err := queryClient.SessionFragments(ctx, &types.QuerySessionFragmentsRequest{
+			SessionId:  sessionID,
+			Statuses:   statuses,
+			Pagination: &query.PageRequest{Key: nextKey, Limit: 500},
+		})
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, res.Fragments...)
+		if res.Pagination == nil || len(res.Pagination.NextKey) == 0 {
+			return out, nil
+		}
+		nextKey = res.Pagination.NextKey
+	}
+}
+
+// waitForFragmentAcks は PENDING の断片がなくなるまで (ACK またはタイムアウトが記録されるまで) 待機します。
+func waitForFragmentAcks(clientCtx client.Context, sessionID string) error {
+	deadline := time.Now().Add(ackWaitTimeout)
+	for {
+		pending, err := listSessionFragments(clientCtx, sessionID, types.FragmentStatus_FRAGMENT_STATUS_PENDING)
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("ACK待機タイムアウト: 未完了の断片 %d 件", len(pending))
+		}
+		fmt.Printf("[Executor] ⏳ ACK待機中... 未完了: %d\n", len(pending))
+		time.Sleep(ackPollInterval)
+	}
+}
+
+// redistributeFailedFragments は ACK エラー/タイムアウトになった断片を MsgRedistributeFragments で再送します。
+// 再送先チャンネルはチェーン側に選ばせます（直前の失敗チャンネルの次）。
+// max_fragment_retries 回の再送後も失敗が残る場合はエラーを返します。
+func redistributeFailedFragments(
+	clientCtx client.Context,
+	session *types.Session,
+	executorAddr string,
+	ownerAddr sdk.AccAddress,
+	fragments func(ref fragRef) (*types.CSUFragmentProofData, error),
+) error {
+	queryClient := types.NewQueryClient(clientCtx)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	paramsRes, err := queryClient.Params(ctx, &types.QueryParamsRequest{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("パラメータの取得に失敗しました: %w", err)
+	}
+	maxRetries := paramsRes.Params.MaxFragmentRetries
+
+	for round := uint32(0); ; round++ {
+		if err := waitForFragmentAcks(clientCtx, session.SessionId); err != nil {
+			return err
+		}
+
+		failed, err := listSessionFragments(clientCtx, session.SessionId,
+			types.FragmentStatus_FRAGMENT_STATUS_ERROR,
+			types.FragmentStatus_FRAGMENT_STATUS_TIMED_OUT,
+		)
+		if err != nil {
+			return err
+		}
+		if len(failed) == 0 {
+			return nil
+		}
+		if round >= maxRetries {
+			return fmt.Errorf("再送上限 (%d) に達しましたが失敗した断片が %d 件残っています", maxRetries, len(failed))
+		}
+
+		fmt.Printf("[Executor] 🔁 失敗した断片を再送します (round %d/%d): %d 件\n", round+1, maxRetries, len(failed))
+
+		for i := 0; i < len(failed); i += MaxFragmentsPerBatch {
+			end := i + MaxFragmentsPerBatch
+			if end > len(failed) {
+				end = len(failed)
+			}
+
+			items := make([]types.DistributeItem, 0, end-i)
+			for _, rec := range failed[i:end] {
+				frag, err := fragments(fragRef{path: rec.Path, index: rec.Index})
+				if err != nil {
+					return fmt.Errorf("再送対象の断片をローカルから読み出せません: %s#%d: %w", rec.Path, rec.Index, err)
+				}
+				items = append(items, types.DistributeItem{
+					Path:          frag.Path,
+					Index:         frag.Index,
+					FragmentBytes: frag.FragmentBytes,
+					FragmentProof: frag.FragmentProof,
+					FileSize:      frag.FileSize,
+					FileProof:     frag.FileProof,
+				})
+			}
+
+			msg := &types.MsgRedistributeFragments{
+				Executor:  executorAddr,
+				SessionId: session.SessionId,
+				Items:     items,
+			}
+			txf, err := prepareFactory(clientCtx, executorAddr, ownerAddr, msg)
+			if err != nil {
+				return fmt.Errorf("Redistribute用Factory準備エラー: %w", err)
+			}
+			txRes, err := broadcastAndConfirm(clientCtx, txf, msg)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("[Executor] ✅ 再送バッチ送信成功 TxHash: %s\n", txRes.TxHash)
+		}
+	}
+}
@@ -24,42 +24,38 @@ func (k Keeper) getParamsOrDefault(ctx sdk.Context) types.Params {
 	return params
 }
 
-func (k Keeper) localAdminOrErr(ctx sdk.Context) (string, error) {
-	params := k.getParamsOrDefault(ctx)
-	if params.LocalAdmin == "" {
-		return "", errorsmod.Wrap(types.ErrLocalAdminNotConfigured, "params.local_admin is empty")
+// enforceSessionExecutor は msg.executor がセッションに割り当てられた executor であり、
+// かつ現在も有効（レジストリで enabled、または local_admin フォールバック）であることを検証します。
+func (k Keeper) enforceSessionExecutor(ctx sdk.Context, sess types.Session, msgExecutor string) (sdk.AccAddress, sdk.AccAddress, error) {
+	if msgExecutor != sess.Executor {
+		return nil, nil, errorsmod.Wrapf(types.ErrExecutorMismatch, "msg.executor must be the session executor: got=%s want=%s", msgExecutor, sess.Executor)
 	}
-	return params.LocalAdmin, nil
-}
-
-func (k Keeper) enforceLocalAdmin(ctx sdk.Context, sess types.Session, msgExecutor string) (sdk.AccAddress, sdk.AccAddress, error) {
-	localAdmin, err := k.localAdminOrErr(ctx)
+	active, err := k.isExecutorActive(ctx, k.getParamsOrDefault(ctx), sess.Executor)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	if msgExecutor != localAdmin {
-		return nil, nil, errorsmod.Wrapf(types.ErrLocalAdminMismatch, "msg.executor must be local-admin: got=%s want=%s", msgExecutor, localAdmin)
-	}
-	if sess.Executor != localAdmin {
-		return nil, nil, errorsmod.Wrapf(types.ErrLocalAdminMismatch, "session.executor must be local-admin: got=%s want=%s", sess.Executor, localAdmin)
+	if !active {
+		return nil, nil, errorsmod.Wrapf(types.ErrExecutorNotActive, "session executor is disabled or unregistered: %s", sess.Executor)
 	}
 
 	ownerAddr, err := sdk.AccAddressFromBech32(sess.Owner)
 	if err != nil {
 		return nil, nil, errorsmod.Wrap(types.ErrInvalidSigner, fmt.Sprintf("invalid session owner address: %v", err))
 	}
-	adminAddr, err := sdk.AccAddressFromBech32(localAdmin)
+	executorAddr, err := sdk.AccAddressFromBech32(sess.Executor)
 	if err != nil {
-		return nil, nil, errorsmod.Wrap(types.ErrInvalidSigner, fmt.Sprintf("invalid local-admin address: %v", err))
+		return nil, nil, errorsmod.Wrap(types.ErrInvalidSigner, fmt.Sprintf("invalid executor address: %v", err))
 	}
 
-	return ownerAddr, adminAddr, nil
+	return ownerAddr, executorAddr, nil
 }
 
 // RequireSessionBoundAuthz は、配布・完了メッセージの権限を検証します。
-func (k Keeper) RequireSessionBoundAuthz(ctx sdk.Context, sess types.Session, msgExecutor string, sessionID string, msgTypeURL string) error {
-	ownerAddr, adminAddr, err := k.enforceLocalAdmin(ctx, sess, msgExecutor)
+// SessionBoundAuthorization の Accept を通し、予算（バイト数・メッセージ数）の消費結果を grant に書き戻します。
+// GenericAuthorization は params.allow_generic_authorization が有効な場合のみ受け付けます。
+func (k Keeper) RequireSessionBoundAuthz(ctx sdk.Context, sess types.Session, msgExecutor string, msg sdk.Msg) error {
+	msgTypeURL := sdk.MsgTypeURL(msg)
+	ownerAddr, adminAddr, err := k.enforceSessionExecutor(ctx, sess, msgExecutor)
 	if err != nil {
 		return err
 	}
@@ -70,13 +66,16 @@ func (k Keeper) RequireSessionBoundAuthz(ctx sdk.Context, sess types.Session, ms
 
 	// 引数の順番は (grantee, granter)
 	// adminAddr (Executor) が受任者(Grantee)、ownerAddr (Alice) が委任者(Granter) です。
-	auth, _ := k.authzKeeper.GetAuthorization(ctx, adminAddr, ownerAddr, msgTypeURL)
+	auth, expiration := k.authzKeeper.GetAuthorization(ctx, adminAddr, ownerAddr, msgTypeURL)
 	if auth == nil {
 		return errorsmod.Wrapf(types.ErrAuthzMissingOrInvalid, "authorization not found: msg_type_url=%s (Granter: %s, Grantee: %s)", msgTypeURL, ownerAddr, adminAddr)
 	}
 
-	// GenericAuthorization を許可（integrity-test.sh 用）
+	// GenericAuthorization はセッションに束縛されないため、パラメータで明示的に許可された場合のみ受け付けます
 	if _, ok := auth.(*authz.GenericAuthorization); ok {
+		if !k.getParamsOrDefault(ctx).AllowGenericAuthorization {
+			return errorsmod.Wrapf(types.ErrAuthzMissingOrInvalid, "generic authorization is disabled; grant a SessionBoundAuthorization: msg_type_url=%s", msgTypeURL)
+		}
 		ctx.Logger().Debug("RequireSessionBoundAuthz: generic authorization used", "type", msgTypeURL)
 		return nil
 	}
@@ -86,23 +85,48 @@ func (k Keeper) RequireSessionBoundAuthz(ctx sdk.Context, sess types.Session, ms
 	if !ok {
 		return errorsmod.Wrapf(types.ErrAuthzMissingOrInvalid, "authorization is not SessionBoundAuthorization: got=%T", auth)
 	}
-	if sb.SessionId != sessionID {
-		return errorsmod.Wrapf(types.ErrAuthzMissingOrInvalid, "session_id mismatch: auth=%s msg=%s", sb.SessionId, sessionID)
+	if sb.SessionId != sess.SessionId {
+		return errorsmod.Wrapf(types.ErrAuthzMissingOrInvalid, "session_id mismatch: auth=%s msg=%s", sb.SessionId, sess.SessionId)
 	}
 	if sb.MsgTypeUrl != msgTypeURL {
 		return errorsmod.Wrapf(types.ErrAuthzMissingOrInvalid, "msg_type_url mismatch: auth=%s want=%s", sb.MsgTypeUrl, msgTypeURL)
 	}
 
+	// 期限・予算の検証と消費
+	resp, err := sb.Accept(ctx, msg)
+	if err != nil {
+		return errorsmod.Wrap(types.ErrAuthzMissingOrInvalid, err.Error())
+	}
+	if !resp.Accept {
+		return errorsmod.Wrapf(types.ErrAuthzMissingOrInvalid, "authorization rejected msg: msg_type_url=%s", msgTypeURL)
+	}
+	switch {
+	case resp.Delete:
+		if err := k.authzKeeper.DeleteGrant(ctx, adminAddr, ownerAddr, msgTypeURL); err != nil {
+			return err
+		}
+	case resp.Updated != nil:
+		if err := k.authzKeeper.SaveGrant(ctx, adminAddr, ownerAddr, resp.Updated, expiration); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func (k Keeper) RevokeCSUGrants(ctx sdk.Context, ownerBech32 string) {
-	localAdmin, err := k.localAdminOrErr(ctx)
+// RevokeCSUGrants はセッション所有者からセッションの executor への authz / feegrant を剥奪します。
+func (k Keeper) RevokeCSUGrants(ctx sdk.Context, sess types.Session) {
+	if sess.Executor == "" {
+		return
+	}
+	ownerAddr, err := sdk.AccAddressFromBech32(sess.Owner)
+	if err != nil {
+		return
+	}
+	adminAddr, err := sdk.AccAddressFromBech32(sess.Executor)
 	if err != nil {
 		return
 	}
-	ownerAddr, _ := sdk.AccAddressFromBech32(ownerBech32)
-	adminAddr, _ := sdk.AccAddressFromBech32(localAdmin)
 
 	if k.authzKeeper != nil {
 		for _, msgTypeURL := range types.CSUAuthorizedMsgTypeURLs() {
@@ -113,8 +137,8 @@ func (k Keeper) RevokeCSUGrants(ctx sdk.Context, ownerBech32 string) {
 	if k.feegrantKeeper != nil {
 		// 【重要修正】SDK v0.47+ の仕様に合わせて MsgRevokeAllowance 構造体を使用する
 		msg := &feegrant.MsgRevokeAllowance{
-			Granter: ownerBech32,
-			Grantee: localAdmin,
+			Granter: sess.Owner,
+			Grantee: sess.Executor,
 		}
 		// メソッドは (context.Context, *feegrant.MsgRevokeAllowance) を期待しています
 		_, _ = k.feegrantKeeper.RevokeAllowance(ctx, msg)
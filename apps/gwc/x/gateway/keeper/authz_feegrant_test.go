@@ -0,0 +1,132 @@
+package keeper
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/authz"
+	"github.com/stretchr/testify/require"
+
+	"gwc/x/gateway/types"
+)
+
+func TestRequireSessionBoundAuthz(t *testing.T) {
+	msgTypeURL := sdk.MsgTypeURL(&types.MsgDistributeBatch{})
+	batch := func(sess types.Session, sizes ...int) *types.MsgDistributeBatch {
+		msg := &types.MsgDistributeBatch{Executor: sess.Executor, SessionId: sess.SessionId}
+		for _, n := range sizes {
+			msg.Items = append(msg.Items, types.DistributeItem{FragmentBytes: make([]byte, n)})
+		}
+		return msg
+	}
+	remaining := func(f *fixture, sess types.Session) (*types.SessionBoundAuthorization, bool) {
+		auth, ok := f.authz.get(f.executor, sess.Owner, msgTypeURL)
+		if !ok {
+			return nil, false
+		}
+		return auth.(*types.SessionBoundAuthorization), true
+	}
+
+	t.Run("missing grant", func(t *testing.T) {
+		f := initFixture(t)
+		sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+		require.ErrorIs(t, f.keeper.RequireSessionBoundAuthz(f.ctx, sess, f.executor, batch(sess, 1)), types.ErrAuthzMissingOrInvalid)
+	})
+
+	t.Run("executor mismatch", func(t *testing.T) {
+		f := initFixture(t)
+		sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+		f.grantSessionBound(sess, msgTypeURL, 0, 0)
+		require.ErrorIs(t, f.keeper.RequireSessionBoundAuthz(f.ctx, sess, f.owner, batch(sess, 1)), types.ErrExecutorMismatch)
+	})
+
+	t.Run("byte budget is consumed and the grant deleted when exhausted", func(t *testing.T) {
+		f := initFixture(t)
+		sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+		f.grantSessionBound(sess, msgTypeURL, 30, 0)
+
+		require.NoError(t, f.keeper.RequireSessionBoundAuthz(f.ctx, sess, f.executor, batch(sess, 4, 6)))
+		sb, ok := remaining(f, sess)
+		require.True(t, ok)
+		require.Equal(t, uint64(20), sb.BytesRemaining)
+
+		// over budget: rejected and the grant is left as is
+		require.ErrorIs(t, f.keeper.RequireSessionBoundAuthz(f.ctx, sess, f.executor, batch(sess, 21)), types.ErrAuthzMissingOrInvalid)
+		sb, ok = remaining(f, sess)
+		require.True(t, ok)
+		require.Equal(t, uint64(20), sb.BytesRemaining)
+
+		// dedup references carry no payload and are not charged
+		ref := batch(sess)
+		ref.Items = []types.DistributeItem{{ContentId: "00"}}
+		require.NoError(t, f.keeper.RequireSessionBoundAuthz(f.ctx, sess, f.executor, ref))
+		sb, ok = remaining(f, sess)
+		require.True(t, ok)
+		require.Equal(t, uint64(20), sb.BytesRemaining)
+
+		require.NoError(t, f.keeper.RequireSessionBoundAuthz(f.ctx, sess, f.executor, batch(sess, 20)))
+		_, ok = remaining(f, sess)
+		require.False(t, ok)
+		require.ErrorIs(t, f.keeper.RequireSessionBoundAuthz(f.ctx, sess, f.executor, batch(sess, 1)), types.ErrAuthzMissingOrInvalid)
+	})
+
+	t.Run("message budget", func(t *testing.T) {
+		f := initFixture(t)
+		sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+		f.grantSessionBound(sess, msgTypeURL, 0, 2)
+
+		require.NoError(t, f.keeper.RequireSessionBoundAuthz(f.ctx, sess, f.executor, batch(sess, 1)))
+		sb, ok := remaining(f, sess)
+		require.True(t, ok)
+		require.Equal(t, uint64(1), sb.MsgsRemaining)
+		require.NoError(t, f.keeper.RequireSessionBoundAuthz(f.ctx, sess, f.executor, batch(sess, 1)))
+		_, ok = remaining(f, sess)
+		require.False(t, ok)
+	})
+
+	t.Run("unbudgeted grant is kept", func(t *testing.T) {
+		f := initFixture(t)
+		sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+		f.grantSessionBound(sess, msgTypeURL, 0, 0)
+		for i := 0; i < 3; i++ {
+			require.NoError(t, f.keeper.RequireSessionBoundAuthz(f.ctx, sess, f.executor, batch(sess, 1<<10)))
+		}
+		_, ok := remaining(f, sess)
+		require.True(t, ok)
+	})
+
+	t.Run("expired grant", func(t *testing.T) {
+		f := initFixture(t)
+		sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+		f.authz.grant(f.executor, sess.Owner, &types.SessionBoundAuthorization{
+			SessionId:   sess.SessionId,
+			MsgTypeUrl:  msgTypeURL,
+			ExpiresUnix: f.ctx.BlockTime().Unix() + 1,
+		}, nil)
+		require.NoError(t, f.keeper.RequireSessionBoundAuthz(f.ctx, sess, f.executor, batch(sess, 1)))
+
+		f.ctx = f.ctx.WithBlockTime(f.ctx.BlockTime().Add(time.Second))
+		require.ErrorIs(t, f.keeper.RequireSessionBoundAuthz(f.ctx, sess, f.executor, batch(sess, 1)), types.ErrAuthzMissingOrInvalid)
+	})
+
+	t.Run("grant of another session", func(t *testing.T) {
+		f := initFixture(t)
+		sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+		other := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+		f.grantSessionBound(other, msgTypeURL, 0, 0)
+		require.ErrorIs(t, f.keeper.RequireSessionBoundAuthz(f.ctx, sess, f.executor, batch(sess, 1)), types.ErrAuthzMissingOrInvalid)
+	})
+
+	t.Run("generic authorization", func(t *testing.T) {
+		f := initFixture(t)
+		sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+		f.authz.grant(f.executor, sess.Owner, authz.NewGenericAuthorization(msgTypeURL), nil)
+
+		f.setParams(t, func(p *types.Params) { p.AllowGenericAuthorization = false })
+		require.ErrorIs(t, f.keeper.RequireSessionBoundAuthz(f.ctx, sess, f.executor, batch(sess, 1)), types.ErrAuthzMissingOrInvalid)
+
+		f.setParams(t, func(p *types.Params) { p.AllowGenericAuthorization = true })
+		require.NoError(t, f.keeper.RequireSessionBoundAuthz(f.ctx, sess, f.executor, batch(sess, 1)))
+	})
+}
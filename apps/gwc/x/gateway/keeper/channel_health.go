@@ -0,0 +1,150 @@
+package keeper
+
+import (
+	"errors"
+	"fmt"
+
+	"cosmossdk.io/collections"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"gwc/x/gateway/types"
+)
+
+// channelOutcome is the delivery result of one FragmentPacket.
+type channelOutcome int
+
+const (
+	channelOutcomeAck channelOutcome = iota
+	channelOutcomeError
+	channelOutcomeTimeout
+)
+
+// RecordChannelAck records a success ack of a FragmentPacket sent on channelID.
+func (k Keeper) RecordChannelAck(ctx sdk.Context, channelID string) error {
+	return k.recordChannelOutcome(ctx, channelID, channelOutcomeAck)
+}
+
+// RecordChannelError records an error ack of a FragmentPacket sent on channelID.
+func (k Keeper) RecordChannelError(ctx sdk.Context, channelID string) error {
+	return k.recordChannelOutcome(ctx, channelID, channelOutcomeError)
+}
+
+// RecordChannelTimeout records a timed out FragmentPacket sent on channelID.
+func (k Keeper) RecordChannelTimeout(ctx sdk.Context, channelID string) error {
+	return k.recordChannelOutcome(ctx, channelID, channelOutcomeTimeout)
+}
+
+// recordChannelOutcome はチャネルの直近カウンタを更新し、連続失敗がしきい値に達したら degraded にします。
+// degraded への遷移・復帰時にはイベントを発行します。
+func (k Keeper) recordChannelOutcome(ctx sdk.Context, channelID string, outcome channelOutcome) error {
+	if channelID == "" {
+		return nil
+	}
+	params := k.getParamsOrDefault(ctx)
+	now := ctx.BlockTime().Unix()
+
+	h, err := k.ChannelHealth.Get(ctx, channelID)
+	if err != nil {
+		if !errors.Is(err, collections.ErrNotFound) {
+			return err
+		}
+		h = types.ChannelHealth{ChannelId: channelID, WindowStartUnix: now}
+	}
+
+	// 集計ウィンドウの更新
+	if window := params.ChannelHealthWindowSeconds; window > 0 && now-h.WindowStartUnix >= window {
+		h.WindowStartUnix = now
+		h.Acks, h.Errors, h.Timeouts = 0, 0, 0
+	}
+
+	wasDegraded := h.Degraded
+	switch outcome {
+	case channelOutcomeAck:
+		h.Acks++
+		h.LastAckUnix = now
+		h.ConsecutiveFailures = 0
+		h.Degraded = false
+		h.DegradedUntilUnix = 0
+	case channelOutcomeError, channelOutcomeTimeout:
+		if outcome == channelOutcomeError {
+			h.Errors++
+		} else {
+			h.Timeouts++
+		}
+		h.LastFailureUnix = now
+		h.ConsecutiveFailures++
+		if params.ChannelFailureThreshold > 0 && h.ConsecutiveFailures >= params.ChannelFailureThreshold {
+			// 既に degraded の場合も、クールダウン明けの再試行で失敗したらクールダウンを延長します
+			if !h.Degraded || now >= h.DegradedUntilUnix {
+				h.DegradedUntilUnix = now + params.ChannelDegradedCooldownSeconds
+			}
+			h.Degraded = true
+		}
+	}
+
+	if err := k.ChannelHealth.Set(ctx, channelID, h); err != nil {
+		return err
+	}
+
+	if wasDegraded != h.Degraded {
+		eventType := "csu_channel_recovered"
+		if h.Degraded {
+			eventType = "csu_channel_degraded"
+			fmt.Printf("⚠️ [KEEPER] FDSC channel degraded | Channel: %s | ConsecutiveFailures: %d | Until: %d\n", channelID, h.ConsecutiveFailures, h.DegradedUntilUnix)
+		} else {
+			fmt.Printf("🟢 [KEEPER] FDSC channel recovered | Channel: %s\n", channelID)
+		}
+		ctx.EventManager().EmitEvent(
+			sdk.NewEvent(
+				eventType,
+				sdk.NewAttribute("channel_id", channelID),
+				sdk.NewAttribute("consecutive_failures", fmt.Sprintf("%d", h.ConsecutiveFailures)),
+				sdk.NewAttribute("degraded_until_unix", fmt.Sprintf("%d", h.DegradedUntilUnix)),
+			),
+		)
+	}
+	return nil
+}
+
+// markChannelSent stamps the channel with the next value of the module-wide send counter.
+func (k Keeper) markChannelSent(ctx sdk.Context, channelID string) error {
+	seq, err := k.ChannelSendSeq.Next(ctx)
+	if err != nil {
+		return err
+	}
+	h, err := k.ChannelHealth.Get(ctx, channelID)
+	if err != nil {
+		if !errors.Is(err, collections.ErrNotFound) {
+			return err
+		}
+		h = types.ChannelHealth{ChannelId: channelID, WindowStartUnix: ctx.BlockTime().Unix()}
+	}
+	// Sequence.Next は現在値を返して進めるため +1 して 0 (= 未送信) と区別します
+	h.LastSentSeq = seq + 1
+	return k.ChannelHealth.Set(ctx, channelID, h)
+}
+
+// channelHealthMap returns the health records of channels (channels without a record are omitted).
+func (k Keeper) channelHealthMap(ctx sdk.Context, channels []string) (map[string]types.ChannelHealth, error) {
+	out := make(map[string]types.ChannelHealth, len(channels))
+	for _, ch := range channels {
+		h, err := k.ChannelHealth.Get(ctx, ch)
+		if err != nil {
+			if errors.Is(err, collections.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		out[ch] = h
+	}
+	return out, nil
+}
+
+// routableFdscChannels narrows the session's allowed channels to the ones default routing may use.
+func (k Keeper) routableFdscChannels(ctx sdk.Context, allowed []string) ([]string, error) {
+	health, err := k.channelHealthMap(ctx, allowed)
+	if err != nil {
+		return nil, err
+	}
+	return types.RoutableChannels(allowed, health, ctx.BlockTime().Unix()), nil
+}
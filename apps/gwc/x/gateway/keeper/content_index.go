@@ -0,0 +1,86 @@
+package keeper
+
+import (
+	"cosmossdk.io/collections"
+	errorsmod "cosmossdk.io/errors"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"gwc/x/gateway/types"
+)
+
+// indexContentLocation records that channelID acked a fragment stored under contentID.
+func (k Keeper) indexContentLocation(ctx sdk.Context, contentID, channelID string, size uint64) error {
+	if contentID == "" {
+		return nil
+	}
+	return k.ContentIndex.Set(ctx, collections.Join(contentID, channelID), size)
+}
+
+// forgetContentLocation drops a (contentID, channelID) entry after FDSC rejected a fragment of that content,
+// so that later uploads send the bytes again instead of referencing a copy that may not exist.
+func (k Keeper) forgetContentLocation(ctx sdk.Context, contentID, channelID string) error {
+	if contentID == "" {
+		return nil
+	}
+	return k.ContentIndex.Remove(ctx, collections.Join(contentID, channelID))
+}
+
+// ContentLocations returns every indexed holder of contentID in channel order.
+func (k Keeper) ContentLocations(ctx sdk.Context, contentID string) ([]types.ContentLocation, error) {
+	var out []types.ContentLocation
+	rng := collections.NewPrefixedPairRange[string, string](contentID)
+	err := k.ContentIndex.Walk(ctx, rng, func(key collections.Pair[string, string], size uint64) (bool, error) {
+		out = append(out, types.ContentLocation{ContentId: key.K1(), ChannelId: key.K2(), Size: size})
+		return false, nil
+	})
+	return out, err
+}
+
+// contentHolders returns the channels among allowed that hold contentID (routable ones first) and the content size.
+func (k Keeper) contentHolders(ctx sdk.Context, contentID string, allowed, routable []string) ([]string, uint64, error) {
+	locs, err := k.ContentLocations(ctx, contentID)
+	if err != nil {
+		return nil, 0, err
+	}
+	held := make(map[string]uint64, len(locs))
+	for _, l := range locs {
+		held[l.ChannelId] = l.Size
+	}
+
+	var holders []string
+	var size uint64
+	for _, pool := range [][]string{routable, allowed} {
+		for _, ch := range pool {
+			if s, ok := held[ch]; ok && !containsString(holders, ch) {
+				holders = append(holders, ch)
+				size = s
+			}
+		}
+	}
+	return holders, size, nil
+}
+
+// referenceTargets picks the n channels a dedup reference is sent to: the explicit target (which must hold
+// the content) first, then the other holders in order.
+func referenceTargets(explicit string, holders []string, n int) ([]string, error) {
+	out := make([]string, 0, n)
+	if explicit != "" {
+		if !containsString(holders, explicit) {
+			return nil, errorsmod.Wrapf(types.ErrInvalidFragmentReference, "target channel %s does not hold the content", explicit)
+		}
+		out = append(out, explicit)
+	}
+	for _, ch := range holders {
+		if len(out) == n {
+			break
+		}
+		if !containsString(out, ch) {
+			out = append(out, ch)
+		}
+	}
+	if len(out) < n {
+		return nil, errorsmod.Wrapf(types.ErrInvalidFragmentReference,
+			"content is held by %d of the %d FDSC channels the session replicates to", len(out), n)
+	}
+	return out, nil
+}
@@ -0,0 +1,158 @@
+package keeper
+
+import (
+	"fmt"
+
+	errorsmod "cosmossdk.io/errors"
+	"cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+
+	"gwc/x/gateway/types"
+)
+
+// depositFor returns ceil(bytes * price_per_byte) in the price denom.
+func depositFor(params types.Params, bytes uint64) sdk.Coin {
+	price := params.DepositPricePerByte
+	amount := price.Amount.MulInt(math.NewIntFromUint64(bytes)).Ceil().TruncateInt()
+	return sdk.NewCoin(price.Denom, amount)
+}
+
+// depositsEnabled reports whether a non-zero deposit price is configured.
+func depositsEnabled(params types.Params) bool {
+	return !params.DepositPricePerByte.Amount.IsNil() && params.DepositPricePerByte.IsPositive()
+}
+
+// depositBytes returns the bytes the session's deposit is sized for: declared_total_bytes stored once per
+// replica, or the data plus parity shards of declared_total_bytes when erasure coded.
+func depositBytes(sess types.Session) uint64 {
+	if sess.IsErasureCoded() {
+		_, bytes := types.ErasureStoredSize(sess.DeclaredTotalBytes, sess.FragmentSize, int(sess.ErasureDataShards), int(sess.ErasureParityShards))
+		return bytes
+	}
+	return sess.DeclaredTotalBytes * uint64(sess.ReplicaCount())
+}
+
+// lockSessionDeposit はセッションの宣言バイト数とレプリカ数（イレイジャーコーディングではパリティシャード）からデポジットを算出し、所有者からエスクロー（gateway モジュールアカウント）へ移します。
+// 価格がゼロの場合は何もしません。
+func (k Keeper) lockSessionDeposit(ctx sdk.Context, params types.Params, sess *types.Session) error {
+	sess.Deposit = sdk.Coin{Denom: params.DepositPricePerByte.Denom, Amount: math.ZeroInt()}
+	if !depositsEnabled(params) {
+		return nil
+	}
+	if sess.DeclaredTotalBytes == 0 {
+		return errorsmod.Wrap(types.ErrDepositInsufficient, "declared_total_bytes must be > 0 when deposits are enabled")
+	}
+
+	// 各レプリカが FDSC 上で同じバイト数を占有するため、レプリカ数倍で算出します（イレイジャーコーディングではパリティ分を加算）
+	deposit := depositFor(params, depositBytes(*sess))
+	if deposit.IsZero() {
+		return nil
+	}
+
+	owner, err := k.addressCodec.StringToBytes(sess.Owner)
+	if err != nil {
+		return errorsmod.Wrapf(sdkerrors.ErrInvalidAddress, "invalid owner address: %s", err)
+	}
+	if err := k.bankKeeper.SendCoinsFromAccountToModule(ctx, owner, types.ModuleName, sdk.NewCoins(deposit)); err != nil {
+		return errorsmod.Wrapf(types.ErrDepositInsufficient, "failed to lock deposit %s: %v", deposit, err)
+	}
+
+	sess.Deposit = deposit
+	sess.DepositStatus = types.DepositStatus_DEPOSIT_STATUS_LOCKED
+	return nil
+}
+
+// SettleSessionDeposit はクローズされたセッションのデポジットを精算します。
+// - CLOSED_SUCCESS: ストレージ報酬プールへ支払い
+// - CLOSED_FAILED : 所有者へ返金
+// LOCKED 以外（デポジット無し・精算済み）の場合は何もしません。呼び出し側は精算後に SetSession してください。
+func (k Keeper) SettleSessionDeposit(ctx sdk.Context, sess *types.Session) error {
+	if sess.DepositStatus != types.DepositStatus_DEPOSIT_STATUS_LOCKED {
+		return nil
+	}
+	coins := sdk.NewCoins(sess.Deposit)
+
+	switch sess.State {
+	case types.SessionState_SESSION_STATE_CLOSED_SUCCESS:
+		if !coins.IsZero() {
+			if err := k.bankKeeper.SendCoinsFromModuleToModule(ctx, types.ModuleName, types.StorageRewardPoolName, coins); err != nil {
+				return err
+			}
+		}
+		sess.DepositStatus = types.DepositStatus_DEPOSIT_STATUS_RELEASED
+	case types.SessionState_SESSION_STATE_CLOSED_FAILED:
+		if !coins.IsZero() {
+			owner, err := k.addressCodec.StringToBytes(sess.Owner)
+			if err != nil {
+				return err
+			}
+			if err := k.bankKeeper.SendCoinsFromModuleToAccount(ctx, types.ModuleName, owner, coins); err != nil {
+				return err
+			}
+		}
+		sess.DepositStatus = types.DepositStatus_DEPOSIT_STATUS_REFUNDED
+	default:
+		return fmt.Errorf("cannot settle deposit of open session %s (state=%s)", sess.SessionId, sess.State)
+	}
+
+	ctx.EventManager().EmitEvent(
+		sdk.NewEvent(
+			"csu_deposit_settled",
+			sdk.NewAttribute("session_id", sess.SessionId),
+			sdk.NewAttribute("amount", sess.Deposit.String()),
+			sdk.NewAttribute("status", sess.DepositStatus.String()),
+		),
+	)
+	return nil
+}
+
+// SettleSessionDepositOrDefer は SettleSessionDeposit を試み、失敗した場合はログに残してデポジットを LOCKED のままにします。
+// クローズ済みで LOCKED のセッションは SetSession で精算待ちキューに入り、EndBlock の RetryDepositSettlements で再試行されます。
+// IBC コールバックや EndBlock のように、精算の失敗でクローズ自体を失敗させられない経路で使います。
+func (k Keeper) SettleSessionDepositOrDefer(ctx sdk.Context, sess *types.Session) {
+	if err := k.SettleSessionDeposit(ctx, sess); err != nil {
+		ctx.Logger().Error("failed to settle session deposit; retrying in EndBlock", "session_id", sess.SessionId, "error", err)
+	}
+}
+
+// RetryDepositSettlements は精算待ちキューのセッションのデポジット精算を再試行します。
+// 1ブロックあたり最大 max_expired_sessions_per_block 件。失敗したものはキューに残り、次のブロックで再試行されます。
+func (k Keeper) RetryDepositSettlements(ctx sdk.Context) error {
+	params := k.getParamsOrDefault(ctx)
+	limit := params.MaxExpiredSessionsPerBlock
+	if limit == 0 {
+		limit = types.DefaultMaxExpiredPerBlock
+	}
+
+	var due []string
+	err := k.DepositSettleQueue.Walk(ctx, nil, func(sessionID string) (bool, error) {
+		due = append(due, sessionID)
+		return uint32(len(due)) >= limit, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, sessionID := range due {
+		sess, err := k.Sessions.Get(ctx, sessionID)
+		if err != nil || !isSessionClosed(sess) || sess.DepositStatus != types.DepositStatus_DEPOSIT_STATUS_LOCKED {
+			// 実体と食い違うインデックスだけが残っている場合は掃除する
+			if err := k.DepositSettleQueue.Remove(ctx, sessionID); err != nil {
+				return err
+			}
+			continue
+		}
+		cacheCtx, write := ctx.CacheContext()
+		if err := k.SettleSessionDeposit(cacheCtx, &sess); err != nil {
+			ctx.Logger().Error("deposit settlement retry failed", "session_id", sessionID, "error", err)
+			continue
+		}
+		if err := k.SetSession(cacheCtx, sess); err != nil {
+			ctx.Logger().Error("deposit settlement retry failed", "session_id", sessionID, "error", err)
+			continue
+		}
+		write()
+	}
+	return nil
+}
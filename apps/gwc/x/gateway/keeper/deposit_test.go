@@ -0,0 +1,122 @@
+package keeper
+
+import (
+	"testing"
+
+	"cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"gwc/x/gateway/types"
+)
+
+func enableDeposits(t *testing.T, f *fixture) types.Params {
+	t.Helper()
+	f.setParams(t, func(p *types.Params) {
+		p.DepositPricePerByte = sdk.NewDecCoinFromDec("stake", math.LegacyNewDecWithPrec(5, 1)) // 0.5 stake / byte
+	})
+	return f.keeper.getParamsOrDefault(f.ctx)
+}
+
+func TestLockSessionDeposit(t *testing.T) {
+	f := initFixture(t)
+
+	// deposits are disabled by default
+	sess := types.Session{Owner: f.owner, DeclaredTotalBytes: 100}
+	require.NoError(t, f.keeper.lockSessionDeposit(f.ctx, f.keeper.getParamsOrDefault(f.ctx), &sess))
+	require.True(t, sess.Deposit.IsZero())
+	require.Equal(t, types.DepositStatus_DEPOSIT_STATUS_UNSPECIFIED, sess.DepositStatus)
+
+	params := enableDeposits(t, f)
+	sess = types.Session{Owner: f.owner}
+	require.ErrorIs(t, f.keeper.lockSessionDeposit(f.ctx, params, &sess), types.ErrDepositInsufficient)
+
+	// ceil(101 bytes * 2 replicas * 0.5)
+	sess = types.Session{Owner: f.owner, DeclaredTotalBytes: 101, ReplicationFactor: 2}
+	require.ErrorIs(t, f.keeper.lockSessionDeposit(f.ctx, params, &sess), types.ErrDepositInsufficient)
+
+	f.bank.balances[f.owner] = sdk.NewCoins(sdk.NewInt64Coin("stake", 150))
+	require.NoError(t, f.keeper.lockSessionDeposit(f.ctx, params, &sess))
+	require.Equal(t, sdk.NewInt64Coin("stake", 101), sess.Deposit)
+	require.Equal(t, types.DepositStatus_DEPOSIT_STATUS_LOCKED, sess.DepositStatus)
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("stake", 49)), f.bank.balances[f.owner])
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("stake", 101)), f.bank.balances[types.ModuleName])
+
+	// erasure coding pays for the parity shards: ceil((100 data + 1 parity * 64) bytes * 0.5)
+	sess = types.Session{Owner: f.owner, DeclaredTotalBytes: 100, FragmentSize: 64, ErasureDataShards: 2, ErasureParityShards: 1}
+	f.bank.balances[f.owner] = sdk.NewCoins(sdk.NewInt64Coin("stake", 82))
+	require.NoError(t, f.keeper.lockSessionDeposit(f.ctx, params, &sess))
+	require.Equal(t, sdk.NewInt64Coin("stake", 82), sess.Deposit)
+	require.True(t, f.bank.balances[f.owner].IsZero())
+}
+
+func TestSettleSessionDeposit(t *testing.T) {
+	f := initFixture(t)
+	deposit := sdk.NewInt64Coin("stake", 100)
+	f.bank.balances[types.ModuleName] = sdk.NewCoins(deposit.Add(deposit))
+
+	locked := func(state types.SessionState) types.Session {
+		sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+		sess.State = state
+		sess.Deposit = deposit
+		sess.DepositStatus = types.DepositStatus_DEPOSIT_STATUS_LOCKED
+		return sess
+	}
+
+	// success releases the deposit to the storage reward pool
+	sess := locked(types.SessionState_SESSION_STATE_CLOSED_SUCCESS)
+	require.NoError(t, f.keeper.SettleSessionDeposit(f.ctx, &sess))
+	require.Equal(t, types.DepositStatus_DEPOSIT_STATUS_RELEASED, sess.DepositStatus)
+	require.Equal(t, sdk.NewCoins(deposit), f.bank.balances[types.StorageRewardPoolName])
+
+	// settling twice is a no-op
+	require.NoError(t, f.keeper.SettleSessionDeposit(f.ctx, &sess))
+	require.Equal(t, sdk.NewCoins(deposit), f.bank.balances[types.StorageRewardPoolName])
+
+	// failure refunds the owner
+	sess = locked(types.SessionState_SESSION_STATE_CLOSED_FAILED)
+	require.NoError(t, f.keeper.SettleSessionDeposit(f.ctx, &sess))
+	require.Equal(t, types.DepositStatus_DEPOSIT_STATUS_REFUNDED, sess.DepositStatus)
+	require.Equal(t, sdk.NewCoins(deposit), f.bank.balances[f.owner])
+	require.True(t, f.bank.balances[types.ModuleName].IsZero())
+
+	// an open session cannot be settled
+	sess = locked(types.SessionState_SESSION_STATE_DISTRIBUTING)
+	require.Error(t, f.keeper.SettleSessionDeposit(f.ctx, &sess))
+	require.Equal(t, types.DepositStatus_DEPOSIT_STATUS_LOCKED, sess.DepositStatus)
+}
+
+func TestRetryDepositSettlements(t *testing.T) {
+	f := initFixture(t)
+	deposit := sdk.NewInt64Coin("stake", 100)
+
+	// the escrow is empty, so the refund is deferred and the session queued
+	sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+	sess.Deposit = deposit
+	sess.DepositStatus = types.DepositStatus_DEPOSIT_STATUS_LOCKED
+	sess.State = types.SessionState_SESSION_STATE_CLOSED_FAILED
+	f.keeper.SettleSessionDepositOrDefer(f.ctx, &sess)
+	require.NoError(t, f.keeper.SetSession(f.ctx, sess))
+
+	queued := func() bool {
+		has, err := f.keeper.DepositSettleQueue.Has(f.ctx, sess.SessionId)
+		require.NoError(t, err)
+		return has
+	}
+	require.True(t, queued())
+
+	// still failing: the session stays queued
+	require.NoError(t, f.keeper.RetryDepositSettlements(f.ctx))
+	require.True(t, queued())
+	got, err := f.keeper.MustGetSession(f.ctx, sess.SessionId)
+	require.NoError(t, err)
+	require.Equal(t, types.DepositStatus_DEPOSIT_STATUS_LOCKED, got.DepositStatus)
+
+	f.bank.balances[types.ModuleName] = sdk.NewCoins(deposit)
+	require.NoError(t, f.keeper.RetryDepositSettlements(f.ctx))
+	require.False(t, queued())
+	got, err = f.keeper.MustGetSession(f.ctx, sess.SessionId)
+	require.NoError(t, err)
+	require.Equal(t, types.DepositStatus_DEPOSIT_STATUS_REFUNDED, got.DepositStatus)
+	require.Equal(t, sdk.NewCoins(deposit), f.bank.balances[f.owner])
+}
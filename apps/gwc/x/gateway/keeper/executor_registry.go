@@ -0,0 +1,113 @@
+package keeper
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"cosmossdk.io/collections"
+	errorsmod "cosmossdk.io/errors"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"gwc/x/gateway/types"
+)
+
+// SetExecutor は authority が executor を登録・有効化・無効化するために使用します。
+// 無効化された executor には新しいセッションが割り当てられず、既存セッションに対する操作も拒否されます。
+func (k msgServer) SetExecutor(goCtx context.Context, msg *types.MsgSetExecutor) (*types.MsgSetExecutorResponse, error) {
+	ctx := sdk.UnwrapSDKContext(goCtx)
+
+	authority, err := k.addressCodec.StringToBytes(msg.Authority)
+	if err != nil {
+		return nil, errorsmod.Wrap(err, "invalid authority address")
+	}
+	if !bytes.Equal(k.GetAuthority(), authority) {
+		expectedAuthorityStr, _ := k.addressCodec.BytesToString(k.GetAuthority())
+		return nil, errorsmod.Wrapf(types.ErrInvalidSigner, "invalid authority; expected %s, got %s", expectedAuthorityStr, msg.Authority)
+	}
+	if _, err := k.addressCodec.StringToBytes(msg.Address); err != nil {
+		return nil, errorsmod.Wrap(err, "invalid executor address")
+	}
+
+	executor := types.Executor{
+		Address: msg.Address,
+		Enabled: msg.Enabled,
+		Moniker: msg.Moniker,
+	}
+	if err := k.Keeper.Executors.Set(ctx, executor.Address, executor); err != nil {
+		return nil, err
+	}
+
+	ctx.EventManager().EmitEvent(
+		sdk.NewEvent(
+			"csu_executor_set",
+			sdk.NewAttribute("executor", executor.Address),
+			sdk.NewAttribute("enabled", fmt.Sprintf("%t", executor.Enabled)),
+		),
+	)
+
+	return &types.MsgSetExecutorResponse{}, nil
+}
+
+// enabledExecutors returns the enabled executor addresses in key (address) order.
+func (k Keeper) enabledExecutors(ctx sdk.Context) ([]string, error) {
+	var out []string
+	err := k.Executors.Walk(ctx, nil, func(addr string, e types.Executor) (bool, error) {
+		if e.Enabled {
+			out = append(out, addr)
+		}
+		return false, nil
+	})
+	return out, err
+}
+
+// isExecutorActive reports whether addr may act as a session executor.
+// Registered executors must be enabled; an unregistered address is accepted only if it is the
+// local_admin fallback (sessions assigned while the registry had no enabled executor).
+func (k Keeper) isExecutorActive(ctx sdk.Context, params types.Params, addr string) (bool, error) {
+	e, err := k.Executors.Get(ctx, addr)
+	if err == nil {
+		return e.Enabled, nil
+	}
+	if !errors.Is(err, collections.ErrNotFound) {
+		return false, err
+	}
+	return addr != "" && addr == params.LocalAdmin, nil
+}
+
+// assignExecutor はセッションの executor を決定します。
+//  1. preferred が指定されていれば、それが有効な登録済み executor であること
+//  2. 未指定なら、有効な executor の中から sha256(session_id) で決定的に選択
+//  3. 有効な executor が1つも無ければ params.local_admin にフォールバック
+func (k Keeper) assignExecutor(ctx sdk.Context, params types.Params, sessionID, preferred string) (string, error) {
+	enabled, err := k.enabledExecutors(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if preferred != "" {
+		active, err := k.isExecutorActive(ctx, params, preferred)
+		if err != nil {
+			return "", err
+		}
+		// local_admin へのフォールバックは有効な executor が無い場合のみ許可
+		if !active || (len(enabled) > 0 && !containsString(enabled, preferred)) {
+			return "", errorsmod.Wrapf(types.ErrExecutorNotActive, "preferred executor is not an enabled executor: %s", preferred)
+		}
+		return preferred, nil
+	}
+
+	if len(enabled) == 0 {
+		if params.LocalAdmin == "" {
+			return "", errorsmod.Wrap(types.ErrLocalAdminNotConfigured, "no enabled executor registered and params.local_admin is empty")
+		}
+		return params.LocalAdmin, nil
+	}
+
+	h := sha256.Sum256([]byte("executor:" + sessionID))
+	idx := binary.BigEndian.Uint64(h[:8]) % uint64(len(enabled))
+	return enabled[idx], nil
+}
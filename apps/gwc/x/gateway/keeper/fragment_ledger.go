@@ -0,0 +1,134 @@
+package keeper
+
+import (
+	"cosmossdk.io/collections"
+	errorsmod "cosmossdk.io/errors"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"gwc/x/gateway/types"
+)
+
+// SetFragmentPending records that replica number `replica` of a fragment was sent on channelID with the given IBC sequence.
+// A previous send of the same replica is overwritten; the attempt counters are carried over.
+// contentID is the content address the fragment is stored under on FDSC.
+func (k Keeper) SetFragmentPending(ctx sdk.Context, sessionID, path string, index uint64, replica int, channelID string, seq uint64, size uint64, contentID string) error {
+	key := collections.Join3(sessionID, path, index)
+	rec, err := k.FragmentDeliveries.Get(ctx, key)
+	if err != nil {
+		rec = types.FragmentDelivery{SessionId: sessionID, Path: path, Index: index}
+	}
+	rec.Replicas = rec.ReplicaSet()
+	for len(rec.Replicas) <= replica {
+		rec.Replicas = append(rec.Replicas, types.FragmentReplica{})
+	}
+	rep := &rec.Replicas[replica]
+	rep.ChannelId = channelID
+	rep.PacketSequence = seq
+	rep.Status = types.FragmentStatus_FRAGMENT_STATUS_PENDING
+	rep.Error = ""
+	rep.ChainId = ""
+	rep.Attempts++
+
+	rec.Attempts++
+	rec.Size = size
+	rec.ContentId = contentID
+	rec.UpdatedUnix = ctx.BlockTime().Unix()
+	rec.SyncReplicas()
+	return k.FragmentDeliveries.Set(ctx, key, rec)
+}
+
+// UpdateFragmentStatus moves the replica sent as (channelID, seq) to a terminal status (error / timed out).
+// Success acks go through MarkFragmentAcked so that the placement is recorded.
+// errMsg is only stored for FRAGMENT_STATUS_ERROR. An error also drops the channel from the dedup index
+// of the fragment's content.
+func (k Keeper) UpdateFragmentStatus(ctx sdk.Context, sessionID, path string, index uint64, channelID string, seq uint64, status types.FragmentStatus, errMsg string) error {
+	rec, err := k.updateFragmentReplica(ctx, sessionID, path, index, channelID, seq, func(rep *types.FragmentReplica) {
+		rep.Status = status
+		rep.Error = ""
+		if status == types.FragmentStatus_FRAGMENT_STATUS_ERROR {
+			rep.Error = errMsg
+		}
+	})
+	if err != nil || status != types.FragmentStatus_FRAGMENT_STATUS_ERROR {
+		return err
+	}
+	return k.forgetContentLocation(ctx, rec.ContentId, channelID)
+}
+
+// MarkFragmentAcked records the success ack of the replica sent as (channelID, seq) together with
+// that channel's counterparty chain ID (the FDSC actually holding the copy). The channel is added to the
+// dedup index of the fragment's content.
+func (k Keeper) MarkFragmentAcked(ctx sdk.Context, sessionID, path string, index uint64, channelID string, seq uint64, chainID string) error {
+	rec, err := k.updateFragmentReplica(ctx, sessionID, path, index, channelID, seq, func(rep *types.FragmentReplica) {
+		rep.Status = types.FragmentStatus_FRAGMENT_STATUS_ACKED
+		rep.Error = ""
+		rep.ChainId = chainID
+	})
+	if err != nil {
+		return err
+	}
+	return k.indexContentLocation(ctx, rec.ContentId, channelID, rec.Size)
+}
+
+// updateFragmentReplica applies fn to the replica sent as (channelID, seq), refreshes the aggregate status
+// and returns the updated record.
+func (k Keeper) updateFragmentReplica(ctx sdk.Context, sessionID, path string, index uint64, channelID string, seq uint64, fn func(rep *types.FragmentReplica)) (types.FragmentDelivery, error) {
+	key := collections.Join3(sessionID, path, index)
+	rec, err := k.FragmentDeliveries.Get(ctx, key)
+	if err != nil {
+		return types.FragmentDelivery{}, err
+	}
+	i := rec.FindReplica(channelID, seq)
+	if i < 0 {
+		return types.FragmentDelivery{}, errorsmod.Wrapf(collections.ErrNotFound, "no replica of %s#%d sent as %s/%d", path, index, channelID, seq)
+	}
+	rec.Replicas = rec.ReplicaSet()
+	fn(&rec.Replicas[i])
+	rec.UpdatedUnix = ctx.BlockTime().Unix()
+	rec.SyncReplicas()
+	return rec, k.FragmentDeliveries.Set(ctx, key, rec)
+}
+
+// GetFragmentDelivery loads the delivery record of a single fragment.
+func (k Keeper) GetFragmentDelivery(ctx sdk.Context, sessionID, path string, index uint64) (types.FragmentDelivery, error) {
+	return k.FragmentDeliveries.Get(ctx, collections.Join3(sessionID, path, index))
+}
+
+// WalkSessionFragments iterates all delivery records of a session in (path, index) order.
+func (k Keeper) WalkSessionFragments(ctx sdk.Context, sessionID string, fn func(rec types.FragmentDelivery) (stop bool, err error)) error {
+	rng := collections.NewPrefixedTripleRange[string, string, uint64](sessionID)
+	return k.FragmentDeliveries.Walk(ctx, rng, func(_ collections.Triple[string, string, uint64], rec types.FragmentDelivery) (bool, error) {
+		return fn(rec)
+	})
+}
+
+// ackedFragmentsByPath checks that every fragment committed by CommitRootProof has a success ack
+// on every replica and returns the delivery records grouped by path (index order).
+// reusedCount / reusedBytes are the fragments of files reused from the previous version (incremental
+// deploy): they are part of the committed totals but were not distributed in this session.
+func (k Keeper) ackedFragmentsByPath(ctx sdk.Context, sess types.Session, reusedCount, reusedBytes uint64) (map[string][]types.FragmentDelivery, error) {
+	byPath := make(map[string][]types.FragmentDelivery)
+	var count, totalBytes uint64
+	err := k.WalkSessionFragments(ctx, sess.SessionId, func(rec types.FragmentDelivery) (bool, error) {
+		if rec.Status != types.FragmentStatus_FRAGMENT_STATUS_ACKED {
+			return true, errorsmod.Wrapf(types.ErrSessionIncomplete, "fragment %s#%d is %s", rec.Path, rec.Index, rec.Status.String())
+		}
+		if n := len(rec.ReplicaSet()); n < sess.ReplicaCount() {
+			return true, errorsmod.Wrapf(types.ErrSessionIncomplete, "fragment %s#%d has %d of %d replicas", rec.Path, rec.Index, n, sess.ReplicaCount())
+		}
+		byPath[rec.Path] = append(byPath[rec.Path], rec)
+		count++
+		totalBytes += rec.Size
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if count+reusedCount != sess.ExpectedFragmentCount {
+		return nil, errorsmod.Wrapf(types.ErrSessionIncomplete, "acked fragments %d + reused %d != expected %d", count, reusedCount, sess.ExpectedFragmentCount)
+	}
+	if totalBytes+reusedBytes != sess.ExpectedTotalBytes {
+		return nil, errorsmod.Wrapf(types.ErrSessionIncomplete, "acked bytes %d + reused %d != expected %d", totalBytes, reusedBytes, sess.ExpectedTotalBytes)
+	}
+	return byPath, nil
+}
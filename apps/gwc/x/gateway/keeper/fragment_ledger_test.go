@@ -0,0 +1,155 @@
+package keeper
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"gwc/x/gateway/types"
+)
+
+// ackFragment records a successful delivery of every replica of (path, index) with the given size.
+func ackFragment(t *testing.T, f *fixture, sess types.Session, path string, index, size uint64) {
+	t.Helper()
+	for replica := 0; replica < sess.ReplicaCount(); replica++ {
+		ch := []string{"channel-1", "channel-2", "channel-3"}[replica]
+		seq := index*10 + uint64(replica) + 1
+		require.NoError(t, f.keeper.SetFragmentPending(f.ctx, sess.SessionId, path, index, replica, ch, seq, size, "cid-"+path))
+		require.NoError(t, f.keeper.MarkFragmentAcked(f.ctx, sess.SessionId, path, index, ch, seq, "fdsc-"+ch))
+	}
+}
+
+func TestAckedFragmentsByPathExpectedTotals(t *testing.T) {
+	f := initFixture(t)
+	sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+	sess.ExpectedFragmentCount = 3
+	sess.ExpectedTotalBytes = 64 + 10 + 5
+	ackFragment(t, f, sess, "a.txt", 0, 64)
+	ackFragment(t, f, sess, "a.txt", 1, 10)
+
+	// one fragment short
+	_, err := f.keeper.ackedFragmentsByPath(f.ctx, sess, 0, 0)
+	require.ErrorIs(t, err, types.ErrSessionIncomplete)
+	require.ErrorContains(t, err, "acked fragments 2 + reused 0 != expected 3")
+
+	// reused fragments of the previous version count towards the totals
+	byPath, err := f.keeper.ackedFragmentsByPath(f.ctx, sess, 1, 5)
+	require.NoError(t, err)
+	require.Len(t, byPath["a.txt"], 2)
+	require.Equal(t, uint64(0), byPath["a.txt"][0].Index)
+	require.Equal(t, uint64(1), byPath["a.txt"][1].Index)
+
+	// the count matches but the bytes do not
+	_, err = f.keeper.ackedFragmentsByPath(f.ctx, sess, 1, 4)
+	require.ErrorIs(t, err, types.ErrSessionIncomplete)
+	require.ErrorContains(t, err, "acked bytes 74 + reused 4 != expected 79")
+
+	ackFragment(t, f, sess, "b.txt", 0, 5)
+	_, err = f.keeper.ackedFragmentsByPath(f.ctx, sess, 0, 0)
+	require.NoError(t, err)
+
+	// more fragments than committed
+	ackFragment(t, f, sess, "c.txt", 0, 1)
+	_, err = f.keeper.ackedFragmentsByPath(f.ctx, sess, 0, 0)
+	require.ErrorContains(t, err, "acked fragments 4 + reused 0 != expected 3")
+}
+
+func TestAckedFragmentsByPathRequiresEveryReplica(t *testing.T) {
+	f := initFixture(t)
+	sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+	sess.ExpectedFragmentCount = 2
+	sess.ExpectedTotalBytes = 20
+	ackFragment(t, f, sess, "a.txt", 0, 10)
+
+	// a fragment still in flight
+	require.NoError(t, f.keeper.SetFragmentPending(f.ctx, sess.SessionId, "a.txt", 1, 0, "channel-1", 99, 10, "cid"))
+	_, err := f.keeper.ackedFragmentsByPath(f.ctx, sess, 0, 0)
+	require.ErrorIs(t, err, types.ErrSessionIncomplete)
+	require.ErrorContains(t, err, "a.txt#1 is FRAGMENT_STATUS_PENDING")
+
+	require.NoError(t, f.keeper.MarkFragmentAcked(f.ctx, sess.SessionId, "a.txt", 1, "channel-1", 99, "fdsc-1"))
+	_, err = f.keeper.ackedFragmentsByPath(f.ctx, sess, 0, 0)
+	require.NoError(t, err)
+
+	// with replication every fragment needs an ack on each replica
+	sess.ReplicationFactor = 2
+	_, err = f.keeper.ackedFragmentsByPath(f.ctx, sess, 0, 0)
+	require.ErrorContains(t, err, "a.txt#0 has 1 of 2 replicas")
+}
+
+func TestCommitRootProofExpectedTotals(t *testing.T) {
+	f := initFixture(t)
+	f.setParams(t, func(p *types.Params) { p.MaxFragmentsPerSession = 10 })
+
+	commit := func(sess types.Session, count, bytes uint64) error {
+		_, err := f.msgServer.CommitRootProof(f.ctx, &types.MsgCommitRootProof{
+			Owner:                 f.owner,
+			SessionId:             sess.SessionId,
+			RootProofHex:          "abcd",
+			ExpectedFragmentCount: count,
+			ExpectedTotalBytes:    bytes,
+		})
+		return err
+	}
+
+	// fragment_size is 64
+	sess := f.newSession(t, types.SessionState_SESSION_STATE_INIT)
+	require.ErrorIs(t, commit(sess, 0, 0), types.ErrInvalidRootProof)
+	require.ErrorIs(t, commit(sess, 11, 11), types.ErrLimitExceeded)
+	require.ErrorIs(t, commit(sess, 2, 1), types.ErrInvalidRootProof)
+	require.ErrorIs(t, commit(sess, 2, 129), types.ErrInvalidRootProof)
+
+	sess.DeclaredTotalBytes = 100
+	require.NoError(t, f.keeper.SetSession(f.ctx, sess))
+	require.ErrorIs(t, commit(sess, 2, 101), types.ErrDepositInsufficient)
+
+	require.NoError(t, commit(sess, 2, 100))
+	got, err := f.keeper.MustGetSession(f.ctx, sess.SessionId)
+	require.NoError(t, err)
+	require.Equal(t, types.SessionState_SESSION_STATE_ROOT_COMMITTED, got.State)
+	require.Equal(t, uint64(2), got.ExpectedFragmentCount)
+	require.Equal(t, uint64(100), got.ExpectedTotalBytes)
+
+	// the totals can only be committed once
+	require.ErrorIs(t, commit(got, 2, 100), types.ErrSessionInvalidState)
+
+	// erasure-coded totals include the parity shards the deposit covers (100 data + 64 parity bytes)
+	ec := f.newSession(t, types.SessionState_SESSION_STATE_INIT)
+	ec.DeclaredTotalBytes = 100
+	ec.ErasureDataShards = 2
+	ec.ErasureParityShards = 1
+	require.NoError(t, f.keeper.SetSession(f.ctx, ec))
+	require.ErrorIs(t, commit(ec, 3, 165), types.ErrDepositInsufficient)
+	require.NoError(t, commit(ec, 3, 164))
+
+	// count * fragment_size overflows uint64 but the totals are consistent
+	f.setParams(t, func(p *types.Params) {
+		p.MaxFragmentsPerSession = 1 << 60
+		p.OwnerQuotaWindowSeconds = 0
+	})
+	huge := f.newSession(t, types.SessionState_SESSION_STATE_INIT)
+	require.ErrorIs(t, commit(huge, 1<<58, 1<<58-1), types.ErrInvalidRootProof)
+	require.NoError(t, commit(huge, 1<<58, 1<<58+1))
+}
+
+func TestDistributeBatchRejectsMoreThanExpected(t *testing.T) {
+	f := initFixture(t)
+	require.NoError(t, f.keeper.DatastoreChannels.Set(f.ctx, "channel-1"))
+	sess := f.newSession(t, types.SessionState_SESSION_STATE_ROOT_COMMITTED)
+	sess.ExpectedFragmentCount = 1
+	sess.ExpectedTotalBytes = 1
+	require.NoError(t, f.keeper.SetSession(f.ctx, sess))
+	f.grantSessionBound(sess, sdk.MsgTypeURL(&types.MsgDistributeBatch{}), 0, 0)
+
+	_, err := f.msgServer.DistributeBatch(f.ctx, &types.MsgDistributeBatch{
+		Executor:  f.executor,
+		SessionId: sess.SessionId,
+		Items: []types.DistributeItem{
+			{Path: "a.txt", Index: 0, FragmentBytes: []byte("a")},
+			{Path: "a.txt", Index: 1, FragmentBytes: []byte("b")},
+		},
+	})
+	require.ErrorIs(t, err, types.ErrLimitExceeded)
+	require.ErrorContains(t, err, "expected_fragment_count exceeded")
+}
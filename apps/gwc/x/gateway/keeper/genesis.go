@@ -3,10 +3,12 @@ package keeper
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"gwc/x/gateway/types"
 
 	"cosmossdk.io/collections"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
 // InitGenesis initializes the module's state from a provided genesis state.
@@ -15,6 +17,101 @@ func (k Keeper) InitGenesis(ctx context.Context, genState types.GenesisState) er
 		return err
 	}
 
+	// --- storage routing ---
+	for _, info := range genState.StorageInfos {
+		if err := k.StorageInfos.Set(ctx, info.ChannelId, info); err != nil {
+			return err
+		}
+	}
+	for _, ch := range genState.DatastoreChannels {
+		if err := k.DatastoreChannels.Set(ctx, ch); err != nil {
+			return err
+		}
+	}
+	if genState.MetastoreChannel != "" {
+		if err := k.MetastoreChannel.Set(ctx, genState.MetastoreChannel); err != nil {
+			return err
+		}
+	}
+
+	// --- CSU sessions ---
+	// SetSession rebuilds the expiry / prune queues from the session state.
+	sdkCtx := sdk.UnwrapSDKContext(ctx)
+	for _, s := range genState.Sessions {
+		if err := k.SetSession(sdkCtx, s); err != nil {
+			return err
+		}
+	}
+	if err := k.SessionSeq.Set(ctx, genState.SessionSeq); err != nil {
+		return err
+	}
+	for _, d := range genState.FragmentDeliveries {
+		if err := k.FragmentDeliveries.Set(ctx, collections.Join3(d.SessionId, d.Path, d.Index), d); err != nil {
+			return err
+		}
+	}
+	for _, f := range genState.SessionFiles {
+		if err := k.SessionFiles.Set(ctx, collections.Join(f.SessionId, f.Path), f); err != nil {
+			return err
+		}
+	}
+	for _, f := range genState.SeenFragments {
+		if err := k.SessionFragmentSeen.Set(ctx, MakeFragKey(f.SessionId, f.Path, f.Index)); err != nil {
+			return err
+		}
+	}
+	for _, b := range genState.FragmentSeqBindings {
+		if err := k.BindFragmentSeq(sdkCtx, b.ChannelId, b.Sequence, b.Fragment.SessionId, b.Fragment.Path, b.Fragment.Index); err != nil {
+			return err
+		}
+	}
+	for _, b := range genState.ManifestSeqBindings {
+		if err := k.BindManifestSeq(sdkCtx, b.Sequence, b.SessionId); err != nil {
+			return err
+		}
+	}
+	for _, t := range genState.UploadTokenHashes {
+		if err := k.SetUploadTokenHash(sdkCtx, t.SessionId, t.TokenHash); err != nil {
+			return err
+		}
+	}
+
+	// --- per-owner quotas ---
+	for _, e := range genState.OwnerUsageEntries {
+		if err := k.OwnerUsage.Set(ctx, collections.Join3(e.Owner, e.CommittedUnix, e.SessionId), e); err != nil {
+			return err
+		}
+	}
+	for _, l := range genState.OwnerLastInits {
+		if err := k.OwnerLastInit.Set(ctx, l.Owner, l.Unix); err != nil {
+			return err
+		}
+	}
+
+	// --- executor registry ---
+	for _, e := range genState.Executors {
+		if err := k.Executors.Set(ctx, e.Address, e); err != nil {
+			return err
+		}
+	}
+
+	// --- FDSC channel health ---
+	for _, h := range genState.ChannelHealth {
+		if err := k.ChannelHealth.Set(ctx, h.ChannelId, h); err != nil {
+			return err
+		}
+	}
+	if err := k.ChannelSendSeq.Set(ctx, genState.ChannelSendSeq); err != nil {
+		return err
+	}
+
+	// --- content-addressed dedup index ---
+	for _, l := range genState.ContentIndex {
+		if err := k.ContentIndex.Set(ctx, collections.Join(l.ContentId, l.ChannelId), l.Size); err != nil {
+			return err
+		}
+	}
+
 	return k.Params.Set(ctx, genState.Params)
 }
 
@@ -32,5 +129,133 @@ func (k Keeper) ExportGenesis(ctx context.Context) (*types.GenesisState, error)
 		return nil, err
 	}
 
+	// --- storage routing ---
+	if err := k.StorageInfos.Walk(ctx, nil, func(_ string, info types.StorageInfo) (bool, error) {
+		genesis.StorageInfos = append(genesis.StorageInfos, info)
+		return false, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := k.DatastoreChannels.Walk(ctx, nil, func(ch string) (bool, error) {
+		genesis.DatastoreChannels = append(genesis.DatastoreChannels, ch)
+		return false, nil
+	}); err != nil {
+		return nil, err
+	}
+	genesis.MetastoreChannel, err = k.MetastoreChannel.Get(ctx)
+	if err != nil && !errors.Is(err, collections.ErrNotFound) {
+		return nil, err
+	}
+
+	// --- CSU sessions ---
+	if err := k.Sessions.Walk(ctx, nil, func(_ string, s types.Session) (bool, error) {
+		genesis.Sessions = append(genesis.Sessions, s)
+		return false, nil
+	}); err != nil {
+		return nil, err
+	}
+	genesis.SessionSeq, err = k.SessionSeq.Peek(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.FragmentDeliveries.Walk(ctx, nil, func(_ collections.Triple[string, string, uint64], d types.FragmentDelivery) (bool, error) {
+		genesis.FragmentDeliveries = append(genesis.FragmentDeliveries, d)
+		return false, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := k.SessionFiles.Walk(ctx, nil, func(_ collections.Pair[string, string], f types.SessionFile) (bool, error) {
+		genesis.SessionFiles = append(genesis.SessionFiles, f)
+		return false, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := k.SessionFragmentSeen.Walk(ctx, nil, func(fragKey string) (bool, error) {
+		sessionID, path, index, ok := ParseFragKey(fragKey)
+		if !ok {
+			return true, fmt.Errorf("malformed fragment key %q", fragKey)
+		}
+		genesis.SeenFragments = append(genesis.SeenFragments, types.FragmentRef{SessionId: sessionID, Path: path, Index: index})
+		return false, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := k.FragmentSeqToFragmentKey.Walk(ctx, nil, func(seqKey, fragKey string) (bool, error) {
+		channelID, seq, ok := ParseChannelSeqKey(seqKey)
+		if !ok {
+			return true, fmt.Errorf("malformed fragment seq key %q", seqKey)
+		}
+		sessionID, path, index, ok := ParseFragKey(fragKey)
+		if !ok {
+			return true, fmt.Errorf("malformed fragment key %q", fragKey)
+		}
+		genesis.FragmentSeqBindings = append(genesis.FragmentSeqBindings, types.FragmentSeqBinding{
+			ChannelId: channelID,
+			Sequence:  seq,
+			Fragment:  types.FragmentRef{SessionId: sessionID, Path: path, Index: index},
+		})
+		return false, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := k.ManifestSeqToSessionID.Walk(ctx, nil, func(seqKey, sessionID string) (bool, error) {
+		seq, ok := ParseSeqKey(seqKey)
+		if !ok {
+			return true, fmt.Errorf("malformed manifest seq key %q", seqKey)
+		}
+		genesis.ManifestSeqBindings = append(genesis.ManifestSeqBindings, types.ManifestSeqBinding{Sequence: seq, SessionId: sessionID})
+		return false, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := k.SessionUploadTokenHash.Walk(ctx, nil, func(sessionID string, tokenHash []byte) (bool, error) {
+		genesis.UploadTokenHashes = append(genesis.UploadTokenHashes, types.UploadTokenHash{SessionId: sessionID, TokenHash: tokenHash})
+		return false, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// --- per-owner quotas ---
+	if err := k.OwnerUsage.Walk(ctx, nil, func(_ collections.Triple[string, int64, string], e types.OwnerUsageEntry) (bool, error) {
+		genesis.OwnerUsageEntries = append(genesis.OwnerUsageEntries, e)
+		return false, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := k.OwnerLastInit.Walk(ctx, nil, func(owner string, unix int64) (bool, error) {
+		genesis.OwnerLastInits = append(genesis.OwnerLastInits, types.OwnerLastInit{Owner: owner, Unix: unix})
+		return false, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// --- executor registry ---
+	if err := k.Executors.Walk(ctx, nil, func(_ string, e types.Executor) (bool, error) {
+		genesis.Executors = append(genesis.Executors, e)
+		return false, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// --- FDSC channel health ---
+	if err := k.ChannelHealth.Walk(ctx, nil, func(_ string, h types.ChannelHealth) (bool, error) {
+		genesis.ChannelHealth = append(genesis.ChannelHealth, h)
+		return false, nil
+	}); err != nil {
+		return nil, err
+	}
+	genesis.ChannelSendSeq, err = k.ChannelSendSeq.Peek(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// --- content-addressed dedup index ---
+	if err := k.ContentIndex.Walk(ctx, nil, func(key collections.Pair[string, string], size uint64) (bool, error) {
+		genesis.ContentIndex = append(genesis.ContentIndex, types.ContentLocation{ContentId: key.K1(), ChannelId: key.K2(), Size: size})
+		return false, nil
+	}); err != nil {
+		return nil, err
+	}
+
 	return genesis, nil
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/cosmos/cosmos-sdk/codec"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	ibckeeper "github.com/cosmos/ibc-go/v10/modules/core/keeper"
+	ibctm "github.com/cosmos/ibc-go/v10/modules/light-clients/07-tendermint"
 
 	"gwc/x/gateway/types"
 )
@@ -32,6 +33,21 @@ type Keeper struct {
 	FragmentSeqToFragmentKey collections.Map[string, string]
 	ManifestSeqToSessionID   collections.Map[string, string]
 	SessionUploadTokenHash   collections.Map[string, []byte]
+	SessionSeq               collections.Sequence
+	FragmentDeliveries       collections.Map[collections.Triple[string, string, uint64], types.FragmentDelivery]
+	SessionFiles             collections.Map[collections.Pair[string, string], types.SessionFile]
+	SessionExpiryQueue       collections.KeySet[collections.Pair[int64, string]]
+	SessionPruneQueue        collections.KeySet[collections.Pair[int64, string]]
+	SessionsByOwner          collections.KeySet[collections.Pair[string, string]]
+	SessionsByState          collections.KeySet[collections.Pair[int32, string]]
+	DepositSettleQueue       collections.KeySet[string]
+	OwnerOpenSessions        collections.Map[string, uint64]
+	OwnerLastInit            collections.Map[string, int64]
+	OwnerUsage               collections.Map[collections.Triple[string, int64, string], types.OwnerUsageEntry]
+	Executors                collections.Map[string, types.Executor]
+	ChannelHealth            collections.Map[string, types.ChannelHealth]
+	ChannelSendSeq           collections.Sequence
+	ContentIndex             collections.Map[collections.Pair[string, string], uint64]
 
 	ibcKeeperFn   func() *ibckeeper.Keeper
 	bankKeeper    types.BankKeeper
@@ -81,6 +97,33 @@ func NewKeeper(
 		FragmentSeqToFragmentKey: collections.NewMap(sb, types.FragmentSeqToFragmentKey, "fragment_seq_to_fragment_key", collections.StringKey, collections.StringValue),
 		ManifestSeqToSessionID:   collections.NewMap(sb, types.ManifestSeqToSessionKey, "manifest_seq_to_session_id", collections.StringKey, collections.StringValue),
 		SessionUploadTokenHash:   collections.NewMap(sb, types.SessionUploadTokenHashKey, "session_upload_token_hash", collections.StringKey, collections.BytesValue),
+		SessionSeq:               collections.NewSequence(sb, types.SessionSeqKey, "session_seq"),
+		FragmentDeliveries: collections.NewMap(sb, types.FragmentDeliveryKey, "fragment_deliveries",
+			collections.TripleKeyCodec(collections.StringKey, collections.StringKey, collections.Uint64Key),
+			codec.CollValue[types.FragmentDelivery](cdc)),
+		SessionFiles: collections.NewMap(sb, types.SessionFileKey, "session_files",
+			collections.PairKeyCodec(collections.StringKey, collections.StringKey),
+			codec.CollValue[types.SessionFile](cdc)),
+		SessionExpiryQueue: collections.NewKeySet(sb, types.SessionExpiryQueueKey, "session_expiry_queue",
+			collections.PairKeyCodec(collections.Int64Key, collections.StringKey)),
+		SessionPruneQueue: collections.NewKeySet(sb, types.SessionPruneQueueKey, "session_prune_queue",
+			collections.PairKeyCodec(collections.Int64Key, collections.StringKey)),
+		SessionsByOwner: collections.NewKeySet(sb, types.SessionOwnerIndexKey, "sessions_by_owner",
+			collections.PairKeyCodec(collections.StringKey, collections.StringKey)),
+		SessionsByState: collections.NewKeySet(sb, types.SessionStateIndexKey, "sessions_by_state",
+			collections.PairKeyCodec(collections.Int32Key, collections.StringKey)),
+		DepositSettleQueue: collections.NewKeySet(sb, types.DepositSettleQueueKey, "deposit_settle_queue",
+			collections.StringKey),
+		OwnerOpenSessions: collections.NewMap(sb, types.OwnerOpenSessionsKey, "owner_open_sessions", collections.StringKey, collections.Uint64Value),
+		OwnerLastInit:     collections.NewMap(sb, types.OwnerLastInitKey, "owner_last_init", collections.StringKey, collections.Int64Value),
+		OwnerUsage: collections.NewMap(sb, types.OwnerUsageKey, "owner_usage",
+			collections.TripleKeyCodec(collections.StringKey, collections.Int64Key, collections.StringKey),
+			codec.CollValue[types.OwnerUsageEntry](cdc)),
+		Executors:      collections.NewMap(sb, types.ExecutorKey, "executors", collections.StringKey, codec.CollValue[types.Executor](cdc)),
+		ChannelHealth:  collections.NewMap(sb, types.ChannelHealthKey, "channel_health", collections.StringKey, codec.CollValue[types.ChannelHealth](cdc)),
+		ChannelSendSeq: collections.NewSequence(sb, types.ChannelSendSeqKey, "channel_send_seq"),
+		ContentIndex: collections.NewMap(sb, types.ContentIndexKey, "content_index",
+			collections.PairKeyCodec(collections.StringKey, collections.StringKey), collections.Uint64Value),
 	}
 
 	schema, err := sb.Build()
@@ -92,37 +135,6 @@ func NewKeeper(
 	return k
 }
 
-// HandleExpiredSessions は期限を過ぎたセッションを安全にクローズし、権限を剥奪します。
-func (k Keeper) HandleExpiredSessions(ctx sdk.Context) error {
-	currentTime := ctx.BlockTime().Unix()
-	var expiredIDs []string
-
-	err := k.Sessions.Walk(ctx, nil, func(id string, sess types.Session) (bool, error) {
-		if sess.State != types.SessionState_SESSION_STATE_CLOSED_SUCCESS &&
-			sess.State != types.SessionState_SESSION_STATE_CLOSED_FAILED &&
-			sess.DeadlineUnix < currentTime {
-			expiredIDs = append(expiredIDs, id)
-		}
-		return false, nil
-	})
-	if err != nil {
-		return err
-	}
-
-	for _, id := range expiredIDs {
-		sess, _ := k.Sessions.Get(ctx, id)
-		sess.State = types.SessionState_SESSION_STATE_CLOSED_FAILED
-		sess.CloseReason = "EXPIRED"
-		k.SetSession(ctx, sess)
-
-		// 権限の物理的撤去
-		k.RevokeCSUGrants(ctx, sess.Owner)
-
-		ctx.Logger().Info("Session expired and closed", "session_id", id)
-	}
-	return nil
-}
-
 func (k Keeper) GetAuthority() []byte {
 	return k.authority
 }
@@ -148,8 +160,26 @@ func (k Keeper) RegisterChannel(ctx sdk.Context, portID, channelID string) error
 
 	info := types.StorageInfo{
 		ChannelId:      channelID,
+		ChainId:        k.CounterpartyChainID(ctx, portID, channelID),
 		ConnectionType: connectionType,
 	}
 	k.StorageInfos.Set(ctx, channelID, info)
 	return nil
 }
+
+// CounterpartyChainID resolves the chain ID on the other end of a channel from its light client state.
+// Returns "" if the channel or a tendermint client state cannot be found.
+func (k Keeper) CounterpartyChainID(ctx sdk.Context, portID, channelID string) string {
+	ibcK := k.ibcKeeperFn()
+	if ibcK == nil {
+		return ""
+	}
+	_, clientState, err := ibcK.ChannelKeeper.GetChannelClientState(ctx, portID, channelID)
+	if err != nil {
+		return ""
+	}
+	if cs, ok := clientState.(*ibctm.ClientState); ok {
+		return cs.ChainId
+	}
+	return ""
+}
@@ -0,0 +1,259 @@
+package keeper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"cosmossdk.io/collections"
+	"cosmossdk.io/core/address"
+	storetypes "cosmossdk.io/store/types"
+	"cosmossdk.io/x/feegrant"
+	"github.com/cosmos/cosmos-sdk/codec"
+	addresscodec "github.com/cosmos/cosmos-sdk/codec/address"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/runtime"
+	"github.com/cosmos/cosmos-sdk/testutil"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	"github.com/cosmos/cosmos-sdk/x/authz"
+	ibckeeper "github.com/cosmos/ibc-go/v10/modules/core/keeper"
+	"github.com/stretchr/testify/require"
+
+	"gwc/x/gateway/types"
+)
+
+// fixture is an in-memory gateway keeper with fake bank / authz / feegrant keepers.
+// The IBC keeper is nil, so anything that transmits a packet fails with "ibc keeper is nil".
+type fixture struct {
+	ctx          sdk.Context
+	keeper       Keeper
+	msgServer    types.MsgServer
+	addressCodec address.Codec
+	bank         *fakeBankKeeper
+	authz        *fakeAuthzKeeper
+	feegrant     *fakeFeegrantKeeper
+
+	owner    string
+	executor string
+}
+
+func initFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	registry := codectypes.NewInterfaceRegistry()
+	types.RegisterInterfaces(registry)
+	cdc := codec.NewProtoCodec(registry)
+	addressCodec := addresscodec.NewBech32Codec(sdk.GetConfig().GetBech32AccountAddrPrefix())
+
+	storeKey := storetypes.NewKVStoreKey(types.StoreKey)
+	ctx := testutil.DefaultContextWithDB(t, storeKey, storetypes.NewTransientStoreKey("transient_test")).Ctx
+	ctx = ctx.WithBlockTime(time.Unix(1_700_000_000, 0)).WithBlockHeight(10)
+
+	bank := newFakeBankKeeper()
+	authzKeeper := newFakeAuthzKeeper()
+	feegrantKeeper := &fakeFeegrantKeeper{}
+	authority := authtypes.NewModuleAddress(types.GovModuleName)
+
+	k := NewKeeper(
+		runtime.NewKVStoreService(storeKey),
+		cdc,
+		addressCodec,
+		authority,
+		func() *ibckeeper.Keeper { return nil },
+		bank,
+		fakeAccountKeeper{},
+		authzKeeper,
+		feegrantKeeper,
+	)
+
+	f := &fixture{
+		ctx:          ctx,
+		keeper:       k,
+		msgServer:    NewMsgServerImpl(k),
+		addressCodec: addressCodec,
+		bank:         bank,
+		authz:        authzKeeper,
+		feegrant:     feegrantKeeper,
+		owner:        sdk.AccAddress([]byte("owner_______________")).String(),
+		executor:     sdk.AccAddress([]byte("executor____________")).String(),
+	}
+
+	params := types.DefaultParams()
+	params.LocalAdmin = f.executor
+	require.NoError(t, k.Params.Set(ctx, params))
+	require.NoError(t, k.Executors.Set(ctx, f.executor, types.Executor{Address: f.executor, Enabled: true}))
+	return f
+}
+
+// setParams applies fn to the stored params.
+func (f *fixture) setParams(t *testing.T, fn func(p *types.Params)) {
+	t.Helper()
+	params, err := f.keeper.Params.Get(f.ctx)
+	require.NoError(t, err)
+	fn(&params)
+	require.NoError(t, f.keeper.Params.Set(f.ctx, params))
+}
+
+// newSession stores a session of f.owner assigned to f.executor in the given state.
+func (f *fixture) newSession(t *testing.T, state types.SessionState) types.Session {
+	t.Helper()
+	id, err := f.keeper.NextSessionID(f.ctx, f.owner)
+	require.NoError(t, err)
+	sess := types.Session{
+		SessionId:    id,
+		Owner:        f.owner,
+		Executor:     f.executor,
+		FragmentSize: 64,
+		State:        state,
+		CreatedUnix:  f.ctx.BlockTime().Unix(),
+		DeadlineUnix: f.ctx.BlockTime().Unix() + 3600,
+	}
+	if state != types.SessionState_SESSION_STATE_INIT {
+		sess.RootProofHex = "00"
+	}
+	require.NoError(t, f.keeper.SetSession(f.ctx, sess))
+	return sess
+}
+
+// grantSessionBound grants f.executor a SessionBoundAuthorization of the session for msgTypeURL.
+func (f *fixture) grantSessionBound(sess types.Session, msgTypeURL string, bytes, msgs uint64) {
+	f.authz.grant(f.executor, sess.Owner, &types.SessionBoundAuthorization{
+		SessionId:      sess.SessionId,
+		MsgTypeUrl:     msgTypeURL,
+		BytesRemaining: bytes,
+		MsgsRemaining:  msgs,
+	}, nil)
+}
+
+type fakeAccountKeeper struct{}
+
+func (fakeAccountKeeper) GetAccount(context.Context, sdk.AccAddress) sdk.AccountI { return nil }
+
+func (fakeAccountKeeper) GetModuleAddress(moduleName string) sdk.AccAddress {
+	return authtypes.NewModuleAddress(moduleName)
+}
+
+// fakeBankKeeper keeps balances of accounts (bech32) and module accounts (module name).
+type fakeBankKeeper struct {
+	balances map[string]sdk.Coins
+}
+
+func newFakeBankKeeper() *fakeBankKeeper {
+	return &fakeBankKeeper{balances: make(map[string]sdk.Coins)}
+}
+
+func (b *fakeBankKeeper) SpendableCoins(_ context.Context, addr sdk.AccAddress) sdk.Coins {
+	return b.balances[addr.String()]
+}
+
+func (b *fakeBankKeeper) GetAllBalances(_ context.Context, addr sdk.AccAddress) sdk.Coins {
+	return b.balances[addr.String()]
+}
+
+func (b *fakeBankKeeper) SendCoinsFromAccountToModule(_ context.Context, sender sdk.AccAddress, module string, amt sdk.Coins) error {
+	return b.move(sender.String(), module, amt)
+}
+
+func (b *fakeBankKeeper) SendCoinsFromModuleToAccount(_ context.Context, module string, recipient sdk.AccAddress, amt sdk.Coins) error {
+	return b.move(module, recipient.String(), amt)
+}
+
+func (b *fakeBankKeeper) SendCoinsFromModuleToModule(_ context.Context, from, to string, amt sdk.Coins) error {
+	return b.move(from, to, amt)
+}
+
+func (b *fakeBankKeeper) move(from, to string, amt sdk.Coins) error {
+	left, negative := b.balances[from].SafeSub(amt...)
+	if negative {
+		return fmt.Errorf("insufficient funds: %s < %s", b.balances[from], amt)
+	}
+	b.balances[from] = left
+	b.balances[to] = b.balances[to].Add(amt...)
+	return nil
+}
+
+type fakeGrant struct {
+	auth       authz.Authorization
+	expiration *time.Time
+}
+
+// fakeAuthzKeeper stores grants by (grantee, granter, msg type url).
+type fakeAuthzKeeper struct {
+	grants map[string]fakeGrant
+}
+
+func newFakeAuthzKeeper() *fakeAuthzKeeper {
+	return &fakeAuthzKeeper{grants: make(map[string]fakeGrant)}
+}
+
+func grantKey(grantee, granter, msgTypeURL string) string {
+	return grantee + "/" + granter + "/" + msgTypeURL
+}
+
+func (a *fakeAuthzKeeper) grant(grantee, granter string, auth authz.Authorization, expiration *time.Time) {
+	a.grants[grantKey(grantee, granter, auth.MsgTypeURL())] = fakeGrant{auth: auth, expiration: expiration}
+}
+
+func (a *fakeAuthzKeeper) get(grantee, granter, msgTypeURL string) (authz.Authorization, bool) {
+	g, ok := a.grants[grantKey(grantee, granter, msgTypeURL)]
+	return g.auth, ok
+}
+
+func (a *fakeAuthzKeeper) GetAuthorization(_ context.Context, grantee, granter sdk.AccAddress, msgTypeURL string) (authz.Authorization, *time.Time) {
+	g, ok := a.grants[grantKey(grantee.String(), granter.String(), msgTypeURL)]
+	if !ok {
+		return nil, nil
+	}
+	return g.auth, g.expiration
+}
+
+func (a *fakeAuthzKeeper) DeleteGrant(_ context.Context, grantee, granter sdk.AccAddress, msgTypeURL string) error {
+	key := grantKey(grantee.String(), granter.String(), msgTypeURL)
+	if _, ok := a.grants[key]; !ok {
+		return authz.ErrNoAuthorizationFound
+	}
+	delete(a.grants, key)
+	return nil
+}
+
+func (a *fakeAuthzKeeper) SaveGrant(_ context.Context, grantee, granter sdk.AccAddress, auth authz.Authorization, expiration *time.Time) error {
+	a.grant(grantee.String(), granter.String(), auth, expiration)
+	return nil
+}
+
+// fakeFeegrantKeeper records revoked allowances.
+type fakeFeegrantKeeper struct {
+	revoked []feegrant.MsgRevokeAllowance
+}
+
+func (fg *fakeFeegrantKeeper) RevokeAllowance(_ context.Context, msg *feegrant.MsgRevokeAllowance) (*feegrant.MsgRevokeAllowanceResponse, error) {
+	fg.revoked = append(fg.revoked, *msg)
+	return &feegrant.MsgRevokeAllowanceResponse{}, nil
+}
+
+func TestSetSessionMaintainsIndexes(t *testing.T) {
+	f := initFixture(t)
+	sess := f.newSession(t, types.SessionState_SESSION_STATE_INIT)
+
+	has, err := f.keeper.SessionExpiryQueue.Has(f.ctx, collections.Join(sess.DeadlineUnix, sess.SessionId))
+	require.NoError(t, err)
+	require.True(t, has)
+	open, err := f.keeper.ownerOpenSessions(f.ctx, f.owner)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), open)
+
+	sess.State = types.SessionState_SESSION_STATE_CLOSED_FAILED
+	require.NoError(t, f.keeper.SetSession(f.ctx, sess))
+
+	has, err = f.keeper.SessionExpiryQueue.Has(f.ctx, collections.Join(sess.DeadlineUnix, sess.SessionId))
+	require.NoError(t, err)
+	require.False(t, has)
+	has, err = f.keeper.SessionPruneQueue.Has(f.ctx, collections.Join(f.ctx.BlockTime().Unix(), sess.SessionId))
+	require.NoError(t, err)
+	require.True(t, has)
+	open, err = f.keeper.ownerOpenSessions(f.ctx, f.owner)
+	require.NoError(t, err)
+	require.Zero(t, open)
+}
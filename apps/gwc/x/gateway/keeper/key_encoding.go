@@ -1,6 +1,10 @@
 package keeper
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 // MakeFragKey encodes (session_id, path, index) into a single unique string key.
 //
@@ -15,8 +19,50 @@ func MakeFragKey(sessionID, path string, index uint64) string {
 	return sessionID + "\x00" + path + "\x00" + fmt.Sprintf("%020d", index)
 }
 
+// ParseFragKey decodes a key produced by MakeFragKey back into (session_id, path, index).
+func ParseFragKey(fragKey string) (sessionID, path string, index uint64, ok bool) {
+	parts := strings.Split(fragKey, "\x00")
+	if len(parts) != 3 {
+		return "", "", 0, false
+	}
+	index, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return "", "", 0, false
+	}
+	return parts[0], parts[1], index, true
+}
+
 // MakeSeqKey encodes an IBC packet sequence (uint64) into a lexicographically stable key.
 // We use zero-padded decimal for stable string ordering and easy debugging.
 func MakeSeqKey(seq uint64) string {
 	return fmt.Sprintf("%020d", seq)
 }
+
+// MakeChannelSeqKey encodes (channel_id, sequence) into a single key.
+// IBC packet sequences are per-channel, so fragment packets sent on different FDSC channels
+// can share the same sequence number.
+func MakeChannelSeqKey(channelID string, seq uint64) string {
+	return channelID + "/" + MakeSeqKey(seq)
+}
+
+// ParseSeqKey decodes a key produced by MakeSeqKey.
+func ParseSeqKey(seqKey string) (uint64, bool) {
+	seq, err := strconv.ParseUint(seqKey, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seq, true
+}
+
+// ParseChannelSeqKey decodes a key produced by MakeChannelSeqKey back into (channel_id, sequence).
+func ParseChannelSeqKey(key string) (channelID string, seq uint64, ok bool) {
+	i := strings.LastIndex(key, "/")
+	if i <= 0 {
+		return "", 0, false
+	}
+	seq, ok = ParseSeqKey(key[i+1:])
+	if !ok {
+		return "", 0, false
+	}
+	return key[:i], seq, true
+}
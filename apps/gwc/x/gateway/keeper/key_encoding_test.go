@@ -0,0 +1,41 @@
+package keeper
+
+import "testing"
+
+func TestParseFragKey_RoundTrip(t *testing.T) {
+	key := MakeFragKey("cosmos1owner-7", "assets/app.js", 42)
+
+	sessionID, path, index, ok := ParseFragKey(key)
+	if !ok {
+		t.Fatalf("expected ok")
+	}
+	if sessionID != "cosmos1owner-7" || path != "assets/app.js" || index != 42 {
+		t.Fatalf("unexpected decode: %q %q %d", sessionID, path, index)
+	}
+}
+
+func TestParseFragKey_Malformed(t *testing.T) {
+	for _, key := range []string{"", "no-separator", "a\x00b", "a\x00b\x00notanumber"} {
+		if _, _, _, ok := ParseFragKey(key); ok {
+			t.Fatalf("expected malformed key to fail: %q", key)
+		}
+	}
+}
+
+func TestMakeChannelSeqKey_DistinctPerChannel(t *testing.T) {
+	if MakeChannelSeqKey("channel-1", 5) == MakeChannelSeqKey("channel-2", 5) {
+		t.Fatalf("same sequence on different channels must not collide")
+	}
+}
+
+func TestParseChannelSeqKey_RoundTrip(t *testing.T) {
+	channelID, seq, ok := ParseChannelSeqKey(MakeChannelSeqKey("channel-12", 7))
+	if !ok || channelID != "channel-12" || seq != 7 {
+		t.Fatalf("unexpected decode: %q %d %v", channelID, seq, ok)
+	}
+	for _, key := range []string{"", "/00000000000000000001", "channel-1/abc", "channel-1"} {
+		if _, _, ok := ParseChannelSeqKey(key); ok {
+			t.Fatalf("expected malformed key to fail: %q", key)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package keeper
+
+import (
+	"sort"
+
+	"cosmossdk.io/collections"
+	errorsmod "cosmossdk.io/errors"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"gwc/x/gateway/types"
+)
+
+// recordSessionFile stores the (file_size, file_root) proven by a verified DistributeItem.
+// Every fragment of a file proves the same file leaf, so repeated writes are idempotent.
+func (k Keeper) recordSessionFile(ctx sdk.Context, sessionID string, item *types.DistributeItem) error {
+	key := collections.Join(sessionID, item.Path)
+	if has, _ := k.SessionFiles.Has(ctx, key); has {
+		return nil
+	}
+	fileRoot, err := FileRootOf(item)
+	if err != nil {
+		return errorsmod.Wrap(types.ErrInvalidProof, err.Error())
+	}
+	return k.SessionFiles.Set(ctx, key, types.SessionFile{
+		SessionId: sessionID,
+		Path:      item.Path,
+		FileSize:  item.FileSize,
+		FileRoot:  fileRoot,
+	})
+}
+
+// recordEmptySessionFiles stores zero-byte files of the session. They have no fragments, so they are
+// never recorded by DistributeBatch; a path that was distributed cannot be declared empty.
+func (k Keeper) recordEmptySessionFiles(ctx sdk.Context, sessionID string, paths []string) error {
+	for _, p := range paths {
+		key := collections.Join(sessionID, p)
+		if has, _ := k.SessionFiles.Has(ctx, key); has {
+			return errorsmod.Wrapf(types.ErrInvalidManifest, "empty file %s was distributed in this session", p)
+		}
+		if err := k.SessionFiles.Set(ctx, key, types.SessionFile{SessionId: sessionID, Path: p}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BuildSessionManifest builds the ManifestPacket of a session from on-chain records:
+// files proven by DistributeBatch and the placement recorded on each success ack.
+// Every expected fragment must be acked (see ackedFragmentsByPath). Zero-byte files recorded by
+// recordEmptySessionFiles are emitted with no fragments.
+//
+// reused are the files of an incremental deploy that are unchanged since the version MDSC holds:
+// each is proven against the session RootProof and sent with reused=true and no fragments, and MDSC
+// copies the fragment locations of its stored file with the same file_root.
+func (k Keeper) BuildSessionManifest(ctx sdk.Context, sess types.Session, projectName, version string, mimeOverrides []types.MimeTypeOverride, reused []types.ReusedFile) (types.ManifestPacket, error) {
+	reusedByPath := make(map[string]types.ReusedFile, len(reused))
+	var reusedCount, reusedBytes uint64
+	for _, f := range reused {
+		if err := f.Validate(); err != nil {
+			return types.ManifestPacket{}, errorsmod.Wrap(types.ErrInvalidReusedFile, err.Error())
+		}
+		if _, dup := reusedByPath[f.Path]; dup {
+			return types.ManifestPacket{}, errorsmod.Wrapf(types.ErrInvalidReusedFile, "duplicate reused file %s", f.Path)
+		}
+		if err := VerifyReusedFile(sess.RootProofHex, f); err != nil {
+			return types.ManifestPacket{}, errorsmod.Wrapf(types.ErrInvalidReusedFile, "%s: %s", f.Path, err.Error())
+		}
+		// 再利用ファイルが今回のセッションでも配布されていれば、どちらの断片を載せるか決まらないため拒否します
+		if has, _ := k.SessionFiles.Has(ctx, collections.Join(sess.SessionId, f.Path)); has {
+			return types.ManifestPacket{}, errorsmod.Wrapf(types.ErrInvalidReusedFile, "%s was distributed in this session", f.Path)
+		}
+		count, size, err := sess.ReusedFileSize(f)
+		if err != nil {
+			return types.ManifestPacket{}, errorsmod.Wrap(types.ErrInvalidReusedFile, err.Error())
+		}
+		if count > sess.ExpectedFragmentCount-reusedCount || size > sess.ExpectedTotalBytes-reusedBytes {
+			return types.ManifestPacket{}, errorsmod.Wrapf(types.ErrInvalidReusedFile, "reused files exceed the committed totals at %s", f.Path)
+		}
+		reusedCount += count
+		reusedBytes += size
+		reusedByPath[f.Path] = f
+	}
+
+	ackedByPath, err := k.ackedFragmentsByPath(ctx, sess, reusedCount, reusedBytes)
+	if err != nil {
+		return types.ManifestPacket{}, err
+	}
+	for p := range reusedByPath {
+		if _, ok := ackedByPath[p]; ok {
+			return types.ManifestPacket{}, errorsmod.Wrapf(types.ErrInvalidReusedFile, "%s was distributed in this session", p)
+		}
+	}
+
+	emptyPaths := make(map[string]struct{})
+	err = k.SessionFiles.Walk(ctx, collections.NewPrefixedPairRange[string, string](sess.SessionId), func(key collections.Pair[string, string], f types.SessionFile) (bool, error) {
+		if _, acked := ackedByPath[key.K2()]; !acked && f.FileSize == 0 {
+			emptyPaths[key.K2()] = struct{}{}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return types.ManifestPacket{}, err
+	}
+
+	overrides := make(map[string]string, len(mimeOverrides))
+	for _, o := range mimeOverrides {
+		_, acked := ackedByPath[o.Path]
+		_, isReused := reusedByPath[o.Path]
+		_, isEmpty := emptyPaths[o.Path]
+		if !acked && !isReused && !isEmpty {
+			return types.ManifestPacket{}, errorsmod.Wrapf(types.ErrInvalidManifest, "mime override for unknown path: %s", o.Path)
+		}
+		overrides[o.Path] = o.MimeType
+	}
+
+	paths := make([]string, 0, len(ackedByPath)+len(reusedByPath)+len(emptyPaths))
+	for p := range ackedByPath {
+		paths = append(paths, p)
+	}
+	for p := range reusedByPath {
+		paths = append(paths, p)
+	}
+	for p := range emptyPaths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	files := make([]types.ManifestFileEntry, 0, len(paths))
+	for _, p := range paths {
+		mimeType, ok := overrides[p]
+		if !ok {
+			mimeType = types.DefaultMimeType(p)
+		}
+
+		if f, ok := reusedByPath[p]; ok {
+			files = append(files, types.ManifestFileEntry{
+				Path: p,
+				Metadata: types.FileMetadata{
+					MimeType: mimeType,
+					FileSize: f.FileSize,
+					FileRoot: f.FileRoot,
+					Reused:   true,
+
+					ErasureDataShards:   sess.ErasureDataShards,
+					ErasureParityShards: sess.ErasureParityShards,
+					CipherSuite:         sess.CipherSuite,
+					FragmentSize:        sess.FragmentSize,
+					Compression:         sess.Compression,
+				},
+			})
+			continue
+		}
+
+		// 空ファイルは断片も符号化もないため、MIME タイプのみを載せます
+		if _, ok := emptyPaths[p]; ok {
+			files = append(files, types.ManifestFileEntry{
+				Path:     p,
+				Metadata: types.FileMetadata{MimeType: mimeType},
+			})
+			continue
+		}
+
+		recs := ackedByPath[p]
+		file, err := k.SessionFiles.Get(ctx, collections.Join(sess.SessionId, p))
+		if err != nil {
+			return types.ManifestPacket{}, errorsmod.Wrapf(types.ErrInvalidManifest, "file record not found: %s", p)
+		}
+
+		fragments := make([]*types.PacketFragmentMapping, 0, len(recs))
+		var fileBytes uint64
+		for i, rec := range recs {
+			if rec.Index != uint64(i) {
+				return types.ManifestPacket{}, errorsmod.Wrapf(types.ErrSessionIncomplete, "missing fragment %s#%d", p, i)
+			}
+			// 先頭レプリカを fdsc_id、残りを replica_fdsc_ids に載せます
+			var chainIDs []string
+			for _, rep := range rec.ReplicaSet() {
+				chainID := rep.ChainId
+				if chainID == "" {
+					// ACK 時に解決できなかった場合は登録済みの StorageInfo を参照
+					if info, err := k.StorageInfos.Get(ctx, rep.ChannelId); err == nil {
+						chainID = info.ChainId
+					}
+				}
+				if chainID == "" {
+					return types.ManifestPacket{}, errorsmod.Wrapf(types.ErrInvalidManifest, "chain_id unknown for channel %s", rep.ChannelId)
+				}
+				chainIDs = append(chainIDs, chainID)
+			}
+			if len(chainIDs) == 0 {
+				return types.ManifestPacket{}, errorsmod.Wrapf(types.ErrInvalidManifest, "no replica recorded for %s#%d", p, rec.Index)
+			}
+			// content addressed fragments point at the content id, so identical fragments of other
+			// sessions and versions resolve to the same FDSC entry
+			fragmentID := rec.ContentId
+			if fragmentID == "" {
+				fragmentID = types.MakeFragmentID(sess.SessionId, p, rec.Index)
+			}
+			fragments = append(fragments, &types.PacketFragmentMapping{
+				FdscId:         chainIDs[0],
+				FragmentId:     fragmentID,
+				ReplicaFdscIds: chainIDs[1:],
+			})
+			fileBytes += rec.Size
+		}
+		// イレイジャーコーディングではパリティシャード分も含めた格納バイト数・シャード数と照合します
+		wantBytes := file.FileSize
+		if sess.IsErasureCoded() {
+			var wantCount uint64
+			wantCount, wantBytes = types.ErasureStoredSize(file.FileSize, sess.FragmentSize, int(sess.ErasureDataShards), int(sess.ErasureParityShards))
+			if uint64(len(recs)) != wantCount {
+				return types.ManifestPacket{}, errorsmod.Wrapf(types.ErrSessionIncomplete, "file %s: acked %d of %d shards", p, len(recs), wantCount)
+			}
+		}
+		if fileBytes != wantBytes {
+			return types.ManifestPacket{}, errorsmod.Wrapf(types.ErrSessionIncomplete, "file %s: acked %d of %d bytes", p, fileBytes, wantBytes)
+		}
+
+		files = append(files, types.ManifestFileEntry{
+			Path: p,
+			Metadata: types.FileMetadata{
+				MimeType:  mimeType,
+				FileSize:  file.FileSize,
+				Fragments: fragments,
+				FileRoot:  file.FileRoot,
+
+				ErasureDataShards:   sess.ErasureDataShards,
+				ErasureParityShards: sess.ErasureParityShards,
+				CipherSuite:         sess.CipherSuite,
+				FragmentSize:        sess.FragmentSize,
+				Compression:         sess.Compression,
+			},
+		})
+	}
+
+	return types.ManifestPacket{
+		ProjectName:  projectName,
+		Version:      version,
+		Files:        files,
+		RootProof:    sess.RootProofHex,
+		FragmentSize: sess.FragmentSize,
+		Owner:        sess.Owner,
+		SessionId:    sess.SessionId,
+		WrappedKeys:  sess.WrappedKeys,
+	}, nil
+}
@@ -0,0 +1,44 @@
+package keeper
+
+import (
+	"testing"
+
+	"cosmossdk.io/collections"
+	"github.com/stretchr/testify/require"
+
+	"gwc/x/gateway/types"
+)
+
+func TestBuildSessionManifestEmptyFiles(t *testing.T) {
+	f := initFixture(t)
+	sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+	sess.ExpectedFragmentCount = 1
+	sess.ExpectedTotalBytes = 10
+	ackFragment(t, f, sess, "index.html", 0, 10)
+	require.NoError(t, f.keeper.SessionFiles.Set(f.ctx, collections.Join(sess.SessionId, "index.html"), types.SessionFile{
+		SessionId: sess.SessionId, Path: "index.html", FileSize: 10, FileRoot: "aa",
+	}))
+
+	// a distributed path cannot be declared empty
+	require.ErrorIs(t, f.keeper.recordEmptySessionFiles(f.ctx, sess.SessionId, []string{"index.html"}), types.ErrInvalidManifest)
+
+	require.NoError(t, f.keeper.recordEmptySessionFiles(f.ctx, sess.SessionId, []string{".nojekyll", "css/empty.css"}))
+	manifest, err := f.keeper.BuildSessionManifest(f.ctx, sess, "site", "v1",
+		[]types.MimeTypeOverride{{Path: ".nojekyll", MimeType: "text/plain"}}, nil)
+	require.NoError(t, err)
+
+	require.Len(t, manifest.Files, 3)
+	byPath := make(map[string]types.FileMetadata)
+	for _, e := range manifest.Files {
+		byPath[e.Path] = e.Metadata
+	}
+	require.Len(t, byPath["index.html"].Fragments, 1)
+	require.Equal(t, uint64(10), byPath["index.html"].FileSize)
+	for _, p := range []string{".nojekyll", "css/empty.css"} {
+		require.Contains(t, byPath, p)
+		require.Empty(t, byPath[p].Fragments, p)
+		require.Zero(t, byPath[p].FileSize, p)
+	}
+	require.Equal(t, "text/plain", byPath[".nojekyll"].MimeType)
+	require.Equal(t, types.DefaultMimeType("css/empty.css"), byPath["css/empty.css"].MimeType)
+}
@@ -1,6 +1,7 @@
 package keeper
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -38,12 +39,24 @@ func mustHex32(h string) ([]byte, error) {
 // - fragment_bytes hash is hex-encoded lowercase (std hex.EncodeToString)
 func HashFragmentLeaf(path string, index uint64, fragmentBytes []byte) []byte {
 	fragDigest := sha256Bytes(fragmentBytes)
-	fragDigestHex := hex.EncodeToString(fragDigest)
+	return HashFragmentLeafDigest(path, index, hex.EncodeToString(fragDigest))
+}
 
+// HashFragmentLeafDigest computes the fragment leaf from hex(SHA256(fragment_bytes)), i.e. the content id.
+// Dedup reference items carry only the content id, and the leaf commits to nothing else of the bytes.
+func HashFragmentLeafDigest(path string, index uint64, fragDigestHex string) []byte {
 	payload := []byte(fmt.Sprintf("FRAG:%s:%d:%s", path, index, fragDigestHex))
 	return sha256Bytes(payload)
 }
 
+// fragmentLeafOf returns the fragment leaf of an item: from its bytes, or from content_id for a dedup reference.
+func fragmentLeafOf(item *types.DistributeItem) []byte {
+	if len(item.FragmentBytes) == 0 && item.ContentId != "" {
+		return HashFragmentLeafDigest(item.Path, item.Index, item.ContentId)
+	}
+	return HashFragmentLeaf(item.Path, item.Index, item.FragmentBytes)
+}
+
 // HashFileLeaf computes the CSU file leaf hash.
 //
 // Domain-separated string scheme (deterministic):
@@ -95,6 +108,7 @@ func VerifyMerkleProof(leaf []byte, proof *types.MerkleProof) ([]byte, error) {
 //
 // CSU rules (layer4):
 //  1. fragment_leaf := HashFragmentLeaf(path, index, fragment_bytes)
+//     (HashFragmentLeafDigest(path, index, content_id) for a dedup reference without bytes)
 //  2. file_root := VerifyMerkleProof(fragment_leaf, fragment_proof)
 //  3. file_leaf := HashFileLeaf(path, file_size, file_root)
 //  4. root := VerifyMerkleProof(file_leaf, file_proof)
@@ -112,7 +126,7 @@ func VerifyFragment(rootProofHex string, item *types.DistributeItem) error {
 		return fmt.Errorf("invalid root_proof_hex: %w", err)
 	}
 
-	fragLeaf := HashFragmentLeaf(item.Path, item.Index, item.FragmentBytes)
+	fragLeaf := fragmentLeafOf(item)
 	fileRoot, err := VerifyMerkleProof(fragLeaf, item.FragmentProof)
 	if err != nil {
 		return fmt.Errorf("fragment_proof verification failed: %w", err)
@@ -134,3 +148,44 @@ func VerifyFragment(rootProofHex string, item *types.DistributeItem) error {
 	}
 	return nil
 }
+
+// FileRootOf returns the file_root (hex) that item.fragment_proof leads to.
+// Only meaningful after VerifyFragment succeeded for the same item.
+func FileRootOf(item *types.DistributeItem) (string, error) {
+	fileRoot, err := VerifyMerkleProof(fragmentLeafOf(item), item.FragmentProof)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(fileRoot), nil
+}
+
+// VerifyReusedFile verifies that a file reused from the previous version is part of the session RootProof.
+//
+//  1. file_leaf := HashFileLeaf(path, file_size, file_root)
+//  2. root := VerifyMerkleProof(file_leaf, file_proof)
+//  3. root must equal root_proof_hex (session RootProof)
+//
+// The fragments are not re-verified: MDSC only copies the locations of a stored file with the same file_root.
+func VerifyReusedFile(rootProofHex string, f types.ReusedFile) error {
+	if f.Path == "" {
+		return fmt.Errorf("reused file path is empty")
+	}
+	rootProof, err := mustHex32(rootProofHex)
+	if err != nil {
+		return fmt.Errorf("invalid root_proof_hex: %w", err)
+	}
+	fileRoot, err := mustHex32(f.FileRoot)
+	if err != nil {
+		return fmt.Errorf("invalid file_root: %w", err)
+	}
+
+	fileLeaf := HashFileLeaf(f.Path, f.FileSize, fileRoot)
+	root, err := VerifyMerkleProof(fileLeaf, f.FileProof)
+	if err != nil {
+		return fmt.Errorf("file_proof verification failed: %w", err)
+	}
+	if !bytes.Equal(root, rootProof) {
+		return fmt.Errorf("root_proof mismatch")
+	}
+	return nil
+}
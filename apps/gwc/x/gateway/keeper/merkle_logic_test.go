@@ -145,3 +145,81 @@ func TestVerifyFragment_RootProofMismatch_Fails(t *testing.T) {
 		t.Fatalf("expected error, got nil")
 	}
 }
+
+func TestVerifyFragment_ContentIDReference(t *testing.T) {
+	path := "index.html"
+
+	frag0 := []byte("hello-----0")
+	frag1 := []byte("hello-----1")
+
+	leaf1 := HashFragmentLeaf(path, 1, frag1)
+	fp0 := &types.MerkleProof{
+		Steps: []*types.MerkleStep{
+			{
+				SiblingHex:    hex.EncodeToString(leaf1),
+				SiblingIsLeft: false,
+			},
+		},
+	}
+	fileRoot, err := VerifyMerkleProof(HashFragmentLeaf(path, 0, frag0), fp0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fileSize := uint64(len(frag0) + len(frag1))
+	rootProofHex := hex.EncodeToString(HashFileLeaf(path, fileSize, fileRoot))
+
+	// a reference item carries only the content id of the bytes
+	ref := &types.DistributeItem{
+		Path:          path,
+		Index:         0,
+		ContentId:     types.MakeContentID(frag0),
+		FragmentProof: fp0,
+		FileSize:      fileSize,
+		FileProof:     &types.MerkleProof{Steps: nil},
+	}
+	if err := VerifyFragment(rootProofHex, ref); err != nil {
+		t.Fatalf("expected ok, got error: %v", err)
+	}
+
+	ref.ContentId = types.MakeContentID(frag1)
+	if err := VerifyFragment(rootProofHex, ref); err == nil {
+		t.Fatalf("expected error for the content id of other bytes, got nil")
+	}
+}
+
+func TestVerifyReusedFile(t *testing.T) {
+	rootA := sha256Bytes([]byte("file-a"))
+	rootB := sha256Bytes([]byte("file-b"))
+	leafA := HashFileLeaf("a.css", 10, rootA)
+	leafB := HashFileLeaf("b.js", 20, rootB)
+	rootProofHex := hex.EncodeToString(sha256Bytes([]byte(hex.EncodeToString(leafA) + hex.EncodeToString(leafB))))
+
+	reused := types.ReusedFile{
+		Path:     "b.js",
+		FileSize: 20,
+		FileRoot: hex.EncodeToString(rootB),
+		FileProof: &types.MerkleProof{
+			Steps: []*types.MerkleStep{
+				{
+					SiblingHex:    hex.EncodeToString(leafA),
+					SiblingIsLeft: true,
+				},
+			},
+		},
+	}
+	if err := VerifyReusedFile(rootProofHex, reused); err != nil {
+		t.Fatalf("expected ok, got error: %v", err)
+	}
+
+	// the file leaf commits to the size and the file root
+	wrongSize := reused
+	wrongSize.FileSize = 21
+	if err := VerifyReusedFile(rootProofHex, wrongSize); err == nil {
+		t.Fatalf("expected error for a different file_size, got nil")
+	}
+	wrongRoot := reused
+	wrongRoot.FileRoot = hex.EncodeToString(rootA)
+	if err := VerifyReusedFile(rootProofHex, wrongRoot); err == nil {
+		t.Fatalf("expected error for a different file_root, got nil")
+	}
+}
@@ -0,0 +1,180 @@
+package keeper
+
+import (
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"gwc/x/gateway/types"
+)
+
+// Migrator handles in-place store migrations of the gateway module.
+type Migrator struct {
+	keeper Keeper
+}
+
+// NewMigrator returns a new Migrator.
+func NewMigrator(keeper Keeper) Migrator {
+	return Migrator{keeper: keeper}
+}
+
+// Migrate1to2 はバージョン 1 で保存されたセッションに対し、後から追加された補助インデックスを構築します。
+// 期限キュー・削除キュー・所有者／状態インデックス・所有者ごとの未クローズ数は SetSession でしか
+// 書かれないため、既存セッションを SetSession し直して全て作り直します。
+// あわせて、バージョン 1 に存在しなかったパラメータに既定値を設定し、バージョン 2 では完了できない
+// 配布中のセッションを閉じます。
+func (m Migrator) Migrate1to2(ctx sdk.Context) error {
+	if err := m.keeper.migrateParamsDefaults(ctx); err != nil {
+		return err
+	}
+	if err := m.keeper.rebuildSessionIndexes(ctx); err != nil {
+		return err
+	}
+	return m.keeper.closeLegacyInFlightSessions(ctx)
+}
+
+// migrateParamsDefaults fills the params added after version 1 with their defaults.
+// They are stored as zero values, several of which fail Params.Validate
+// (e.g. max_session_duration_seconds 0 < default_deadline_seconds).
+func (k Keeper) migrateParamsDefaults(ctx sdk.Context) error {
+	params, err := k.Params.Get(ctx)
+	if err != nil {
+		return err
+	}
+	def := types.DefaultParams()
+
+	if params.MaxFragmentRetries == 0 {
+		params.MaxFragmentRetries = def.MaxFragmentRetries
+	}
+	if params.MaxExpiredSessionsPerBlock == 0 {
+		params.MaxExpiredSessionsPerBlock = def.MaxExpiredSessionsPerBlock
+	}
+	if params.SessionRetentionSeconds == 0 {
+		params.SessionRetentionSeconds = def.SessionRetentionSeconds
+	}
+	if params.MaxOpenSessionsPerOwner == 0 {
+		params.MaxOpenSessionsPerOwner = def.MaxOpenSessionsPerOwner
+	}
+	if params.OwnerQuotaWindowSeconds == 0 {
+		params.OwnerQuotaWindowSeconds = def.OwnerQuotaWindowSeconds
+	}
+	if params.MaxBytesPerOwnerWindow == 0 {
+		params.MaxBytesPerOwnerWindow = def.MaxBytesPerOwnerWindow
+	}
+	if params.MaxFragmentsPerOwnerWindow == 0 {
+		params.MaxFragmentsPerOwnerWindow = def.MaxFragmentsPerOwnerWindow
+	}
+	if params.DepositPricePerByte.Amount.IsNil() {
+		params.DepositPricePerByte = def.DepositPricePerByte
+	}
+	if params.MaxSessionDurationSeconds == 0 {
+		params.MaxSessionDurationSeconds = def.MaxSessionDurationSeconds
+	}
+	if params.MaxSessionDurationSeconds < params.DefaultDeadlineSeconds {
+		params.MaxSessionDurationSeconds = params.DefaultDeadlineSeconds
+	}
+	if params.ChannelFailureThreshold == 0 {
+		params.ChannelFailureThreshold = def.ChannelFailureThreshold
+	}
+	if params.ChannelHealthWindowSeconds == 0 {
+		params.ChannelHealthWindowSeconds = def.ChannelHealthWindowSeconds
+	}
+	if params.ChannelDegradedCooldownSeconds == 0 {
+		params.ChannelDegradedCooldownSeconds = def.ChannelDegradedCooldownSeconds
+	}
+	if params.MaxReplicationFactor == 0 {
+		params.MaxReplicationFactor = def.MaxReplicationFactor
+	}
+
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	return k.Params.Set(ctx, params)
+}
+
+// closeLegacyInFlightSessions closes the sessions that were distributing when the chain upgraded.
+// Version 1 bound fragment packets by sequence only (MakeSeqKey) and recorded no source channel,
+// so their acks can no longer be correlated; nor did it commit expected_fragment_count or write
+// FragmentDeliveries, so finalize could never succeed for them. The sessions are closed as failed
+// (deposit refunded, grants revoked) and the legacy sequence bindings are dropped.
+// INIT sessions have not committed a root proof yet and continue under the version 2 rules.
+func (k Keeper) closeLegacyInFlightSessions(ctx sdk.Context) error {
+	var inFlight []types.Session
+	err := k.Sessions.Walk(ctx, nil, func(_ string, s types.Session) (bool, error) {
+		if s.State == types.SessionState_SESSION_STATE_ROOT_COMMITTED || s.State == types.SessionState_SESSION_STATE_DISTRIBUTING {
+			inFlight = append(inFlight, s)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, s := range inFlight {
+		if _, err := k.closeSessionFailed(ctx, s, "MIGRATION: distributed before upgrade", "unbound by upgrade"); err != nil {
+			return err
+		}
+	}
+
+	// バージョン 2 のキーは MakeChannelSeqKey 形式（"channel/seq"）です
+	var legacyKeys []string
+	err = k.FragmentSeqToFragmentKey.Walk(ctx, nil, func(seqKey, _ string) (bool, error) {
+		if !strings.Contains(seqKey, "/") {
+			legacyKeys = append(legacyKeys, seqKey)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, seqKey := range legacyKeys {
+		if err := k.FragmentSeqToFragmentKey.Remove(ctx, seqKey); err != nil {
+			return err
+		}
+	}
+
+	ctx.Logger().Info("Closed legacy in-flight sessions", "sessions", len(inFlight), "fragment_seq_keys", len(legacyKeys))
+	return nil
+}
+
+// rebuildSessionIndexes clears every session index and re-stores all sessions through SetSession.
+func (k Keeper) rebuildSessionIndexes(ctx sdk.Context) error {
+	var sessions []types.Session
+	err := k.Sessions.Walk(ctx, nil, func(_ string, s types.Session) (bool, error) {
+		sessions = append(sessions, s)
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := k.SessionExpiryQueue.Clear(ctx, nil); err != nil {
+		return err
+	}
+	if err := k.SessionPruneQueue.Clear(ctx, nil); err != nil {
+		return err
+	}
+	if err := k.SessionsByOwner.Clear(ctx, nil); err != nil {
+		return err
+	}
+	if err := k.SessionsByState.Clear(ctx, nil); err != nil {
+		return err
+	}
+	if err := k.OwnerOpenSessions.Clear(ctx, nil); err != nil {
+		return err
+	}
+	if err := k.DepositSettleQueue.Clear(ctx, nil); err != nil {
+		return err
+	}
+	// SetSession は前回の値との差分でインデックスを更新するため、本体も一度消してから書き直します
+	if err := k.Sessions.Clear(ctx, nil); err != nil {
+		return err
+	}
+
+	for _, s := range sessions {
+		if err := k.SetSession(ctx, s); err != nil {
+			return err
+		}
+	}
+	ctx.Logger().Info("Rebuilt session indexes", "sessions", len(sessions))
+	return nil
+}
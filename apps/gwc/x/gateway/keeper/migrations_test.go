@@ -0,0 +1,130 @@
+package keeper
+
+import (
+	"testing"
+
+	"cosmossdk.io/collections"
+	"github.com/stretchr/testify/require"
+
+	"gwc/x/gateway/types"
+)
+
+func TestMigrate1to2RebuildsSessionIndexes(t *testing.T) {
+	f := initFixture(t)
+	now := f.ctx.BlockTime().Unix()
+
+	// version 1 stored sessions without any of the indexes
+	open := types.Session{SessionId: f.owner + "-a", Owner: f.owner, State: types.SessionState_SESSION_STATE_INIT, DeadlineUnix: now + 60}
+	closed := types.Session{SessionId: f.owner + "-b", Owner: f.owner, State: types.SessionState_SESSION_STATE_CLOSED_SUCCESS}
+	archived := types.Session{SessionId: f.owner + "-c", Owner: f.owner, State: types.SessionState_SESSION_STATE_CLOSED_FAILED, ClosedUnix: now - 10, Archived: true}
+	for _, s := range []types.Session{open, closed, archived} {
+		require.NoError(t, f.keeper.Sessions.Set(f.ctx, s.SessionId, s))
+	}
+	// a stale index entry is dropped
+	require.NoError(t, f.keeper.SessionExpiryQueue.Set(f.ctx, collections.Join(int64(1), "gone")))
+	require.NoError(t, f.keeper.OwnerOpenSessions.Set(f.ctx, f.owner, 7))
+
+	require.NoError(t, NewMigrator(f.keeper).Migrate1to2(f.ctx))
+
+	has := func(ok bool, err error) bool {
+		require.NoError(t, err)
+		return ok
+	}
+	require.True(t, has(f.keeper.SessionExpiryQueue.Has(f.ctx, collections.Join(open.DeadlineUnix, open.SessionId))))
+	require.False(t, has(f.keeper.SessionExpiryQueue.Has(f.ctx, collections.Join(int64(1), "gone"))))
+	require.True(t, has(f.keeper.SessionPruneQueue.Has(f.ctx, collections.Join(now, closed.SessionId))))
+	require.False(t, has(f.keeper.SessionPruneQueue.Has(f.ctx, collections.Join(archived.ClosedUnix, archived.SessionId))))
+	for _, s := range []types.Session{open, closed, archived} {
+		require.True(t, has(f.keeper.SessionsByOwner.Has(f.ctx, collections.Join(f.owner, s.SessionId))))
+		require.True(t, has(f.keeper.SessionsByState.Has(f.ctx, collections.Join(int32(s.State), s.SessionId))))
+	}
+
+	n, err := f.keeper.ownerOpenSessions(f.ctx, f.owner)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), n)
+
+	got, err := f.keeper.MustGetSession(f.ctx, closed.SessionId)
+	require.NoError(t, err)
+	require.Equal(t, now, got.ClosedUnix)
+}
+
+func TestMigrate1to2FillsParamsDefaults(t *testing.T) {
+	f := initFixture(t)
+
+	// version 1 params: only the original fields are set
+	v1 := types.Params{
+		MaxFragmentBytes:       1 << 20,
+		MaxFragmentsPerSession: 100,
+		DefaultDeadlineSeconds: 7200,
+		LocalAdmin:             f.executor,
+	}
+	require.NoError(t, f.keeper.Params.Set(f.ctx, v1))
+	require.Error(t, v1.Validate())
+
+	require.NoError(t, NewMigrator(f.keeper).Migrate1to2(f.ctx))
+
+	got, err := f.keeper.Params.Get(f.ctx)
+	require.NoError(t, err)
+	require.NoError(t, got.Validate())
+
+	def := types.DefaultParams()
+	require.Equal(t, v1.MaxFragmentBytes, got.MaxFragmentBytes)
+	require.Equal(t, v1.MaxFragmentsPerSession, got.MaxFragmentsPerSession)
+	require.Equal(t, v1.DefaultDeadlineSeconds, got.DefaultDeadlineSeconds)
+	require.Equal(t, v1.LocalAdmin, got.LocalAdmin)
+	require.Equal(t, def.MaxFragmentRetries, got.MaxFragmentRetries)
+	require.Equal(t, def.MaxExpiredSessionsPerBlock, got.MaxExpiredSessionsPerBlock)
+	require.Equal(t, def.MaxOpenSessionsPerOwner, got.MaxOpenSessionsPerOwner)
+	require.Equal(t, def.OwnerQuotaWindowSeconds, got.OwnerQuotaWindowSeconds)
+	require.Equal(t, def.DepositPricePerByte.String(), got.DepositPricePerByte.String())
+	require.Equal(t, def.MaxSessionDurationSeconds, got.MaxSessionDurationSeconds)
+	require.Equal(t, def.ChannelFailureThreshold, got.ChannelFailureThreshold)
+	require.Equal(t, def.MaxReplicationFactor, got.MaxReplicationFactor)
+	require.False(t, got.AllowGenericAuthorization)
+}
+
+func TestMigrate1to2ClosesLegacyInFlightSessions(t *testing.T) {
+	f := initFixture(t)
+	now := f.ctx.BlockTime().Unix()
+
+	distributing := types.Session{SessionId: f.owner + "-a", Owner: f.owner, Executor: f.executor, State: types.SessionState_SESSION_STATE_DISTRIBUTING, DeadlineUnix: now + 60}
+	committed := types.Session{SessionId: f.owner + "-b", Owner: f.owner, Executor: f.executor, State: types.SessionState_SESSION_STATE_ROOT_COMMITTED, DeadlineUnix: now + 60}
+	initial := types.Session{SessionId: f.owner + "-c", Owner: f.owner, Executor: f.executor, State: types.SessionState_SESSION_STATE_INIT, DeadlineUnix: now + 60}
+	for _, s := range []types.Session{distributing, committed, initial} {
+		require.NoError(t, f.keeper.Sessions.Set(f.ctx, s.SessionId, s))
+	}
+	f.grantSessionBound(distributing, types.MsgTypeURLDistributeBatch, 0, 0)
+
+	// version 1 bound fragment packets by sequence only
+	legacyKey := MakeSeqKey(5)
+	require.NoError(t, f.keeper.FragmentSeqToFragmentKey.Set(f.ctx, legacyKey, MakeFragKey(distributing.SessionId, "a.txt", 0)))
+	require.NoError(t, f.keeper.BindFragmentSeq(f.ctx, "channel-1", 6, initial.SessionId, "b.txt", 0))
+
+	require.NoError(t, NewMigrator(f.keeper).Migrate1to2(f.ctx))
+
+	for _, s := range []types.Session{distributing, committed} {
+		got, err := f.keeper.MustGetSession(f.ctx, s.SessionId)
+		require.NoError(t, err)
+		require.Equal(t, types.SessionState_SESSION_STATE_CLOSED_FAILED, got.State)
+		has, err := f.keeper.SessionExpiryQueue.Has(f.ctx, collections.Join(s.DeadlineUnix, s.SessionId))
+		require.NoError(t, err)
+		require.False(t, has)
+	}
+	_, ok := f.authz.get(f.executor, f.owner, types.MsgTypeURLDistributeBatch)
+	require.False(t, ok)
+
+	// INIT sessions continue under the new rules
+	got, err := f.keeper.MustGetSession(f.ctx, initial.SessionId)
+	require.NoError(t, err)
+	require.Equal(t, types.SessionState_SESSION_STATE_INIT, got.State)
+	n, err := f.keeper.ownerOpenSessions(f.ctx, f.owner)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), n)
+
+	// legacy sequence keys are dropped, channel-scoped ones are kept
+	has, err := f.keeper.FragmentSeqToFragmentKey.Has(f.ctx, legacyKey)
+	require.NoError(t, err)
+	require.False(t, has)
+	_, err = f.keeper.GetFragmentKeyBySeq(f.ctx, "channel-1", 6)
+	require.NoError(t, err)
+}
@@ -25,7 +25,7 @@ func (k msgServer) AbortAndCloseSession(goCtx context.Context, msg *types.MsgAbo
 		return nil, errorsmod.Wrapf(types.ErrExecutorMismatch, "executor mismatch: session.executor=%s msg.executor=%s", sess.Executor, msg.Executor)
 	}
 
-	if err := k.Keeper.RequireSessionBoundAuthz(ctx, sess, msg.Executor, msg.SessionId, types.MsgTypeURLAbortAndCloseSession); err != nil {
+	if err := k.Keeper.RequireSessionBoundAuthz(ctx, sess, msg.Executor, msg); err != nil {
 		return nil, err
 	}
 
@@ -33,17 +33,15 @@ func (k msgServer) AbortAndCloseSession(goCtx context.Context, msg *types.MsgAbo
 		return nil, errorsmod.Wrap(types.ErrSessionClosed, "session is already closed")
 	}
 
-	sess.State = types.SessionState_SESSION_STATE_CLOSED_FAILED
-	sess.CloseReason = msg.Reason
-
-	if err := k.Keeper.SetSession(ctx, sess); err != nil {
+	// キャンセル・期限切れと同じ経路で閉じ、応答待ちの断片・マニフェストのバインドを解除します。
+	// マニフェスト送信済み (FINALIZING) でも、後から届く ACK は FINALIZING 以外のセッションを無視するため上書きされません。
+	pending, err := k.Keeper.closeSessionFailed(ctx, sess, msg.Reason, "session aborted")
+	if err != nil {
 		return nil, err
 	}
 
-	k.Keeper.RevokeCSUGrants(ctx, sess.Owner)
-
 	// [LOG: CSU Phase 7]
-	fmt.Printf("🔴 [KEEPER] CSU Phase 7: Session Aborted & Revoked | Owner: %s\n", sess.Owner)
+	fmt.Printf("🔴 [KEEPER] CSU Phase 7: Session Aborted & Revoked | Owner: %s | Pending: %d\n", sess.Owner, pending)
 
 	ctx.EventManager().EmitEvent(
 		sdk.NewEvent(
@@ -0,0 +1,47 @@
+package keeper
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"gwc/x/gateway/types"
+)
+
+func TestAbortFinalizingSessionUnbindsPackets(t *testing.T) {
+	f := initFixture(t)
+	msgTypeURL := sdk.MsgTypeURL(&types.MsgAbortAndCloseSession{})
+
+	sess := f.newSession(t, types.SessionState_SESSION_STATE_FINALIZING)
+	sess.ManifestSequence = 7
+	require.NoError(t, f.keeper.SetSession(f.ctx, sess))
+	require.NoError(t, f.keeper.BindManifestSeq(f.ctx, 7, sess.SessionId))
+	require.NoError(t, f.keeper.SetFragmentPending(f.ctx, sess.SessionId, "a.txt", 0, 0, "channel-1", 3, 10, "cid"))
+	require.NoError(t, f.keeper.BindFragmentSeq(f.ctx, "channel-1", 3, sess.SessionId, "a.txt", 0))
+	f.grantSessionBound(sess, msgTypeURL, 0, 0)
+
+	_, err := f.msgServer.AbortAndCloseSession(f.ctx, &types.MsgAbortAndCloseSession{Executor: f.executor, SessionId: sess.SessionId, Reason: "broken upload"})
+	require.NoError(t, err)
+
+	got, err := f.keeper.MustGetSession(f.ctx, sess.SessionId)
+	require.NoError(t, err)
+	require.Equal(t, types.SessionState_SESSION_STATE_CLOSED_FAILED, got.State)
+	require.Equal(t, "broken upload", got.CloseReason)
+
+	// a late manifest ack / fragment ack no longer resolves to the session
+	_, err = f.keeper.GetSessionIDByManifestSeq(f.ctx, 7)
+	require.Error(t, err)
+	_, err = f.keeper.GetFragmentKeyBySeq(f.ctx, "channel-1", 3)
+	require.Error(t, err)
+	rec, err := f.keeper.GetFragmentDelivery(f.ctx, sess.SessionId, "a.txt", 0)
+	require.NoError(t, err)
+	require.Equal(t, types.FragmentStatus_FRAGMENT_STATUS_ERROR, rec.ReplicaSet()[0].Status)
+
+	// the grants are revoked
+	_, ok := f.authz.get(f.executor, sess.Owner, msgTypeURL)
+	require.False(t, ok)
+
+	_, err = f.msgServer.AbortAndCloseSession(f.ctx, &types.MsgAbortAndCloseSession{Executor: f.executor, SessionId: sess.SessionId})
+	require.Error(t, err)
+}
@@ -41,15 +41,42 @@ func (k msgServer) CommitRootProof(goCtx context.Context, msg *types.MsgCommitRo
 		return nil, errorsmod.Wrap(types.ErrInvalidRootProof, "root_proof_hex is not valid hex")
 	}
 
+	params := k.Keeper.getParamsOrDefault(ctx)
+	if msg.ExpectedFragmentCount == 0 {
+		return nil, errorsmod.Wrap(types.ErrInvalidRootProof, "expected_fragment_count must be > 0")
+	}
+	if params.MaxFragmentsPerSession > 0 && msg.ExpectedFragmentCount > params.MaxFragmentsPerSession {
+		return nil, errorsmod.Wrap(types.ErrLimitExceeded, "expected_fragment_count exceeds max_fragments_per_session")
+	}
+	// 各断片は 1 バイト以上 fragment_size 以下なので、総バイト数は断片数以上、断片数 * fragment_size 以下でなければならない
+	// （乗算はオーバーフローしうるため、総バイト数を収めるのに要る断片数 ceil(総バイト数 / fragment_size) と比較します）
+	if msg.ExpectedTotalBytes < msg.ExpectedFragmentCount || (msg.ExpectedTotalBytes-1)/sess.FragmentSize+1 > msg.ExpectedFragmentCount {
+		return nil, errorsmod.Wrapf(types.ErrInvalidRootProof, "expected_total_bytes %d is inconsistent with %d fragments of <= %d bytes", msg.ExpectedTotalBytes, msg.ExpectedFragmentCount, sess.FragmentSize)
+	}
+
+	// デポジットは宣言バイト数で算出しているため、それを超える確定は拒否します
+	// （イレイジャーコーディングの expected_total_bytes はパリティシャードを含むため、デポジットと同じくパリティ込みで比較します）
+	if limit := depositBytes(sess); sess.DeclaredTotalBytes > 0 && msg.ExpectedTotalBytes > limit {
+		return nil, errorsmod.Wrapf(types.ErrDepositInsufficient, "expected_total_bytes %d exceeds the %d bytes covered by declared_total_bytes %d", msg.ExpectedTotalBytes, limit, sess.DeclaredTotalBytes)
+	}
+
 	sess.RootProofHex = msg.RootProofHex
+	sess.ExpectedFragmentCount = msg.ExpectedFragmentCount
+	sess.ExpectedTotalBytes = msg.ExpectedTotalBytes
 	sess.State = types.SessionState_SESSION_STATE_ROOT_COMMITTED
 
+	// 所有者ごとのローリングウィンドウ上限（宣言したバイト数・断片数で課金）
+	if err := k.Keeper.chargeOwnerUsage(ctx, params, sess); err != nil {
+		fmt.Printf("❌ [KEEPER] Owner quota rejected CommitRootProof: %v\n", err)
+		return nil, err
+	}
+
 	if err := k.Keeper.SetSession(ctx, sess); err != nil {
 		return nil, err
 	}
 
 	// [LOG: CSU Phase 2]
-	fmt.Printf("🟢 [KEEPER] CSU Phase 2: RootProof Committed | Proof: %s\n", msg.RootProofHex)
+	fmt.Printf("🟢 [KEEPER] CSU Phase 2: RootProof Committed | Proof: %s | Fragments: %d | Bytes: %d\n", msg.RootProofHex, msg.ExpectedFragmentCount, msg.ExpectedTotalBytes)
 
 	ctx.EventManager().EmitEvent(
 		sdk.NewEvent(
@@ -57,6 +84,8 @@ func (k msgServer) CommitRootProof(goCtx context.Context, msg *types.MsgCommitRo
 			sdk.NewAttribute("session_id", msg.SessionId),
 			sdk.NewAttribute("owner", msg.Owner),
 			sdk.NewAttribute("root_proof", msg.RootProofHex),
+			sdk.NewAttribute("expected_fragment_count", fmt.Sprintf("%d", msg.ExpectedFragmentCount)),
+			sdk.NewAttribute("expected_total_bytes", fmt.Sprintf("%d", msg.ExpectedTotalBytes)),
 		),
 	)
 
@@ -31,7 +31,7 @@ func (k msgServer) DistributeBatch(goCtx context.Context, msg *types.MsgDistribu
 		return nil, errorsmod.Wrapf(types.ErrExecutorMismatch, "executor mismatch")
 	}
 
-	if err := k.Keeper.RequireSessionBoundAuthz(ctx, sess, msg.Executor, msg.SessionId, types.MsgTypeURLDistributeBatch); err != nil {
+	if err := k.Keeper.RequireSessionBoundAuthz(ctx, sess, msg.Executor, msg); err != nil {
 		fmt.Printf("❌ [KEEPER] Authz Failed\n")
 		return nil, err
 	}
@@ -40,6 +40,10 @@ func (k msgServer) DistributeBatch(goCtx context.Context, msg *types.MsgDistribu
 		return nil, errorsmod.Wrap(types.ErrSessionClosed, "session is closed")
 	}
 
+	if sess.RootProofHex == "" {
+		return nil, errorsmod.Wrap(types.ErrRootProofNotCommitted, "root proof not committed")
+	}
+
 	if params.MaxFragmentsPerSession > 0 {
 		after := sess.DistributedCount + uint64(len(msg.Items))
 		if after > params.MaxFragmentsPerSession {
@@ -47,38 +51,73 @@ func (k msgServer) DistributeBatch(goCtx context.Context, msg *types.MsgDistribu
 		}
 	}
 
-	var fdscChannels []string
-	iter, _ := k.Keeper.DatastoreChannels.Iterate(ctx, nil)
-	defer iter.Close()
-	for ; iter.Valid(); iter.Next() {
-		ch, _ := iter.Key()
-		fdscChannels = append(fdscChannels, ch)
-	}
-	if len(fdscChannels) == 0 {
-		return nil, errorsmod.Wrap(types.ErrNoDatastoreChannels, "no FDSC channels")
+	// CommitRootProof で宣言された断片数・総バイト数を超える配布は拒否
+	if sess.DistributedCount+uint64(len(msg.Items)) > sess.ExpectedFragmentCount {
+		return nil, errorsmod.Wrap(types.ErrLimitExceeded, "expected_fragment_count exceeded")
 	}
 
-	// 決定論的な順序にするためチャンネル名をソート
-	sort.Strings(fdscChannels)
+	fdscChannels, err := k.Keeper.sessionFdscChannels(ctx, sess)
+	if err != nil {
+		return nil, err
+	}
 
-	// 指定された数に制限
-	if sess.NumFdscChains > 0 && uint32(len(fdscChannels)) > sess.NumFdscChains {
-		fdscChannels = fdscChannels[:sess.NumFdscChains]
+	if n := sess.ReplicaCount(); n > len(fdscChannels) {
+		return nil, errorsmod.Wrapf(types.ErrInvalidReplicationFactor,
+			"replication_factor %d exceeds the %d FDSC channels of the session", n, len(fdscChannels))
+	}
+	shardsPerStripe := int(sess.ErasureDataShards + sess.ErasureParityShards)
+	if sess.IsErasureCoded() && shardsPerStripe > len(fdscChannels) {
+		return nil, errorsmod.Wrapf(types.ErrInvalidErasureCoding,
+			"%d shards per stripe need as many FDSC channels, the session has %d", shardsPerStripe, len(fdscChannels))
 	}
 
 	fdscSet := make(map[string]struct{})
 	for _, ch := range fdscChannels {
 		fdscSet[ch] = struct{}{}
 	}
+	// 既定の振り分けは degraded なチャネルを飛ばし、セッションの配置戦略で選びます（明示指定は許可されたチャネルならそのまま使用）
+	routable, err := k.Keeper.routableFdscChannels(ctx, fdscChannels)
+	if err != nil {
+		return nil, err
+	}
+	candidates := routable
+	if sess.IsErasureCoded() && len(routable) < shardsPerStripe {
+		// 健全なチャネルだけではストライプを分散しきれない場合は degraded なチャネルも使います
+		candidates = fdscChannels
+	}
+	placer, err := k.Keeper.newFragmentPlacer(ctx, sess, candidates)
+	if err != nil {
+		return nil, err
+	}
 
-	roundRobin := 0
+	var dedupCount int
 	for i := range msg.Items {
 		item := &msg.Items[i]
 		fragKey := MakeFragKey(msg.SessionId, item.Path, item.Index)
 
-		if params.MaxFragmentBytes > 0 && uint64(len(item.FragmentBytes)) > params.MaxFragmentBytes {
+		// バイト列を省略した項目は dedup 参照: 既に content_id を保持している FDSC へ参照だけを送ります
+		size := uint64(len(item.FragmentBytes))
+		var holders []string
+		if len(item.FragmentBytes) == 0 {
+			if sess.IsErasureCoded() {
+				return nil, errorsmod.Wrap(types.ErrInvalidFragmentReference, "erasure-coded sessions distribute every shard with its bytes")
+			}
+			holders, size, err = k.Keeper.contentHolders(ctx, item.ContentId, fdscChannels, routable)
+			if err != nil {
+				return nil, err
+			}
+			if len(holders) == 0 {
+				return nil, errorsmod.Wrapf(types.ErrInvalidFragmentReference, "no FDSC channel of the session holds content %s", item.ContentId)
+			}
+		}
+
+		if params.MaxFragmentBytes > 0 && size > params.MaxFragmentBytes {
 			return nil, errorsmod.Wrap(types.ErrLimitExceeded, "fragment too large")
 		}
+		// CommitRootProof で宣言された総バイト数を超える配布は拒否（参照は索引上のサイズで数えます）
+		if sess.DistributedBytes+size > sess.ExpectedTotalBytes {
+			return nil, errorsmod.Wrap(types.ErrLimitExceeded, "expected_total_bytes exceeded")
+		}
 
 		already, _ := k.Keeper.SessionFragmentSeen.Has(ctx, fragKey)
 		if already {
@@ -89,20 +128,26 @@ func (k msgServer) DistributeBatch(goCtx context.Context, msg *types.MsgDistribu
 			fmt.Printf("❌ [KEEPER] Merkle Verify Failed | Path: %s | Index: %d | Err: %v\n", item.Path, item.Index, err)
 			return nil, errorsmod.Wrap(types.ErrInvalidProof, err.Error())
 		}
-
-		packetData := types.GatewayPacketData{
-			Packet: &types.GatewayPacketData_FragmentPacket{
-				FragmentPacket: &types.FragmentPacket{
-					SessionId: msg.SessionId,
-					RootProof: sess.RootProofHex,
-					Path:      item.Path,
-					Index:     item.Index,
-					Data:      item.FragmentBytes,
-				},
-			},
+		if err := k.Keeper.recordSessionFile(ctx, msg.SessionId, item); err != nil {
+			return nil, err
 		}
 
-		timeoutTimestamp := uint64(ctx.BlockTime().UnixNano()) + uint64(fragmentTimeoutSeconds*1_000_000_000)
+		if holders != nil {
+			targets, err := referenceTargets(item.TargetFdscChannel, holders, sess.ReplicaCount())
+			if err != nil {
+				return nil, err
+			}
+			for r, ch := range targets {
+				if err := k.Keeper.sendFragmentPacket(ctx, sess, item, r, ch, size); err != nil {
+					return nil, err
+				}
+			}
+			_ = k.Keeper.SessionFragmentSeen.Set(ctx, fragKey)
+			sess.DistributedCount++
+			sess.DistributedBytes += size
+			dedupCount++
+			continue
+		}
 
 		targetChannel := ""
 		if item.TargetFdscChannel != "" {
@@ -112,18 +157,25 @@ func (k msgServer) DistributeBatch(goCtx context.Context, msg *types.MsgDistribu
 			}
 			targetChannel = item.TargetFdscChannel
 		} else {
-			targetChannel = fdscChannels[roundRobin%len(fdscChannels)]
-			roundRobin++
+			targetChannel, err = placer.Place(ctx, item)
+			if err != nil {
+				return nil, err
+			}
 		}
 
-		seq, err := k.Keeper.TransmitGatewayPacketData(ctx, packetData, "gateway", targetChannel, clienttypes.ZeroHeight(), timeoutTimestamp)
+		// 先頭レプリカの後ろに、残りのレプリカを別々のチャネルへ配置します
+		targets, err := replicaChannels(targetChannel, routable, fdscChannels, sess.ReplicaCount())
 		if err != nil {
 			return nil, err
 		}
-
-		_ = k.Keeper.BindFragmentSeq(ctx, seq, msg.SessionId, item.Path, item.Index)
+		for r, ch := range targets {
+			if err := k.Keeper.sendFragmentPacket(ctx, sess, item, r, ch, size); err != nil {
+				return nil, err
+			}
+		}
 		_ = k.Keeper.SessionFragmentSeen.Set(ctx, fragKey)
 		sess.DistributedCount++
+		sess.DistributedBytes += size
 	}
 
 	if sess.State == types.SessionState_SESSION_STATE_ROOT_COMMITTED {
@@ -133,7 +185,69 @@ func (k msgServer) DistributeBatch(goCtx context.Context, msg *types.MsgDistribu
 	_ = k.Keeper.SetSession(ctx, sess)
 
 	// [LOG: CSU Phase 5]
-	fmt.Printf("🟢 [KEEPER] CSU Phase 5: Batch Distributed | Count: %d | State: %s\n", len(msg.Items), sess.State.String())
+	fmt.Printf("🟢 [KEEPER] CSU Phase 5: Batch Distributed | Count: %d | Dedup: %d | State: %s\n", len(msg.Items), dedupCount, sess.State.String())
 
 	return &types.MsgDistributeBatchResponse{}, nil
 }
+
+// sessionFdscChannels returns the FDSC channels a session may distribute to:
+// all registered datastore channels in sorted order, limited to sess.NumFdscChains.
+func (k Keeper) sessionFdscChannels(ctx sdk.Context, sess types.Session) ([]string, error) {
+	var fdscChannels []string
+	iter, err := k.DatastoreChannels.Iterate(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		ch, _ := iter.Key()
+		fdscChannels = append(fdscChannels, ch)
+	}
+	if len(fdscChannels) == 0 {
+		return nil, errorsmod.Wrap(types.ErrNoDatastoreChannels, "no FDSC channels")
+	}
+
+	// 決定論的な順序にするためチャンネル名をソート
+	sort.Strings(fdscChannels)
+
+	// 指定された数に制限
+	if sess.NumFdscChains > 0 && uint32(len(fdscChannels)) > sess.NumFdscChains {
+		fdscChannels = fdscChannels[:sess.NumFdscChains]
+	}
+	return fdscChannels, nil
+}
+
+// sendFragmentPacket transmits replica number `replica` of a verified fragment to channelID and records the
+// (channel, seq) binding and the pending delivery record. size is the fragment length (the indexed size for
+// a dedup reference, which is sent without data).
+func (k Keeper) sendFragmentPacket(ctx sdk.Context, sess types.Session, item *types.DistributeItem, replica int, channelID string, size uint64) error {
+	contentID := item.ContentId
+	if len(item.FragmentBytes) > 0 {
+		contentID = types.MakeContentID(item.FragmentBytes)
+	}
+	packetData := types.GatewayPacketData{
+		Packet: &types.GatewayPacketData_FragmentPacket{
+			FragmentPacket: &types.FragmentPacket{
+				SessionId: sess.SessionId,
+				RootProof: sess.RootProofHex,
+				Path:      item.Path,
+				Index:     item.Index,
+				Data:      item.FragmentBytes,
+				ContentId: contentID,
+			},
+		},
+	}
+
+	timeoutTimestamp := uint64(ctx.BlockTime().UnixNano()) + uint64(fragmentTimeoutSeconds*1_000_000_000)
+
+	seq, err := k.TransmitGatewayPacketData(ctx, packetData, "gateway", channelID, clienttypes.ZeroHeight(), timeoutTimestamp)
+	if err != nil {
+		return err
+	}
+
+	_ = k.BindFragmentSeq(ctx, channelID, seq, sess.SessionId, item.Path, item.Index)
+	if err := k.markChannelSent(ctx, channelID); err != nil {
+		return err
+	}
+	return k.SetFragmentPending(ctx, sess.SessionId, item.Path, item.Index, replica, channelID, seq, size, contentID)
+}
@@ -28,7 +28,7 @@ func (k msgServer) FinalizeAndCloseSession(goCtx context.Context, msg *types.Msg
 		return nil, errorsmod.Wrapf(types.ErrExecutorMismatch, "executor mismatch: session.executor=%s msg.executor=%s", sess.Executor, msg.Executor)
 	}
 
-	if err := k.Keeper.RequireSessionBoundAuthz(ctx, sess, msg.Executor, msg.SessionId, types.MsgTypeURLFinalizeAndCloseSession); err != nil {
+	if err := k.Keeper.RequireSessionBoundAuthz(ctx, sess, msg.Executor, msg); err != nil {
 		return nil, err
 	}
 
@@ -40,18 +40,19 @@ func (k msgServer) FinalizeAndCloseSession(goCtx context.Context, msg *types.Msg
 		return nil, errorsmod.Wrap(types.ErrRootProofNotCommitted, "root proof not committed or invalid state")
 	}
 
-	manifest := msg.Manifest
-	if manifest.SessionId != msg.SessionId {
-		return nil, errorsmod.Wrapf(types.ErrInvalidManifest, "manifest.session_id mismatch")
-	}
-	if manifest.Owner != sess.Owner {
-		return nil, errorsmod.Wrapf(types.ErrInvalidManifest, "manifest.owner mismatch")
+	// 空ファイルは断片を持たず DistributeBatch で記録されないため、ここで記録します
+	if err := k.Keeper.recordEmptySessionFiles(ctx, sess.SessionId, msg.EmptyFiles); err != nil {
+		return nil, err
 	}
-	if manifest.RootProof != sess.RootProofHex {
-		return nil, errorsmod.Wrapf(types.ErrInvalidManifest, "manifest.root_proof mismatch")
+
+	// 宣言された全断片の成功ACKを確認し、ACK時に記録した配置からマニフェストを組み立てる
+	// (前バージョンから再利用するファイルは RootProof に対する file_proof のみ検証し、配置は MDSC 側でコピー)
+	manifest, err := k.Keeper.BuildSessionManifest(ctx, sess, msg.ProjectName, msg.Version, msg.MimeOverrides, msg.ReusedFiles)
+	if err != nil {
+		return nil, err
 	}
-	if manifest.FragmentSize != sess.FragmentSize {
-		return nil, errorsmod.Wrapf(types.ErrInvalidManifest, "manifest.fragment_size mismatch")
+	if len(msg.ReusedFiles) > 0 {
+		fmt.Printf("♻️ [KEEPER] CSU Phase 6: Incremental Deploy | Reused Files: %d of %d\n", len(msg.ReusedFiles), len(manifest.Files))
 	}
 
 	mdscChannel, err := k.Keeper.MetastoreChannel.Get(ctx)
@@ -70,18 +71,19 @@ func (k msgServer) FinalizeAndCloseSession(goCtx context.Context, msg *types.Msg
 		return nil, err
 	}
 
-	fmt.Printf("🟢 [KEEPER] CSU Phase 6: Manifest Packet Sent | Seq: %d | Channel: %s\n", seq, mdscChannel)
+	fmt.Printf("🟢 [KEEPER] CSU Phase 6: Manifest Packet Sent | Seq: %d | Channel: %s | Files: %d\n", seq, mdscChannel, len(manifest.Files))
 
 	if err := k.Keeper.BindManifestSeq(ctx, seq, msg.SessionId); err != nil {
 		return nil, err
 	}
 
 	sess.State = types.SessionState_SESSION_STATE_FINALIZING
+	sess.ManifestSequence = seq
 	if err := k.Keeper.SetSession(ctx, sess); err != nil {
 		return nil, err
 	}
 
-	k.Keeper.RevokeCSUGrants(ctx, sess.Owner)
+	k.Keeper.RevokeCSUGrants(ctx, sess)
 
 	// [LOG: CSU Phase 6]
 	fmt.Printf("🟢 [KEEPER] CSU Phase 6: Authz/Feegrant Revoked & Session Closed (Pending ACK) | Owner: %s\n", sess.Owner)
@@ -2,8 +2,6 @@ package keeper
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"time"
 
@@ -12,6 +10,7 @@ import (
 	"cosmossdk.io/collections"
 	errorsmod "cosmossdk.io/errors"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
 )
 
 func (k msgServer) InitSession(goCtx context.Context, msg *types.MsgInitSession) (*types.MsgInitSessionResponse, error) {
@@ -33,14 +32,6 @@ func (k msgServer) InitSession(goCtx context.Context, msg *types.MsgInitSession)
 		params = types.DefaultParams()
 	}
 
-	// Enforce local-admin configured (Issue8)
-	if params.LocalAdmin == "" {
-		fmt.Printf("❌ [KEEPER] LocalAdmin not configured\n")
-		return nil, errorsmod.Wrap(types.ErrLocalAdminNotConfigured, "params.local_admin must be set for CSU")
-	}
-
-	executor := params.LocalAdmin
-
 	if params.MaxFragmentBytes > 0 && msg.FragmentSize > params.MaxFragmentBytes {
 		return nil, errorsmod.Wrapf(
 			types.ErrLimitExceeded,
@@ -50,20 +41,67 @@ func (k msgServer) InitSession(goCtx context.Context, msg *types.MsgInitSession)
 		)
 	}
 
-	sessionID := fmt.Sprintf("%s-%d", msg.Owner, ctx.BlockTime().UnixNano())
+	if params.MaxReplicationFactor > 0 && msg.ReplicationFactor > params.MaxReplicationFactor {
+		return nil, errorsmod.Wrapf(
+			types.ErrInvalidReplicationFactor,
+			"replication_factor exceeds max_replication_factor: replication_factor=%d max=%d",
+			msg.ReplicationFactor,
+			params.MaxReplicationFactor,
+		)
+	}
+
+	// 所有者ごとの同時オープン数・呼び出し間隔の制限
+	if err := k.Keeper.checkInitSessionQuota(ctx, params, msg.Owner); err != nil {
+		fmt.Printf("❌ [KEEPER] Owner quota rejected InitSession: %v\n", err)
+		return nil, err
+	}
+
+	sessionID, err := k.Keeper.NextSessionID(ctx, msg.Owner)
+	if err != nil {
+		return nil, err
+	}
+	// 採番済みIDの再利用（既存セッションやトークンハッシュの上書き）を拒否します
+	if exists, err := k.Keeper.HasSession(ctx, sessionID); err != nil {
+		return nil, err
+	} else if exists {
+		return nil, errorsmod.Wrapf(types.ErrSessionAlreadyExists, "session_id already in use: %s", sessionID)
+	}
+	if exists, err := k.Keeper.SessionUploadTokenHash.Has(ctx, sessionID); err != nil {
+		return nil, err
+	} else if exists {
+		return nil, errorsmod.Wrapf(types.ErrSessionAlreadyExists, "upload token already bound to session_id: %s", sessionID)
+	}
+
+	// executor の割り当て（指定があれば検証、無ければレジストリから決定的に選択）
+	executor, err := k.Keeper.assignExecutor(ctx, params, sessionID, msg.PreferredExecutor)
+	if err != nil {
+		fmt.Printf("❌ [KEEPER] Executor assignment failed: %v\n", err)
+		return nil, err
+	}
 
+	// 期限: 未指定ならデフォルト、指定時は未来かつ max_session_duration_seconds 以内であること
+	nowUnix := ctx.BlockTime().Unix()
 	var deadlineUnix int64
 	if msg.DeadlineUnix == 0 {
 		deadlineUnix = ctx.BlockTime().Add(time.Duration(params.DefaultDeadlineSeconds) * time.Second).Unix()
 	} else {
+		if msg.DeadlineUnix <= nowUnix {
+			return nil, errorsmod.Wrapf(types.ErrInvalidDeadline, "deadline_unix is not in the future: deadline=%d now=%d", msg.DeadlineUnix, nowUnix)
+		}
+		if maxDeadline := nowUnix + maxSessionDuration(params); msg.DeadlineUnix > maxDeadline {
+			return nil, errorsmod.Wrapf(types.ErrInvalidDeadline,
+				"deadline_unix exceeds max_session_duration_seconds: deadline=%d max=%d", msg.DeadlineUnix, maxDeadline)
+		}
 		deadlineUnix = msg.DeadlineUnix
 	}
 
-	sum := sha256.Sum256([]byte("upload_token:" + sessionID))
-	tokenPlain := hex.EncodeToString(sum[:])
-
-	tokenHash := sha256.Sum256([]byte(tokenPlain))
-	if err := k.Keeper.SetUploadTokenHash(ctx, sessionID, tokenHash[:]); err != nil {
+	// upload token: クライアントがローカルで生成したトークンの sha256 だけを保存します。
+	// (ブロック・Tx の値はすべて公開されているため、チェーン上でトークンを導出・返却はしません)
+	tokenHash, err := mustHex32(msg.UploadTokenHashHex)
+	if err != nil {
+		return nil, errorsmod.Wrapf(sdkerrors.ErrInvalidRequest, "invalid upload_token_hash_hex: %v", err)
+	}
+	if err := k.Keeper.SetUploadTokenHash(ctx, sessionID, tokenHash); err != nil {
 		return nil, err
 	}
 
@@ -80,7 +118,27 @@ func (k msgServer) InitSession(goCtx context.Context, msg *types.MsgInitSession)
 		AckSuccessCount:  0,
 		AckErrorCount:    0,
 		NumFdscChains:    msg.NumFdscChains, // 追加
+
+		DeclaredTotalBytes: msg.DeclaredTotalBytes,
+		CreatedUnix:        nowUnix,
+		PlacementStrategy:  msg.PlacementStrategy,
+		ReplicationFactor:  msg.ReplicationFactor,
+
+		ErasureDataShards:   msg.ErasureDataShards,
+		ErasureParityShards: msg.ErasureParityShards,
+
+		CipherSuite: msg.CipherSuite,
+		WrappedKeys: msg.WrappedKeys,
+
+		Compression: msg.Compression,
 	}
+
+	// 宣言バイト数 × レプリカ数 × 単価のデポジットをエスクローへロック
+	if err := k.Keeper.lockSessionDeposit(ctx, params, &sess); err != nil {
+		fmt.Printf("❌ [KEEPER] Deposit lock failed: %v\n", err)
+		return nil, err
+	}
+
 	if err := k.Keeper.SetSession(ctx, sess); err != nil {
 		return nil, err
 	}
@@ -97,12 +155,17 @@ func (k msgServer) InitSession(goCtx context.Context, msg *types.MsgInitSession)
 			sdk.NewAttribute("fragment_size", fmt.Sprintf("%d", msg.FragmentSize)),
 			sdk.NewAttribute("deadline_unix", fmt.Sprintf("%d", deadlineUnix)),
 			sdk.NewAttribute("num_fdsc_chains", fmt.Sprintf("%d", msg.NumFdscChains)),
+			sdk.NewAttribute("placement_strategy", msg.PlacementStrategy.String()),
+			sdk.NewAttribute("replication_factor", fmt.Sprintf("%d", sess.ReplicaCount())),
+			sdk.NewAttribute("erasure_coding", fmt.Sprintf("%d+%d", msg.ErasureDataShards, msg.ErasureParityShards)),
+			sdk.NewAttribute("cipher_suite", msg.CipherSuite),
+			sdk.NewAttribute("compression", msg.Compression),
+			sdk.NewAttribute("deposit", sess.Deposit.String()),
 		),
 	)
 
 	return &types.MsgInitSessionResponse{
 		SessionId:            sessionID,
-		SessionUploadToken:   tokenPlain,
 		ResolvedDeadlineUnix: deadlineUnix,
 	}, nil
 }
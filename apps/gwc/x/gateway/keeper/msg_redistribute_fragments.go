@@ -0,0 +1,213 @@
+package keeper
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"gwc/x/gateway/types"
+
+	errorsmod "cosmossdk.io/errors"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// RedistributeFragments re-sends fragments whose previous delivery ended in an error ack or a timeout.
+// Only fragments already distributed via DistributeBatch can be re-sent. Every failed replica of an item
+// is re-sent to a channel that holds no other replica of the fragment, and each replica is limited
+// to params.MaxFragmentRetries re-sends.
+func (k msgServer) RedistributeFragments(goCtx context.Context, msg *types.MsgRedistributeFragments) (*types.MsgRedistributeFragmentsResponse, error) {
+	ctx := sdk.UnwrapSDKContext(goCtx)
+
+	fmt.Printf("🔵 [KEEPER] CSU Phase 5b: RedistributeFragments Started | SessionID: %s | Items: %d\n", msg.SessionId, len(msg.Items))
+
+	params := k.Keeper.getParamsOrDefault(ctx)
+	if params.MaxFragmentRetries == 0 {
+		return nil, errorsmod.Wrap(types.ErrFragmentRetriesExceeded, "fragment redistribution is disabled (max_fragment_retries = 0)")
+	}
+
+	sess, err := k.Keeper.MustGetSession(ctx, msg.SessionId)
+	if err != nil {
+		return nil, errorsmod.Wrap(types.ErrSessionNotFound, err.Error())
+	}
+
+	if sess.Executor != msg.Executor {
+		return nil, errorsmod.Wrapf(types.ErrExecutorMismatch, "executor mismatch")
+	}
+
+	if err := k.Keeper.RequireSessionBoundAuthz(ctx, sess, msg.Executor, msg); err != nil {
+		fmt.Printf("❌ [KEEPER] Authz Failed\n")
+		return nil, err
+	}
+
+	if sess.State == types.SessionState_SESSION_STATE_CLOSED_SUCCESS || sess.State == types.SessionState_SESSION_STATE_CLOSED_FAILED {
+		return nil, errorsmod.Wrap(types.ErrSessionClosed, "session is closed")
+	}
+	if sess.State != types.SessionState_SESSION_STATE_DISTRIBUTING {
+		return nil, errorsmod.Wrapf(types.ErrSessionInvalidState, "invalid state for redistribute_fragments: %s", sess.State.String())
+	}
+
+	fdscChannels, err := k.Keeper.sessionFdscChannels(ctx, sess)
+	if err != nil {
+		return nil, err
+	}
+	routable, err := k.Keeper.routableFdscChannels(ctx, fdscChannels)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{}, len(msg.Items))
+	for i := range msg.Items {
+		item := &msg.Items[i]
+		fragKey := MakeFragKey(msg.SessionId, item.Path, item.Index)
+
+		if _, dup := seen[fragKey]; dup {
+			return nil, errorsmod.Wrap(types.ErrDuplicateFragment, "duplicate fragment in request")
+		}
+		seen[fragKey] = struct{}{}
+
+		if params.MaxFragmentBytes > 0 && uint64(len(item.FragmentBytes)) > params.MaxFragmentBytes {
+			return nil, errorsmod.Wrap(types.ErrLimitExceeded, "fragment too large")
+		}
+
+		rec, err := k.Keeper.GetFragmentDelivery(ctx, msg.SessionId, item.Path, item.Index)
+		if err != nil {
+			return nil, errorsmod.Wrapf(types.ErrFragmentNotRedistributable, "fragment was never distributed: %s", fragKey)
+		}
+		if !isFailedFragmentStatus(rec.Status) {
+			return nil, errorsmod.Wrapf(types.ErrFragmentNotRedistributable, "fragment %s is %s", fragKey, rec.Status.String())
+		}
+		// attempts には初回送信分が含まれる
+		replicas := rec.ReplicaSet()
+		for _, rep := range replicas {
+			if isFailedFragmentStatus(rep.Status) && rep.Attempts > params.MaxFragmentRetries {
+				return nil, errorsmod.Wrapf(types.ErrFragmentRetriesExceeded, "fragment %s already sent %d times to %s", fragKey, rep.Attempts, rep.ChannelId)
+			}
+		}
+
+		if err := VerifyFragment(sess.RootProofHex, item); err != nil {
+			fmt.Printf("❌ [KEEPER] Merkle Verify Failed | Path: %s | Index: %d | Err: %v\n", item.Path, item.Index, err)
+			return nil, errorsmod.Wrap(types.ErrInvalidProof, err.Error())
+		}
+
+		// イレイジャーコーディングでは同じストライプの他のシャードがあるチャネルも避けます
+		var stripePeers []string
+		if sess.IsErasureCoded() {
+			stripePeers, err = k.Keeper.erasureStripeChannels(ctx, sess, item)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// 失敗したレプリカだけを、他のレプリカと重ならないチャネルへ再送します（明示指定は最初の失敗レプリカに適用）
+		explicit := item.TargetFdscChannel
+		for r, rep := range replicas {
+			if !isFailedFragmentStatus(rep.Status) {
+				continue
+			}
+			others := make([]string, 0, len(replicas)-1)
+			for j, other := range replicas {
+				if j != r {
+					others = append(others, other.ChannelId)
+				}
+			}
+
+			targetChannel := ""
+			if explicit != "" {
+				if !containsString(fdscChannels, explicit) {
+					return nil, errorsmod.Wrap(types.ErrUnknownDatastoreChannel, "target channel is not allowed for this session")
+				}
+				if containsString(others, explicit) {
+					return nil, errorsmod.Wrapf(types.ErrInvalidReplicationFactor, "target channel %s already holds a replica of %s", explicit, fragKey)
+				}
+				targetChannel = explicit
+				explicit = ""
+			} else {
+				targetChannel = nextFreeChannelAfter(fdscChannels, routable, append(others, stripePeers...), rep.ChannelId)
+			}
+
+			if err := k.Keeper.sendFragmentPacket(ctx, sess, item, r, targetChannel, uint64(len(item.FragmentBytes))); err != nil {
+				return nil, err
+			}
+			replicas[r].ChannelId = targetChannel
+
+			ctx.EventManager().EmitEvent(
+				sdk.NewEvent(
+					"csu_fragment_redistributed",
+					sdk.NewAttribute("session_id", msg.SessionId),
+					sdk.NewAttribute("path", item.Path),
+					sdk.NewAttribute("index", strconv.FormatUint(item.Index, 10)),
+					sdk.NewAttribute("replica", strconv.Itoa(r)),
+					sdk.NewAttribute("previous_channel", rep.ChannelId),
+					sdk.NewAttribute("channel", targetChannel),
+					sdk.NewAttribute("attempt", strconv.FormatUint(uint64(rep.Attempts+1), 10)),
+				),
+			)
+		}
+	}
+
+	fmt.Printf("🟢 [KEEPER] CSU Phase 5b: Fragments Redistributed | Count: %d\n", len(msg.Items))
+
+	return &types.MsgRedistributeFragmentsResponse{}, nil
+}
+
+func isFailedFragmentStatus(s types.FragmentStatus) bool {
+	return s == types.FragmentStatus_FRAGMENT_STATUS_ERROR || s == types.FragmentStatus_FRAGMENT_STATUS_TIMED_OUT
+}
+
+// nextFreeChannelAfter picks the first channel following prev that holds no other replica of the fragment,
+// preferring routable channels. prev itself is only picked again when every other channel is taken.
+func nextFreeChannelAfter(channels, routable, occupied []string, prev string) string {
+	start := 0
+	for i, ch := range channels {
+		if ch == prev {
+			start = i + 1
+			break
+		}
+	}
+	for _, pool := range [][]string{routable, channels} {
+		for n := 0; n < len(channels); n++ {
+			if ch := channels[(start+n)%len(channels)]; containsString(pool, ch) && !containsString(occupied, ch) {
+				return ch
+			}
+		}
+	}
+	return nextRoutableChannelAfter(channels, routable, prev)
+}
+
+// nextChannelAfter picks the channel following prev in the (sorted) allowed list.
+// If prev is no longer allowed, the first channel is used.
+func nextChannelAfter(channels []string, prev string) string {
+	for i, ch := range channels {
+		if ch == prev {
+			return channels[(i+1)%len(channels)]
+		}
+	}
+	return channels[0]
+}
+
+// nextRoutableChannelAfter picks the first routable channel following prev in the (sorted) allowed list.
+// prev itself is only picked again when it is the only routable channel.
+func nextRoutableChannelAfter(channels, routable []string, prev string) string {
+	start := 0
+	for i, ch := range channels {
+		if ch == prev {
+			start = i + 1
+			break
+		}
+	}
+	for n := 0; n < len(channels); n++ {
+		if ch := channels[(start+n)%len(channels)]; containsString(routable, ch) {
+			return ch
+		}
+	}
+	return nextChannelAfter(channels, prev)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
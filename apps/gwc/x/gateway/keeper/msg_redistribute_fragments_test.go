@@ -0,0 +1,107 @@
+package keeper
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"gwc/x/gateway/types"
+)
+
+// failFragment records `attempts` sends of replica 0 of (path, index) on channel-1, the last one failed with status.
+func failFragment(t *testing.T, f *fixture, sess types.Session, path string, index uint64, attempts int, status types.FragmentStatus) {
+	t.Helper()
+	for i := 0; i < attempts; i++ {
+		seq := uint64(i + 1)
+		require.NoError(t, f.keeper.SetFragmentPending(f.ctx, sess.SessionId, path, index, 0, "channel-1", seq, 10, "cid"))
+		if status != types.FragmentStatus_FRAGMENT_STATUS_PENDING {
+			require.NoError(t, f.keeper.UpdateFragmentStatus(f.ctx, sess.SessionId, path, index, "channel-1", seq, status, "boom"))
+		}
+	}
+}
+
+func TestRedistributeFragmentsRetryLimit(t *testing.T) {
+	f := initFixture(t)
+	for _, ch := range []string{"channel-1", "channel-2"} {
+		require.NoError(t, f.keeper.DatastoreChannels.Set(f.ctx, ch))
+	}
+	sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+	msgTypeURL := sdk.MsgTypeURL(&types.MsgRedistributeFragments{})
+	f.grantSessionBound(sess, msgTypeURL, 0, 0)
+
+	// attempts include the first send: max_fragment_retries = 3 allows 4 sends in total
+	failFragment(t, f, sess, "a.txt", 0, 3, types.FragmentStatus_FRAGMENT_STATUS_ERROR)
+	failFragment(t, f, sess, "b.txt", 0, 4, types.FragmentStatus_FRAGMENT_STATUS_TIMED_OUT)
+	failFragment(t, f, sess, "c.txt", 0, 1, types.FragmentStatus_FRAGMENT_STATUS_PENDING)
+
+	redistribute := func(paths ...string) error {
+		msg := &types.MsgRedistributeFragments{Executor: f.executor, SessionId: sess.SessionId}
+		for _, p := range paths {
+			msg.Items = append(msg.Items, types.DistributeItem{Path: p, Index: 0, FragmentBytes: []byte("0123456789")})
+		}
+		_, err := f.msgServer.RedistributeFragments(f.ctx, msg)
+		return err
+	}
+
+	// within the limit the request passes the retry check and fails only on the (dummy) proof
+	require.ErrorIs(t, redistribute("a.txt"), types.ErrInvalidProof)
+	require.ErrorIs(t, redistribute("b.txt"), types.ErrFragmentRetriesExceeded)
+
+	// only failed fragments that were distributed before can be re-sent
+	require.ErrorIs(t, redistribute("c.txt"), types.ErrFragmentNotRedistributable)
+	require.ErrorIs(t, redistribute("never.txt"), types.ErrFragmentNotRedistributable)
+
+	// a lower limit applies to the recorded attempts
+	f.setParams(t, func(p *types.Params) { p.MaxFragmentRetries = 2 })
+	require.ErrorIs(t, redistribute("a.txt"), types.ErrFragmentRetriesExceeded)
+
+	// 0 disables redistribution
+	f.setParams(t, func(p *types.Params) { p.MaxFragmentRetries = 0 })
+	require.ErrorIs(t, redistribute("a.txt"), types.ErrFragmentRetriesExceeded)
+}
+
+func TestRedistributeFragmentsRequiresDistributingSession(t *testing.T) {
+	f := initFixture(t)
+	require.NoError(t, f.keeper.DatastoreChannels.Set(f.ctx, "channel-1"))
+	msgTypeURL := sdk.MsgTypeURL(&types.MsgRedistributeFragments{})
+
+	for state, want := range map[types.SessionState]error{
+		types.SessionState_SESSION_STATE_ROOT_COMMITTED: types.ErrSessionInvalidState,
+		types.SessionState_SESSION_STATE_FINALIZING:     types.ErrSessionInvalidState,
+		types.SessionState_SESSION_STATE_CLOSED_FAILED:  types.ErrSessionClosed,
+	} {
+		sess := f.newSession(t, state)
+		f.grantSessionBound(sess, msgTypeURL, 0, 0)
+		_, err := f.msgServer.RedistributeFragments(f.ctx, &types.MsgRedistributeFragments{
+			Executor:  f.executor,
+			SessionId: sess.SessionId,
+			Items:     []types.DistributeItem{{Path: "a.txt", FragmentBytes: []byte("x")}},
+		})
+		require.ErrorIs(t, err, want, state.String())
+	}
+}
+
+func TestNextFreeChannelAfter(t *testing.T) {
+	channels := []string{"channel-1", "channel-2", "channel-3"}
+	cases := []struct {
+		name     string
+		routable []string
+		occupied []string
+		prev     string
+		want     string
+	}{
+		{"next after prev", channels, nil, "channel-1", "channel-2"},
+		{"wraps around", channels, nil, "channel-3", "channel-1"},
+		{"skips occupied", channels, []string{"channel-2"}, "channel-1", "channel-3"},
+		{"skips degraded", []string{"channel-1", "channel-3"}, nil, "channel-1", "channel-3"},
+		{"falls back to degraded channels", nil, []string{"channel-2"}, "channel-1", "channel-3"},
+		{"prev only when everything else is taken", channels, []string{"channel-2", "channel-3"}, "channel-1", "channel-1"},
+		{"unknown prev starts at the first channel", channels, nil, "channel-9", "channel-1"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, nextFreeChannelAfter(channels, tc.routable, tc.occupied, tc.prev))
+		})
+	}
+}
@@ -47,6 +47,9 @@ func (k msgServer) RegisterStorage(goCtx context.Context, msg *types.MsgRegister
 		if info.ChannelId == "" {
 			return nil, errorsmod.Wrap(sdkerrors.ErrInvalidRequest, "channel_id is required")
 		}
+		if info.CapacityWeight > types.MaxCapacityWeight {
+			return nil, errorsmod.Wrapf(sdkerrors.ErrInvalidRequest, "capacity_weight exceeds %d: %d", types.MaxCapacityWeight, info.CapacityWeight)
+		}
 
 		// 既存の情報を取得（IBCで登録済みの ChainId 等を保持するため）
 		existing, err := k.Keeper.StorageInfos.Get(ctx, info.ChannelId)
@@ -61,6 +64,9 @@ func (k msgServer) RegisterStorage(goCtx context.Context, msg *types.MsgRegister
 			if info.ConnectionType != "" {
 				existing.ConnectionType = info.ConnectionType
 			}
+			if info.CapacityWeight != 0 {
+				existing.CapacityWeight = info.CapacityWeight
+			}
 			info = &existing
 		}
 
@@ -0,0 +1,120 @@
+package keeper
+
+import (
+	"context"
+	"fmt"
+
+	errorsmod "cosmossdk.io/errors"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"gwc/x/gateway/types"
+)
+
+// CancelSession は所有者が進行中のセッションを放棄するために使用します。
+// 応答待ちの断片は ERROR になり、セッションは CLOSED_FAILED で閉じられ、デポジットは返金されます。
+// マニフェスト送信済み (FINALIZING) のセッションは MDSC の ACK を待つ必要があるため取り消せません。
+func (k msgServer) CancelSession(goCtx context.Context, msg *types.MsgCancelSession) (*types.MsgCancelSessionResponse, error) {
+	ctx := sdk.UnwrapSDKContext(goCtx)
+
+	fmt.Printf("🔵 [KEEPER] Cancel Requested | SessionID: %s | Owner: %s | Reason: %s\n", msg.SessionId, msg.Owner, msg.Reason)
+
+	sess, err := k.Keeper.MustGetSession(ctx, msg.SessionId)
+	if err != nil {
+		return nil, errorsmod.Wrap(types.ErrSessionNotFound, err.Error())
+	}
+	if sess.Owner != msg.Owner {
+		return nil, errorsmod.Wrapf(types.ErrOwnerMismatch, "owner mismatch: session.owner=%s msg.owner=%s", sess.Owner, msg.Owner)
+	}
+	if isSessionClosed(sess) {
+		return nil, errorsmod.Wrap(types.ErrSessionClosed, "session is already closed")
+	}
+	if sess.State == types.SessionState_SESSION_STATE_FINALIZING {
+		return nil, errorsmod.Wrap(types.ErrSessionInvalidState, "manifest already sent; wait for the MDSC ack")
+	}
+
+	closeReason := "CANCELLED"
+	if msg.Reason != "" {
+		closeReason = "CANCELLED: " + msg.Reason
+	}
+	pending, err := k.Keeper.closeSessionFailed(ctx, sess, closeReason, "session cancelled")
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("🔴 [KEEPER] Session Cancelled & Revoked | SessionID: %s | Pending: %d\n", sess.SessionId, pending)
+
+	ctx.EventManager().EmitEvent(
+		sdk.NewEvent(
+			"csu_session_cancelled",
+			sdk.NewAttribute("session_id", sess.SessionId),
+			sdk.NewAttribute("owner", sess.Owner),
+			sdk.NewAttribute("reason", msg.Reason),
+			sdk.NewAttribute("pending_fragments", fmt.Sprintf("%d", pending)),
+		),
+	)
+
+	return &types.MsgCancelSessionResponse{}, nil
+}
+
+// ExtendSessionDeadline は所有者が進行中のセッションの期限を延長するために使用します。
+// 新しい期限は現在の期限より後で、かつ created_unix + max_session_duration_seconds 以下である必要があります。
+// NOTE: executor への authz / feegrant に有効期限を付けている場合、所有者はそれらも付け直す必要があります。
+func (k msgServer) ExtendSessionDeadline(goCtx context.Context, msg *types.MsgExtendSessionDeadline) (*types.MsgExtendSessionDeadlineResponse, error) {
+	ctx := sdk.UnwrapSDKContext(goCtx)
+
+	sess, err := k.Keeper.MustGetSession(ctx, msg.SessionId)
+	if err != nil {
+		return nil, errorsmod.Wrap(types.ErrSessionNotFound, err.Error())
+	}
+	if sess.Owner != msg.Owner {
+		return nil, errorsmod.Wrapf(types.ErrOwnerMismatch, "owner mismatch: session.owner=%s msg.owner=%s", sess.Owner, msg.Owner)
+	}
+	if isSessionClosed(sess) {
+		return nil, errorsmod.Wrap(types.ErrSessionClosed, "session is already closed")
+	}
+	if msg.NewDeadlineUnix <= sess.DeadlineUnix {
+		return nil, errorsmod.Wrapf(types.ErrInvalidDeadline,
+			"new_deadline_unix must be after the current deadline: new=%d current=%d", msg.NewDeadlineUnix, sess.DeadlineUnix)
+	}
+
+	params := k.Keeper.getParamsOrDefault(ctx)
+	createdUnix := sess.CreatedUnix
+	if createdUnix == 0 {
+		// created_unix 導入前のセッションは延長時点を起点とします
+		createdUnix = ctx.BlockTime().Unix()
+	}
+	if maxDeadline := createdUnix + maxSessionDuration(params); msg.NewDeadlineUnix > maxDeadline {
+		return nil, errorsmod.Wrapf(types.ErrInvalidDeadline,
+			"new_deadline_unix exceeds max_session_duration_seconds: new=%d max=%d", msg.NewDeadlineUnix, maxDeadline)
+	}
+
+	prevDeadline := sess.DeadlineUnix
+	sess.DeadlineUnix = msg.NewDeadlineUnix
+	// SetSession が期限キューのキーを付け替えます
+	if err := k.Keeper.SetSession(ctx, sess); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("🟢 [KEEPER] Session Deadline Extended | SessionID: %s | %d -> %d\n", sess.SessionId, prevDeadline, sess.DeadlineUnix)
+
+	ctx.EventManager().EmitEvent(
+		sdk.NewEvent(
+			"csu_session_deadline_extended",
+			sdk.NewAttribute("session_id", sess.SessionId),
+			sdk.NewAttribute("owner", sess.Owner),
+			sdk.NewAttribute("previous_deadline_unix", fmt.Sprintf("%d", prevDeadline)),
+			sdk.NewAttribute("deadline_unix", fmt.Sprintf("%d", sess.DeadlineUnix)),
+		),
+	)
+
+	return &types.MsgExtendSessionDeadlineResponse{DeadlineUnix: sess.DeadlineUnix}, nil
+}
+
+// maxSessionDuration returns params.max_session_duration_seconds, falling back to the default
+// for params stored before the field existed.
+func maxSessionDuration(params types.Params) int64 {
+	if params.MaxSessionDurationSeconds <= 0 {
+		return types.DefaultMaxSessionDurationSecs
+	}
+	return params.MaxSessionDurationSeconds
+}
@@ -0,0 +1,120 @@
+package keeper
+
+import (
+	"strings"
+	"testing"
+
+	"cosmossdk.io/collections"
+	"github.com/stretchr/testify/require"
+
+	"gwc/x/gateway/types"
+)
+
+func TestCancelSession(t *testing.T) {
+	f := initFixture(t)
+
+	cancel := func(sess types.Session, owner string) error {
+		_, err := f.msgServer.CancelSession(f.ctx, &types.MsgCancelSession{Owner: owner, SessionId: sess.SessionId, Reason: "test"})
+		return err
+	}
+
+	open := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+	require.ErrorIs(t, cancel(open, f.executor), types.ErrOwnerMismatch)
+
+	_, err := f.msgServer.CancelSession(f.ctx, &types.MsgCancelSession{Owner: f.owner, SessionId: "missing"})
+	require.ErrorIs(t, err, types.ErrSessionNotFound)
+
+	// the manifest has been sent: the session has to wait for the MDSC ack
+	require.ErrorIs(t, cancel(f.newSession(t, types.SessionState_SESSION_STATE_FINALIZING), f.owner), types.ErrSessionInvalidState)
+
+	for _, state := range []types.SessionState{types.SessionState_SESSION_STATE_CLOSED_SUCCESS, types.SessionState_SESSION_STATE_CLOSED_FAILED} {
+		require.ErrorIs(t, cancel(f.newSession(t, state), f.owner), types.ErrSessionClosed, state.String())
+	}
+
+	require.NoError(t, cancel(open, f.owner))
+	got, err := f.keeper.MustGetSession(f.ctx, open.SessionId)
+	require.NoError(t, err)
+	require.Equal(t, types.SessionState_SESSION_STATE_CLOSED_FAILED, got.State)
+	require.Equal(t, "CANCELLED: test", got.CloseReason)
+
+	// cancelling twice fails
+	require.ErrorIs(t, cancel(open, f.owner), types.ErrSessionClosed)
+}
+
+func TestExtendSessionDeadline(t *testing.T) {
+	f := initFixture(t)
+	f.setParams(t, func(p *types.Params) { p.MaxSessionDurationSeconds = 7200 })
+
+	extend := func(sess types.Session, deadline int64) error {
+		_, err := f.msgServer.ExtendSessionDeadline(f.ctx, &types.MsgExtendSessionDeadline{Owner: f.owner, SessionId: sess.SessionId, NewDeadlineUnix: deadline})
+		return err
+	}
+
+	sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+	maxDeadline := sess.CreatedUnix + 7200
+
+	// the new deadline must be strictly after the current one
+	require.ErrorIs(t, extend(sess, sess.DeadlineUnix), types.ErrInvalidDeadline)
+	require.ErrorIs(t, extend(sess, sess.DeadlineUnix-1), types.ErrInvalidDeadline)
+	// and at most created_unix + max_session_duration_seconds
+	require.ErrorIs(t, extend(sess, maxDeadline+1), types.ErrInvalidDeadline)
+
+	_, err := f.msgServer.ExtendSessionDeadline(f.ctx, &types.MsgExtendSessionDeadline{Owner: f.executor, SessionId: sess.SessionId, NewDeadlineUnix: maxDeadline})
+	require.ErrorIs(t, err, types.ErrOwnerMismatch)
+
+	require.NoError(t, extend(sess, maxDeadline))
+	got, err := f.keeper.MustGetSession(f.ctx, sess.SessionId)
+	require.NoError(t, err)
+	require.Equal(t, maxDeadline, got.DeadlineUnix)
+	// the expiry queue follows the new deadline
+	expired, err := f.keeper.SessionExpiryQueue.Has(f.ctx, collections.Join(maxDeadline, sess.SessionId))
+	require.NoError(t, err)
+	require.True(t, expired)
+	stale, err := f.keeper.SessionExpiryQueue.Has(f.ctx, collections.Join(sess.DeadlineUnix, sess.SessionId))
+	require.NoError(t, err)
+	require.False(t, stale)
+
+	// the limit counts from block time for sessions without created_unix
+	legacy := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+	legacy.CreatedUnix = 0
+	require.NoError(t, f.keeper.SetSession(f.ctx, legacy))
+	require.NoError(t, extend(legacy, f.ctx.BlockTime().Unix()+7200))
+
+	closed := f.newSession(t, types.SessionState_SESSION_STATE_CLOSED_FAILED)
+	require.ErrorIs(t, extend(closed, closed.DeadlineUnix+1), types.ErrSessionClosed)
+}
+
+func TestInitSessionDeadline(t *testing.T) {
+	f := initFixture(t)
+	f.setParams(t, func(p *types.Params) {
+		p.MaxSessionDurationSeconds = 7200
+	})
+	now := f.ctx.BlockTime().Unix()
+
+	initSession := func(deadline int64) (*types.MsgInitSessionResponse, error) {
+		return f.msgServer.InitSession(f.ctx, &types.MsgInitSession{
+			Owner:              f.owner,
+			FragmentSize:       64,
+			DeadlineUnix:       deadline,
+			UploadTokenHashHex: strings.Repeat("ab", 32),
+		})
+	}
+
+	// unset: default_deadline_seconds from now
+	res, err := initSession(0)
+	require.NoError(t, err)
+	require.Equal(t, now+types.DefaultDeadlineSeconds, res.ResolvedDeadlineUnix)
+
+	_, err = initSession(now)
+	require.ErrorIs(t, err, types.ErrInvalidDeadline)
+	_, err = initSession(now + 7201)
+	require.ErrorIs(t, err, types.ErrInvalidDeadline)
+
+	res, err = initSession(now + 7200)
+	require.NoError(t, err)
+	require.Equal(t, now+7200, res.ResolvedDeadlineUnix)
+	sess, err := f.keeper.MustGetSession(f.ctx, res.SessionId)
+	require.NoError(t, err)
+	require.Equal(t, now, sess.CreatedUnix)
+	require.Equal(t, now+7200, sess.DeadlineUnix)
+}
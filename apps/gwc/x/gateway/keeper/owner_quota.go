@@ -0,0 +1,133 @@
+package keeper
+
+import (
+	"errors"
+	"math"
+
+	"cosmossdk.io/collections"
+	errorsmod "cosmossdk.io/errors"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"gwc/x/gateway/types"
+)
+
+// adjustOwnerOpenSessions は所有者の未クローズセッション数を増減します（SetSession から呼ばれます）。
+func (k Keeper) adjustOwnerOpenSessions(ctx sdk.Context, owner string, opened bool) error {
+	n, err := k.OwnerOpenSessions.Get(ctx, owner)
+	if err != nil && !errors.Is(err, collections.ErrNotFound) {
+		return err
+	}
+	if opened {
+		n++
+	} else if n > 0 {
+		n--
+	}
+	if n == 0 {
+		return k.OwnerOpenSessions.Remove(ctx, owner)
+	}
+	return k.OwnerOpenSessions.Set(ctx, owner, n)
+}
+
+// ownerOpenSessions returns the number of sessions of owner that are not closed.
+func (k Keeper) ownerOpenSessions(ctx sdk.Context, owner string) (uint64, error) {
+	n, err := k.OwnerOpenSessions.Get(ctx, owner)
+	if errors.Is(err, collections.ErrNotFound) {
+		return 0, nil
+	}
+	return n, err
+}
+
+// checkInitSessionQuota は InitSession の前に同時オープン数と呼び出し間隔を検査し、最終呼び出し時刻を記録します。
+func (k Keeper) checkInitSessionQuota(ctx sdk.Context, params types.Params, owner string) error {
+	now := ctx.BlockTime().Unix()
+
+	if params.MinInitSessionIntervalSeconds > 0 {
+		last, err := k.OwnerLastInit.Get(ctx, owner)
+		if err != nil && !errors.Is(err, collections.ErrNotFound) {
+			return err
+		}
+		if err == nil && now-last < params.MinInitSessionIntervalSeconds {
+			return errorsmod.Wrapf(types.ErrOwnerRateLimited,
+				"init_session too frequent: last=%d now=%d min_interval=%ds", last, now, params.MinInitSessionIntervalSeconds)
+		}
+	}
+
+	if params.MaxOpenSessionsPerOwner > 0 {
+		open, err := k.ownerOpenSessions(ctx, owner)
+		if err != nil {
+			return err
+		}
+		if open >= uint64(params.MaxOpenSessionsPerOwner) {
+			return errorsmod.Wrapf(types.ErrOwnerQuotaExceeded,
+				"too many open sessions: open=%d max=%d", open, params.MaxOpenSessionsPerOwner)
+		}
+	}
+
+	return k.OwnerLastInit.Set(ctx, owner, now)
+}
+
+// ownerWindowUsage sums the usage entries of owner committed at or after since.
+func (k Keeper) ownerWindowUsage(ctx sdk.Context, owner string, since int64) (bytes, fragments uint64, err error) {
+	rng := new(collections.Range[collections.Triple[string, int64, string]]).
+		StartInclusive(collections.Join3(owner, since, "")).
+		EndExclusive(collections.Join3(owner, int64(math.MaxInt64), ""))
+	err = k.OwnerUsage.Walk(ctx, rng, func(_ collections.Triple[string, int64, string], e types.OwnerUsageEntry) (bool, error) {
+		bytes += e.Bytes
+		fragments += e.Fragments
+		return false, nil
+	})
+	return bytes, fragments, err
+}
+
+// chargeOwnerUsage はローリングウィンドウ内の使用量に今回の宣言値を加えて上限を検査し、使用量ログに記録します。
+// ウィンドウ外の古いエントリはここで削除します。
+func (k Keeper) chargeOwnerUsage(ctx sdk.Context, params types.Params, sess types.Session) error {
+	if params.OwnerQuotaWindowSeconds <= 0 {
+		return nil
+	}
+	now := ctx.BlockTime().Unix()
+	since := now - params.OwnerQuotaWindowSeconds
+
+	// 1. ウィンドウ外のエントリを削除
+	var stale []collections.Triple[string, int64, string]
+	staleRange := collections.NewPrefixedTripleRange[string, int64, string](sess.Owner)
+	err := k.OwnerUsage.Walk(ctx, staleRange, func(key collections.Triple[string, int64, string], _ types.OwnerUsageEntry) (bool, error) {
+		if key.K2() >= since {
+			return true, nil
+		}
+		stale = append(stale, key)
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range stale {
+		if err := k.OwnerUsage.Remove(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	// 2. 上限検査
+	usedBytes, usedFragments, err := k.ownerWindowUsage(ctx, sess.Owner, since)
+	if err != nil {
+		return err
+	}
+	if params.MaxBytesPerOwnerWindow > 0 && usedBytes+sess.ExpectedTotalBytes > params.MaxBytesPerOwnerWindow {
+		return errorsmod.Wrapf(types.ErrOwnerQuotaExceeded,
+			"byte quota exceeded: used=%d requested=%d max=%d per %ds", usedBytes, sess.ExpectedTotalBytes, params.MaxBytesPerOwnerWindow, params.OwnerQuotaWindowSeconds)
+	}
+	if params.MaxFragmentsPerOwnerWindow > 0 && usedFragments+sess.ExpectedFragmentCount > params.MaxFragmentsPerOwnerWindow {
+		return errorsmod.Wrapf(types.ErrOwnerQuotaExceeded,
+			"fragment quota exceeded: used=%d requested=%d max=%d per %ds", usedFragments, sess.ExpectedFragmentCount, params.MaxFragmentsPerOwnerWindow, params.OwnerQuotaWindowSeconds)
+	}
+
+	// 3. 記録
+	entry := types.OwnerUsageEntry{
+		Owner:         sess.Owner,
+		CommittedUnix: now,
+		SessionId:     sess.SessionId,
+		Bytes:         sess.ExpectedTotalBytes,
+		Fragments:     sess.ExpectedFragmentCount,
+	}
+	return k.OwnerUsage.Set(ctx, collections.Join3(entry.Owner, entry.CommittedUnix, entry.SessionId), entry)
+}
@@ -0,0 +1,86 @@
+package keeper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"gwc/x/gateway/types"
+)
+
+func TestChargeOwnerUsageRollingWindow(t *testing.T) {
+	f := initFixture(t)
+	f.setParams(t, func(p *types.Params) {
+		p.OwnerQuotaWindowSeconds = 100
+		p.MaxBytesPerOwnerWindow = 1000
+		p.MaxFragmentsPerOwnerWindow = 10
+	})
+	params := f.keeper.getParamsOrDefault(f.ctx)
+
+	charge := func(bytes, fragments uint64) error {
+		sess := f.newSession(t, types.SessionState_SESSION_STATE_INIT)
+		sess.ExpectedTotalBytes = bytes
+		sess.ExpectedFragmentCount = fragments
+		return f.keeper.chargeOwnerUsage(f.ctx, params, sess)
+	}
+	advance := func(secs int64) {
+		f.ctx = f.ctx.WithBlockTime(f.ctx.BlockTime().Add(time.Duration(secs) * time.Second))
+	}
+
+	start := f.ctx.BlockTime().Unix()
+	require.NoError(t, charge(600, 4))
+	advance(50)
+	require.NoError(t, charge(400, 4))
+
+	// both entries are inside the window
+	require.ErrorIs(t, charge(1, 1), types.ErrOwnerQuotaExceeded)
+	bytes, fragments, err := f.keeper.ownerWindowUsage(f.ctx, f.owner, start)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1000), bytes)
+	require.Equal(t, uint64(8), fragments)
+
+	// the first entry is still in the window at exactly now - window
+	advance(50)
+	require.ErrorIs(t, charge(1, 1), types.ErrOwnerQuotaExceeded)
+
+	// and leaves it one second later
+	advance(1)
+	require.NoError(t, charge(600, 1))
+	require.ErrorContains(t, charge(0, 6), "fragment quota exceeded")
+
+	// other owners are not affected
+	other := f.newSession(t, types.SessionState_SESSION_STATE_INIT)
+	other.Owner = "someone-else"
+	other.ExpectedTotalBytes = 1000
+	other.ExpectedFragmentCount = 10
+	require.NoError(t, f.keeper.chargeOwnerUsage(f.ctx, params, other))
+
+	// a zero window disables the quota
+	params.OwnerQuotaWindowSeconds = 0
+	sess := f.newSession(t, types.SessionState_SESSION_STATE_INIT)
+	sess.ExpectedTotalBytes = 1 << 40
+	require.NoError(t, f.keeper.chargeOwnerUsage(f.ctx, params, sess))
+}
+
+func TestCheckInitSessionQuota(t *testing.T) {
+	f := initFixture(t)
+	params := f.keeper.getParamsOrDefault(f.ctx)
+	params.MinInitSessionIntervalSeconds = 10
+	params.MaxOpenSessionsPerOwner = 2
+
+	require.NoError(t, f.keeper.checkInitSessionQuota(f.ctx, params, f.owner))
+	f.ctx = f.ctx.WithBlockTime(f.ctx.BlockTime().Add(9 * time.Second))
+	require.ErrorIs(t, f.keeper.checkInitSessionQuota(f.ctx, params, f.owner), types.ErrOwnerRateLimited)
+	f.ctx = f.ctx.WithBlockTime(f.ctx.BlockTime().Add(time.Second))
+	require.NoError(t, f.keeper.checkInitSessionQuota(f.ctx, params, f.owner))
+
+	// open sessions count until they are closed
+	params.MinInitSessionIntervalSeconds = 0
+	a := f.newSession(t, types.SessionState_SESSION_STATE_INIT)
+	f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+	require.ErrorIs(t, f.keeper.checkInitSessionQuota(f.ctx, params, f.owner), types.ErrOwnerQuotaExceeded)
+	a.State = types.SessionState_SESSION_STATE_CLOSED_FAILED
+	require.NoError(t, f.keeper.SetSession(f.ctx, a))
+	require.NoError(t, f.keeper.checkInitSessionQuota(f.ctx, params, f.owner))
+}
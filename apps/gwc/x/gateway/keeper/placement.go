@@ -0,0 +1,215 @@
+package keeper
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"cosmossdk.io/collections"
+	errorsmod "cosmossdk.io/errors"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"gwc/x/gateway/types"
+)
+
+// fragmentPlacer picks the FDSC channel of a fragment that has no explicit target_fdsc_channel.
+// Implementations only choose among the candidate channels they were built with
+// (the session's allowed channels minus degraded ones).
+type fragmentPlacer interface {
+	Place(ctx sdk.Context, item *types.DistributeItem) (string, error)
+}
+
+// newFragmentPlacer はセッションの placement_strategy に対応する配置戦略を返します。
+// candidates は空であってはいけません。
+func (k Keeper) newFragmentPlacer(ctx sdk.Context, sess types.Session, candidates []string) (fragmentPlacer, error) {
+	if len(candidates) == 0 {
+		return nil, errorsmod.Wrap(types.ErrNoDatastoreChannels, "no FDSC channels")
+	}
+	// イレイジャーコーディングではストライプ内のシャードを別チャネルへ分散させる必要があるため、戦略に関係なく専用の配置を使います
+	if sess.IsErasureCoded() {
+		return &erasurePlacer{
+			sessionID:    sess.SessionId,
+			channels:     candidates,
+			fragmentSize: sess.FragmentSize,
+			dataShards:   int(sess.ErasureDataShards),
+			parityShards: int(sess.ErasureParityShards),
+		}, nil
+	}
+	switch sess.PlacementStrategy {
+	case types.PlacementStrategy_PLACEMENT_STRATEGY_UNSPECIFIED, types.PlacementStrategy_PLACEMENT_STRATEGY_ROUND_ROBIN:
+		// バッチをまたいでも続きから巡回するよう、配布済み断片数を起点にします
+		return &roundRobinPlacer{channels: candidates, next: sess.DistributedCount}, nil
+	case types.PlacementStrategy_PLACEMENT_STRATEGY_WEIGHTED:
+		weights := make([]uint64, len(candidates))
+		var total uint64
+		for i, ch := range candidates {
+			weights[i] = 1
+			info, err := k.StorageInfos.Get(ctx, ch)
+			if err != nil && !errors.Is(err, collections.ErrNotFound) {
+				return nil, err
+			}
+			if err == nil && info.CapacityWeight > 0 {
+				// 上限導入前に登録された重みも上限に丸めます
+				weights[i] = min(info.CapacityWeight, types.MaxCapacityWeight)
+			}
+			if total > math.MaxUint64-weights[i] {
+				return nil, errorsmod.Wrapf(types.ErrNoDatastoreChannels, "total capacity_weight of %d channels overflows", len(candidates))
+			}
+			total += weights[i]
+		}
+		return &weightedPlacer{sessionID: sess.SessionId, channels: candidates, weights: weights, total: total}, nil
+	case types.PlacementStrategy_PLACEMENT_STRATEGY_PATH_HASH:
+		return &pathHashPlacer{sessionID: sess.SessionId, channels: candidates}, nil
+	case types.PlacementStrategy_PLACEMENT_STRATEGY_LEAST_RECENTLY_USED:
+		return &lruPlacer{k: k, channels: candidates}, nil
+	default:
+		return nil, errorsmod.Wrapf(types.ErrSessionInvalidState, "unknown placement strategy: %s", sess.PlacementStrategy.String())
+	}
+}
+
+// roundRobinPlacer cycles through the channels in order.
+type roundRobinPlacer struct {
+	channels []string
+	next     uint64
+}
+
+func (p *roundRobinPlacer) Place(_ sdk.Context, _ *types.DistributeItem) (string, error) {
+	ch := p.channels[p.next%uint64(len(p.channels))]
+	p.next++
+	return ch, nil
+}
+
+// weightedPlacer spreads fragments pseudo-randomly (but deterministically) in proportion to capacity_weight.
+type weightedPlacer struct {
+	sessionID string
+	channels  []string
+	weights   []uint64
+	total     uint64
+}
+
+func (p *weightedPlacer) Place(_ sdk.Context, item *types.DistributeItem) (string, error) {
+	if p.total == 0 {
+		return "", errorsmod.Wrap(types.ErrNoDatastoreChannels, "no weighted FDSC channels")
+	}
+	slot := placementHash("weighted", MakeFragKey(p.sessionID, item.Path, item.Index)) % p.total
+	for i, w := range p.weights {
+		if slot < w {
+			return p.channels[i], nil
+		}
+		slot -= w
+	}
+	return p.channels[len(p.channels)-1], nil
+}
+
+// pathHashPlacer keeps every fragment of a file on one channel.
+// NOTE: if the candidate set changes mid-session (a channel becomes degraded) later fragments of a file may move.
+type pathHashPlacer struct {
+	sessionID string
+	channels  []string
+}
+
+func (p *pathHashPlacer) Place(_ sdk.Context, item *types.DistributeItem) (string, error) {
+	h := placementHash("path", p.sessionID+"\x00"+item.Path)
+	return p.channels[h%uint64(len(p.channels))], nil
+}
+
+// lruPlacer picks the channel whose last FragmentPacket was sent longest ago (ChannelHealth.last_sent_seq).
+// Ties are broken by channel order. Sends are recorded by sendFragmentPacket, so consecutive fragments
+// of a batch rotate through the channels.
+type lruPlacer struct {
+	k        Keeper
+	channels []string
+}
+
+func (p *lruPlacer) Place(ctx sdk.Context, _ *types.DistributeItem) (string, error) {
+	health, err := p.k.channelHealthMap(ctx, p.channels)
+	if err != nil {
+		return "", err
+	}
+	best := p.channels[0]
+	bestSeq := health[best].LastSentSeq
+	for _, ch := range p.channels[1:] {
+		if seq := health[ch].LastSentSeq; seq < bestSeq {
+			best, bestSeq = ch, seq
+		}
+	}
+	return best, nil
+}
+
+// erasurePlacer spreads the shards of each erasure-coded stripe over distinct channels: the stripe picks
+// a starting channel by hash and its j-th shard takes the j-th channel after it, so losing one FDSC
+// costs each stripe at most one shard (given at least k+m candidates).
+type erasurePlacer struct {
+	sessionID    string
+	channels     []string
+	fragmentSize uint64
+	dataShards   int
+	parityShards int
+}
+
+func (p *erasurePlacer) Place(_ sdk.Context, item *types.DistributeItem) (string, error) {
+	stripe, pos, ok := types.LocateErasureShard(item.FileSize, p.fragmentSize, p.dataShards, p.parityShards, item.Index)
+	if !ok {
+		return "", errorsmod.Wrapf(types.ErrInvalidErasureCoding, "fragment %s#%d lies beyond the file's shards", item.Path, item.Index)
+	}
+	start := placementHash("erasure", fmt.Sprintf("%s\x00%s\x00%d", p.sessionID, item.Path, stripe))
+	return p.channels[(start+uint64(pos))%uint64(len(p.channels))], nil
+}
+
+// erasureStripeChannels returns the channels holding the other shards of the stripe that item belongs to,
+// so that a re-sent shard can avoid them.
+func (k Keeper) erasureStripeChannels(ctx sdk.Context, sess types.Session, item *types.DistributeItem) ([]string, error) {
+	dataShards, parityShards := int(sess.ErasureDataShards), int(sess.ErasureParityShards)
+	stripe, _, ok := types.LocateErasureShard(item.FileSize, sess.FragmentSize, dataShards, parityShards, item.Index)
+	if !ok {
+		return nil, errorsmod.Wrapf(types.ErrInvalidErasureCoding, "fragment %s#%d lies beyond the file's shards", item.Path, item.Index)
+	}
+	st := types.ErasureStripes(item.FileSize, sess.FragmentSize, dataShards, parityShards)[stripe]
+	var out []string
+	for i := st.FirstIndex; i < st.FirstIndex+uint64(st.DataCount+parityShards); i++ {
+		if i == item.Index {
+			continue
+		}
+		rec, err := k.GetFragmentDelivery(ctx, sess.SessionId, item.Path, i)
+		if err != nil {
+			continue // まだ配布されていないシャード
+		}
+		for _, rep := range rec.ReplicaSet() {
+			out = append(out, rep.ChannelId)
+		}
+	}
+	return out, nil
+}
+
+// replicaChannels returns n distinct channels for the copies of a fragment, starting with primary.
+// The other replicas take the channels following primary in the candidate ring; when there are not
+// enough candidates (degraded channels were excluded) the remaining allowed channels are used.
+func replicaChannels(primary string, candidates, allowed []string, n int) ([]string, error) {
+	out := []string{primary}
+	for _, pool := range [][]string{candidates, allowed} {
+		start := 0
+		for i, ch := range pool {
+			if ch == primary {
+				start = i + 1
+				break
+			}
+		}
+		for i := 0; i < len(pool) && len(out) < n; i++ {
+			if ch := pool[(start+i)%len(pool)]; !containsString(out, ch) {
+				out = append(out, ch)
+			}
+		}
+	}
+	if len(out) < n {
+		return nil, errorsmod.Wrapf(types.ErrInvalidReplicationFactor,
+			"replication_factor %d exceeds the %d FDSC channels of the session", n, len(allowed))
+	}
+	return out, nil
+}
+
+func placementHash(domain, key string) uint64 {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("placement:%s:%s", domain, key)))
+	return binary.BigEndian.Uint64(sum[:8])
+}
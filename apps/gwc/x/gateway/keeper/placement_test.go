@@ -0,0 +1,131 @@
+package keeper
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gwc/x/gateway/types"
+)
+
+var placementChannels = []string{"channel-1", "channel-2", "channel-3"}
+
+// placeAll places fragments 0..n-1 of path with a placer built for sess and returns the chosen channels.
+func placeAll(t *testing.T, f *fixture, sess types.Session, path string, n int) []string {
+	t.Helper()
+	placer, err := f.keeper.newFragmentPlacer(f.ctx, sess, placementChannels)
+	require.NoError(t, err)
+	out := make([]string, n)
+	for i := range out {
+		out[i], err = placer.Place(f.ctx, &types.DistributeItem{Path: path, Index: uint64(i)})
+		require.NoError(t, err)
+	}
+	return out
+}
+
+func TestRoundRobinPlacer(t *testing.T) {
+	cases := []struct {
+		name        string
+		distributed uint64
+		want        []string
+	}{
+		{"first batch", 0, []string{"channel-1", "channel-2", "channel-3", "channel-1"}},
+		{"continues after earlier batches", 4, []string{"channel-2", "channel-3", "channel-1", "channel-2"}},
+		{"wraps on a multiple of the channel count", 6, []string{"channel-1", "channel-2", "channel-3", "channel-1"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := initFixture(t)
+			sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+			sess.PlacementStrategy = types.PlacementStrategy_PLACEMENT_STRATEGY_ROUND_ROBIN
+			sess.DistributedCount = tc.distributed
+			require.Equal(t, tc.want, placeAll(t, f, sess, "a.txt", len(tc.want)))
+		})
+	}
+}
+
+func TestWeightedPlacer(t *testing.T) {
+	cases := []struct {
+		name    string
+		weights map[string]uint64
+		// want is the expected share of each channel in percent (±5)
+		want map[string]int
+	}{
+		{"unregistered channels count as 1", nil, map[string]int{"channel-1": 33, "channel-2": 33, "channel-3": 33}},
+		{"zero weight counts as 1", map[string]uint64{"channel-1": 0, "channel-2": 1, "channel-3": 2}, map[string]int{"channel-1": 25, "channel-2": 25, "channel-3": 50}},
+		{"proportional to capacity_weight", map[string]uint64{"channel-1": 6, "channel-2": 3, "channel-3": 1}, map[string]int{"channel-1": 60, "channel-2": 30, "channel-3": 10}},
+		{"weights above the cap are clamped", map[string]uint64{"channel-1": ^uint64(0), "channel-2": ^uint64(0), "channel-3": types.MaxCapacityWeight}, map[string]int{"channel-1": 33, "channel-2": 33, "channel-3": 33}},
+	}
+	const n = 3000
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := initFixture(t)
+			for ch, w := range tc.weights {
+				require.NoError(t, f.keeper.StorageInfos.Set(f.ctx, ch, types.StorageInfo{ChannelId: ch, CapacityWeight: w}))
+			}
+			sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+			sess.PlacementStrategy = types.PlacementStrategy_PLACEMENT_STRATEGY_WEIGHTED
+
+			placed := placeAll(t, f, sess, "a.txt", n)
+			counts := make(map[string]int)
+			for _, ch := range placed {
+				counts[ch]++
+			}
+			for ch, pct := range tc.want {
+				require.InDelta(t, pct, counts[ch]*100/n, 5, ch)
+			}
+			// deterministic for the same session and fragments
+			require.Equal(t, placed, placeAll(t, f, sess, "a.txt", n))
+		})
+	}
+
+	t.Run("empty placer", func(t *testing.T) {
+		_, err := (&weightedPlacer{}).Place(initFixture(t).ctx, &types.DistributeItem{Path: "a.txt"})
+		require.ErrorIs(t, err, types.ErrNoDatastoreChannels)
+	})
+}
+
+func TestPathHashPlacer(t *testing.T) {
+	f := initFixture(t)
+	sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+	sess.PlacementStrategy = types.PlacementStrategy_PLACEMENT_STRATEGY_PATH_HASH
+
+	used := make(map[string]bool)
+	for i := 0; i < 30; i++ {
+		path := fmt.Sprintf("dir/file-%d.txt", i)
+		placed := placeAll(t, f, sess, path, 5)
+		// every fragment of a file goes to one channel
+		for _, ch := range placed {
+			require.Equal(t, placed[0], ch, path)
+		}
+		require.Equal(t, placed, placeAll(t, f, sess, path, 5))
+		used[placed[0]] = true
+	}
+	// and files are spread over the channels
+	require.Len(t, used, len(placementChannels))
+}
+
+func TestLRUPlacer(t *testing.T) {
+	cases := []struct {
+		name     string
+		lastSent map[string]uint64
+		want     string
+	}{
+		{"no sends yet: first channel", nil, "channel-1"},
+		{"least recently used", map[string]uint64{"channel-1": 5, "channel-2": 3, "channel-3": 7}, "channel-2"},
+		{"never used beats used", map[string]uint64{"channel-1": 5, "channel-3": 7}, "channel-2"},
+		{"ties go to channel order", map[string]uint64{"channel-1": 9, "channel-2": 4, "channel-3": 4}, "channel-2"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := initFixture(t)
+			for ch, seq := range tc.lastSent {
+				require.NoError(t, f.keeper.ChannelHealth.Set(f.ctx, ch, types.ChannelHealth{ChannelId: ch, LastSentSeq: seq}))
+			}
+			sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+			sess.PlacementStrategy = types.PlacementStrategy_PLACEMENT_STRATEGY_LEAST_RECENTLY_USED
+			require.Equal(t, tc.want, placeAll(t, f, sess, "a.txt", 1)[0])
+		})
+	}
+}
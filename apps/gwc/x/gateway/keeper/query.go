@@ -3,10 +3,13 @@ package keeper
 import (
 	"context"
 	"encoding/hex"
+	"errors"
 
 	"gwc/x/gateway/types"
 
+	"cosmossdk.io/collections"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -41,22 +44,35 @@ func (k queryServer) StorageEndpoints(goCtx context.Context, req *types.QuerySto
 	}
 
 	ctx := sdk.UnwrapSDKContext(goCtx)
-	var storageInfos []*types.StorageInfo
-
-	err := k.Keeper.StorageInfos.Walk(ctx, nil, func(channelID string, info types.StorageInfo) (bool, error) {
-		storageInfos = append(storageInfos, &types.StorageInfo{
-			ChannelId:      info.ChannelId,
-			ChainId:        info.ChainId,
-			ApiEndpoint:    info.ApiEndpoint,
-			ConnectionType: info.ConnectionType,
-		})
-		return false, nil
-	})
+
+	storageInfos, pageRes, err := query.CollectionPaginate(
+		ctx,
+		k.Keeper.StorageInfos,
+		req.Pagination,
+		func(_ string, info types.StorageInfo) (*types.StorageInfo, error) {
+			return &info, nil
+		},
+	)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	return &types.QueryStorageEndpointsResponse{StorageInfos: storageInfos}, nil
+	channels := make([]string, 0, len(storageInfos))
+	for _, info := range storageInfos {
+		channels = append(channels, info.ChannelId)
+	}
+	healthByChannel, err := k.Keeper.channelHealthMap(ctx, channels)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	health := make([]types.ChannelHealth, 0, len(healthByChannel))
+	for _, ch := range channels {
+		if h, ok := healthByChannel[ch]; ok {
+			health = append(health, h)
+		}
+	}
+
+	return &types.QueryStorageEndpointsResponse{StorageInfos: storageInfos, Pagination: pageRes, ChannelHealth: health}, nil
 }
 
 func (k queryServer) Session(goCtx context.Context, req *types.QuerySessionRequest) (*types.QuerySessionResponse, error) {
@@ -78,18 +94,222 @@ func (k queryServer) SessionsByOwner(goCtx context.Context, req *types.QuerySess
 	}
 	ctx := sdk.UnwrapSDKContext(goCtx)
 
-	var out []types.Session
-	err := k.Keeper.Sessions.Walk(ctx, nil, func(sessionID string, sess types.Session) (bool, error) {
-		if sess.Owner == req.Owner {
-			out = append(out, sess)
+	// (owner, session_id) インデックスを所有者プレフィックスで走査し、必要なら状態で絞り込みます。
+	sessions, pageRes, err := query.CollectionFilteredPaginate(
+		ctx,
+		k.Keeper.SessionsByOwner,
+		req.Pagination,
+		func(key collections.Pair[string, string], _ collections.NoValue) (bool, error) {
+			if req.State == types.SessionState_SESSION_STATE_UNSPECIFIED {
+				return true, nil
+			}
+			sess, err := k.Keeper.Sessions.Get(ctx, key.K2())
+			if err != nil {
+				return false, err
+			}
+			return sess.State == req.State, nil
+		},
+		func(key collections.Pair[string, string], _ collections.NoValue) (types.Session, error) {
+			return k.Keeper.Sessions.Get(ctx, key.K2())
+		},
+		query.WithCollectionPaginationPairPrefix[string, string](req.Owner),
+	)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &types.QuerySessionsByOwnerResponse{Sessions: sessions, Pagination: pageRes}, nil
+}
+
+// Sessions は全セッションを一覧します。state を指定した場合は (state, session_id) インデックスを走査します。
+func (k queryServer) Sessions(goCtx context.Context, req *types.QuerySessionsRequest) (*types.QuerySessionsResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	if _, ok := types.SessionState_name[int32(req.State)]; !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown session state: %d", req.State)
+	}
+	ctx := sdk.UnwrapSDKContext(goCtx)
+
+	if req.State == types.SessionState_SESSION_STATE_UNSPECIFIED {
+		sessions, pageRes, err := query.CollectionPaginate(
+			ctx,
+			k.Keeper.Sessions,
+			req.Pagination,
+			func(_ string, sess types.Session) (types.Session, error) {
+				return sess, nil
+			},
+		)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
 		}
-		return false, nil
-	})
+		return &types.QuerySessionsResponse{Sessions: sessions, Pagination: pageRes}, nil
+	}
+
+	sessions, pageRes, err := query.CollectionPaginate(
+		ctx,
+		k.Keeper.SessionsByState,
+		req.Pagination,
+		func(key collections.Pair[int32, string], _ collections.NoValue) (types.Session, error) {
+			return k.Keeper.Sessions.Get(ctx, key.K2())
+		},
+		query.WithCollectionPaginationPairPrefix[int32, string](int32(req.State)),
+	)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	return &types.QuerySessionsByOwnerResponse{Sessions: out}, nil
+	return &types.QuerySessionsResponse{Sessions: sessions, Pagination: pageRes}, nil
+}
+
+// OwnerUsage は所有者の使用量と per-owner 上限を返します。
+func (k queryServer) OwnerUsage(goCtx context.Context, req *types.QueryOwnerUsageRequest) (*types.QueryOwnerUsageResponse, error) {
+	if req == nil || req.Owner == "" {
+		return nil, status.Error(codes.InvalidArgument, "owner required")
+	}
+	ctx := sdk.UnwrapSDKContext(goCtx)
+	params := k.Keeper.getParamsOrDefault(ctx)
+
+	res := &types.QueryOwnerUsageResponse{
+		Owner:              req.Owner,
+		MaxOpenSessions:    params.MaxOpenSessionsPerOwner,
+		WindowSeconds:      params.OwnerQuotaWindowSeconds,
+		MaxWindowBytes:     params.MaxBytesPerOwnerWindow,
+		MaxWindowFragments: params.MaxFragmentsPerOwnerWindow,
+	}
+
+	open, err := k.Keeper.ownerOpenSessions(ctx, req.Owner)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	res.OpenSessions = open
+
+	if params.OwnerQuotaWindowSeconds > 0 {
+		since := ctx.BlockTime().Unix() - params.OwnerQuotaWindowSeconds
+		res.WindowBytes, res.WindowFragments, err = k.Keeper.ownerWindowUsage(ctx, req.Owner, since)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	last, err := k.Keeper.OwnerLastInit.Get(ctx, req.Owner)
+	if err == nil {
+		res.LastInitUnix = last
+		res.NextInitUnix = last + params.MinInitSessionIntervalSeconds
+	} else if !errors.Is(err, collections.ErrNotFound) {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return res, nil
+}
+
+// DepositInfo はデポジット単価とエスクロー・ストレージ報酬プールの残高を返します。
+func (k queryServer) DepositInfo(goCtx context.Context, req *types.QueryDepositInfoRequest) (*types.QueryDepositInfoResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	ctx := sdk.UnwrapSDKContext(goCtx)
+	params := k.Keeper.getParamsOrDefault(ctx)
+
+	escrow := k.Keeper.accountKeeper.GetModuleAddress(types.ModuleName)
+	pool := k.Keeper.accountKeeper.GetModuleAddress(types.StorageRewardPoolName)
+
+	return &types.QueryDepositInfoResponse{
+		PricePerByte:      params.DepositPricePerByte,
+		EscrowAddress:     escrow.String(),
+		EscrowBalance:     k.Keeper.bankKeeper.GetAllBalances(ctx, escrow),
+		RewardPoolAddress: pool.String(),
+		RewardPoolBalance: k.Keeper.bankKeeper.GetAllBalances(ctx, pool),
+	}, nil
+}
+
+// Executors は executor レジストリを返します。
+func (k queryServer) Executors(goCtx context.Context, req *types.QueryExecutorsRequest) (*types.QueryExecutorsResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	ctx := sdk.UnwrapSDKContext(goCtx)
+
+	executors, pageRes, err := query.CollectionPaginate(
+		ctx,
+		k.Keeper.Executors,
+		req.Pagination,
+		func(_ string, e types.Executor) (types.Executor, error) {
+			return e, nil
+		},
+	)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &types.QueryExecutorsResponse{Executors: executors, Pagination: pageRes}, nil
+}
+
+// SessionFragments はセッションの断片配送記録をステータスで絞り込んで返します。
+// executor や運用者が再送対象（未ACK・エラー・タイムアウト）を特定するために使用します。
+func (k queryServer) SessionFragments(goCtx context.Context, req *types.QuerySessionFragmentsRequest) (*types.QuerySessionFragmentsResponse, error) {
+	if req == nil || req.SessionId == "" {
+		return nil, status.Error(codes.InvalidArgument, "session_id required")
+	}
+	ctx := sdk.UnwrapSDKContext(goCtx)
+
+	wanted := make(map[types.FragmentStatus]struct{}, len(req.Statuses))
+	for _, s := range req.Statuses {
+		wanted[s] = struct{}{}
+	}
+
+	fragments, pageRes, err := query.CollectionFilteredPaginate(
+		ctx,
+		k.Keeper.FragmentDeliveries,
+		req.Pagination,
+		func(_ collections.Triple[string, string, uint64], rec types.FragmentDelivery) (bool, error) {
+			if len(wanted) == 0 {
+				return true, nil
+			}
+			_, ok := wanted[rec.Status]
+			return ok, nil
+		},
+		func(_ collections.Triple[string, string, uint64], rec types.FragmentDelivery) (types.FragmentDelivery, error) {
+			return rec, nil
+		},
+		query.WithCollectionPaginationTriplePrefix[string, string, uint64](req.SessionId),
+	)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &types.QuerySessionFragmentsResponse{Fragments: fragments, Pagination: pageRes}, nil
+}
+
+// FragmentContents は content_id ごとに断片を保持している FDSC チャネルを返します。
+// executor はこれを使い、既に保持されている断片のバイト列を送らずに参照だけを配布します。
+func (k queryServer) FragmentContents(goCtx context.Context, req *types.QueryFragmentContentsRequest) (*types.QueryFragmentContentsResponse, error) {
+	if req == nil || len(req.ContentIds) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "content_ids required")
+	}
+	if len(req.ContentIds) > types.MaxFragmentContentsQuery {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d content_ids per request", types.MaxFragmentContentsQuery)
+	}
+	ctx := sdk.UnwrapSDKContext(goCtx)
+
+	var locations []types.ContentLocation
+	seen := make(map[string]struct{}, len(req.ContentIds))
+	for _, id := range req.ContentIds {
+		if !types.IsContentID(id) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid content_id %q", id)
+		}
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		locs, err := k.Keeper.ContentLocations(ctx, id)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		locations = append(locations, locs...)
+	}
+
+	return &types.QueryFragmentContentsResponse{Locations: locations}, nil
 }
 
 // SessionUploadTokenHash は HTTP サーバーがトークンのハッシュを確認するために使用します。
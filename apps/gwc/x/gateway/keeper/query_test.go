@@ -0,0 +1,124 @@
+package keeper
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	"github.com/stretchr/testify/require"
+
+	"gwc/x/gateway/types"
+)
+
+func sessionIDs(sessions []types.Session) []string {
+	ids := make([]string, 0, len(sessions))
+	for _, s := range sessions {
+		ids = append(ids, s.SessionId)
+	}
+	return ids
+}
+
+func TestQuerySessionsByOwnerPagination(t *testing.T) {
+	f := initFixture(t)
+	q := NewQueryServerImpl(f.keeper)
+
+	var mine []string
+	for _, state := range []types.SessionState{
+		types.SessionState_SESSION_STATE_INIT,
+		types.SessionState_SESSION_STATE_DISTRIBUTING,
+		types.SessionState_SESSION_STATE_CLOSED_SUCCESS,
+		types.SessionState_SESSION_STATE_DISTRIBUTING,
+		types.SessionState_SESSION_STATE_CLOSED_FAILED,
+	} {
+		mine = append(mine, f.newSession(t, state).SessionId)
+	}
+	other := sdk.AccAddress([]byte("other_______________")).String()
+	require.NoError(t, f.keeper.SetSession(f.ctx, types.Session{SessionId: other + "-99", Owner: other, State: types.SessionState_SESSION_STATE_INIT}))
+
+	// pages of 2 walk the owner prefix only
+	var got []string
+	var next []byte
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3)
+		res, err := q.SessionsByOwner(f.ctx, &types.QuerySessionsByOwnerRequest{
+			Owner:      f.owner,
+			Pagination: &query.PageRequest{Key: next, Limit: 2, CountTotal: next == nil},
+		})
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(res.Sessions), 2)
+		if next == nil {
+			require.Equal(t, uint64(len(mine)), res.Pagination.Total)
+		}
+		got = append(got, sessionIDs(res.Sessions)...)
+		next = res.Pagination.NextKey
+		if next == nil {
+			break
+		}
+	}
+	require.ElementsMatch(t, mine, got)
+
+	// state filter
+	res, err := q.SessionsByOwner(f.ctx, &types.QuerySessionsByOwnerRequest{
+		Owner: f.owner,
+		State: types.SessionState_SESSION_STATE_DISTRIBUTING,
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{mine[1], mine[3]}, sessionIDs(res.Sessions))
+
+	_, err = q.SessionsByOwner(f.ctx, &types.QuerySessionsByOwnerRequest{})
+	require.Error(t, err)
+}
+
+func TestQuerySessionsByState(t *testing.T) {
+	f := initFixture(t)
+	q := NewQueryServerImpl(f.keeper)
+
+	a := f.newSession(t, types.SessionState_SESSION_STATE_INIT)
+	b := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+	c := f.newSession(t, types.SessionState_SESSION_STATE_INIT)
+
+	res, err := q.Sessions(f.ctx, &types.QuerySessionsRequest{})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{a.SessionId, b.SessionId, c.SessionId}, sessionIDs(res.Sessions))
+
+	res, err = q.Sessions(f.ctx, &types.QuerySessionsRequest{
+		State:      types.SessionState_SESSION_STATE_INIT,
+		Pagination: &query.PageRequest{Limit: 1, CountTotal: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Sessions, 1)
+	require.Equal(t, uint64(2), res.Pagination.Total)
+	require.NotNil(t, res.Pagination.NextKey)
+
+	// the index follows state changes
+	b.State = types.SessionState_SESSION_STATE_CLOSED_FAILED
+	require.NoError(t, f.keeper.SetSession(f.ctx, b))
+	res, err = q.Sessions(f.ctx, &types.QuerySessionsRequest{State: types.SessionState_SESSION_STATE_DISTRIBUTING})
+	require.NoError(t, err)
+	require.Empty(t, res.Sessions)
+	res, err = q.Sessions(f.ctx, &types.QuerySessionsRequest{State: types.SessionState_SESSION_STATE_CLOSED_FAILED})
+	require.NoError(t, err)
+	require.Equal(t, []string{b.SessionId}, sessionIDs(res.Sessions))
+
+	_, err = q.Sessions(f.ctx, &types.QuerySessionsRequest{State: types.SessionState(99)})
+	require.Error(t, err)
+}
+
+func TestQueryStorageEndpointsPagination(t *testing.T) {
+	f := initFixture(t)
+	q := NewQueryServerImpl(f.keeper)
+	for _, ch := range []string{"channel-1", "channel-2", "channel-3"} {
+		require.NoError(t, f.keeper.StorageInfos.Set(f.ctx, ch, types.StorageInfo{ChannelId: ch, ChainId: "fdsc-" + ch}))
+	}
+
+	res, err := q.StorageEndpoints(f.ctx, &types.QueryStorageEndpointsRequest{Pagination: &query.PageRequest{Limit: 2}})
+	require.NoError(t, err)
+	require.Len(t, res.StorageInfos, 2)
+	require.NotNil(t, res.Pagination.NextKey)
+
+	res, err = q.StorageEndpoints(f.ctx, &types.QueryStorageEndpointsRequest{Pagination: &query.PageRequest{Key: res.Pagination.NextKey}})
+	require.NoError(t, err)
+	require.Len(t, res.StorageInfos, 1)
+	require.Equal(t, "channel-3", res.StorageInfos[0].ChannelId)
+	require.Nil(t, res.Pagination.NextKey)
+}
@@ -0,0 +1,120 @@
+package keeper
+
+import (
+	"strconv"
+
+	"cosmossdk.io/collections"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"gwc/x/gateway/types"
+)
+
+// HandleExpiredSessions は期限を過ぎたセッションを安全にクローズし、権限を剥奪します。
+// SessionExpiryQueue を期限順に走査し、期限切れのものだけを最大 max_expired_sessions_per_block 件処理します。
+// 残りは次のブロック以降で処理されます。
+// 1 件ごとにキャッシュコンテキストで処理し、失敗したセッションはログに残してキューに残します
+// （EndBlock のエラーはチェーンを停止させるため、壊れたレコード 1 件で全体を止めません）。
+// キューに残したセッションは次のブロックで再試行され、それまで同時オープン数の枠とデポジットのロックは解放されません。
+func (k Keeper) HandleExpiredSessions(ctx sdk.Context) error {
+	params := k.getParamsOrDefault(ctx)
+	limit := params.MaxExpiredSessionsPerBlock
+	if limit == 0 {
+		limit = types.DefaultMaxExpiredPerBlock
+	}
+
+	// deadline_unix < 現在時刻 のものが対象 (session_id は空にならないため (now, "") は排他的な上限として使える)
+	rng := new(collections.Range[collections.Pair[int64, string]]).
+		EndExclusive(collections.Join(ctx.BlockTime().Unix(), ""))
+
+	var due []collections.Pair[int64, string]
+	err := k.SessionExpiryQueue.Walk(ctx, rng, func(key collections.Pair[int64, string]) (bool, error) {
+		due = append(due, key)
+		return uint32(len(due)) >= limit, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range due {
+		sess, err := k.Sessions.Get(ctx, key.K2())
+		if err != nil || isSessionClosed(sess) || sess.DeadlineUnix != key.K1() {
+			// 実体と食い違うインデックスだけが残っている場合は掃除する
+			if err := k.SessionExpiryQueue.Remove(ctx, key); err != nil {
+				return err
+			}
+			continue
+		}
+		cacheCtx, write := ctx.CacheContext()
+		if err := k.expireSession(cacheCtx, sess); err != nil {
+			ctx.Logger().Error("failed to expire session; retrying next block", "session_id", sess.SessionId, "error", err)
+			continue
+		}
+		write()
+	}
+	return nil
+}
+
+// expireSession closes an in-flight session as EXPIRED, releases its pending IBC sequence bindings
+// and revokes the CSU grants.
+func (k Keeper) expireSession(ctx sdk.Context, sess types.Session) error {
+	pending, err := k.closeSessionFailed(ctx, sess, "EXPIRED", "session expired")
+	if err != nil {
+		return err
+	}
+
+	ctx.EventManager().EmitEvent(
+		sdk.NewEvent(
+			"csu_session_expired",
+			sdk.NewAttribute("session_id", sess.SessionId),
+			sdk.NewAttribute("pending_fragments", strconv.Itoa(pending)),
+		),
+	)
+	ctx.Logger().Info("Session expired and closed", "session_id", sess.SessionId, "pending_fragments", pending)
+	return nil
+}
+
+// closeSessionFailed は進行中のセッションを CLOSED_FAILED で閉じます。
+// 応答待ち断片の (channel, seq) バインドを解除して fragmentError で ERROR にし、送信済みマニフェストのバインドも解除します。
+// デポジットは返金され（失敗した場合は EndBlock で再試行）、CSU 権限は剥奪されます。
+// 解除した応答待ちパケット（レプリカ）の数を返します。
+func (k Keeper) closeSessionFailed(ctx sdk.Context, sess types.Session, closeReason, fragmentError string) (int, error) {
+	// 応答待ちの断片パケット（レプリカ単位）の (channel, seq) バインドを解除
+	type pendingReplica struct {
+		path    string
+		index   uint64
+		replica types.FragmentReplica
+	}
+	var pending []pendingReplica
+	err := k.WalkSessionFragments(ctx, sess.SessionId, func(rec types.FragmentDelivery) (bool, error) {
+		for _, rep := range rec.ReplicaSet() {
+			if rep.Status == types.FragmentStatus_FRAGMENT_STATUS_PENDING {
+				pending = append(pending, pendingReplica{path: rec.Path, index: rec.Index, replica: rep})
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range pending {
+		_ = k.UnbindFragmentSeq(ctx, p.replica.ChannelId, p.replica.PacketSequence)
+		_ = k.UpdateFragmentStatus(ctx, sess.SessionId, p.path, p.index, p.replica.ChannelId, p.replica.PacketSequence, types.FragmentStatus_FRAGMENT_STATUS_ERROR, fragmentError)
+	}
+
+	// 送信済みマニフェストのバインドを解除
+	if sess.State == types.SessionState_SESSION_STATE_FINALIZING && sess.ManifestSequence != 0 {
+		_ = k.UnbindManifestSeq(ctx, sess.ManifestSequence)
+	}
+
+	sess.State = types.SessionState_SESSION_STATE_CLOSED_FAILED
+	sess.CloseReason = closeReason
+	k.SettleSessionDepositOrDefer(ctx, &sess)
+	if err := k.SetSession(ctx, sess); err != nil {
+		return 0, err
+	}
+
+	// 権限の物理的撤去
+	k.RevokeCSUGrants(ctx, sess)
+
+	return len(pending), nil
+}
@@ -0,0 +1,66 @@
+package keeper
+
+import (
+	"testing"
+	"time"
+
+	"cosmossdk.io/collections"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"gwc/x/gateway/types"
+)
+
+func TestHandleExpiredSessionsSkipsBadRecords(t *testing.T) {
+	f := initFixture(t)
+	good := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+
+	// a corrupt record without session_id cannot be stored back
+	bad := types.Session{Owner: f.owner, State: types.SessionState_SESSION_STATE_DISTRIBUTING, DeadlineUnix: good.DeadlineUnix}
+	require.NoError(t, f.keeper.Sessions.Set(f.ctx, "corrupt", bad))
+	require.NoError(t, f.keeper.SessionExpiryQueue.Set(f.ctx, collections.Join(bad.DeadlineUnix, "corrupt")))
+
+	f.ctx = f.ctx.WithBlockTime(f.ctx.BlockTime().Add(2 * time.Hour))
+	require.NoError(t, f.keeper.HandleExpiredSessions(f.ctx))
+
+	got, err := f.keeper.MustGetSession(f.ctx, good.SessionId)
+	require.NoError(t, err)
+	require.Equal(t, types.SessionState_SESSION_STATE_CLOSED_FAILED, got.State)
+	require.Equal(t, "EXPIRED", got.CloseReason)
+
+	// the failed session is left untouched and stays queued for the next block
+	got, err = f.keeper.MustGetSession(f.ctx, "corrupt")
+	require.NoError(t, err)
+	require.Equal(t, types.SessionState_SESSION_STATE_DISTRIBUTING, got.State)
+	has, err := f.keeper.SessionExpiryQueue.Has(f.ctx, collections.Join(bad.DeadlineUnix, "corrupt"))
+	require.NoError(t, err)
+	require.True(t, has)
+
+	// retrying does not halt the chain either
+	f.ctx = f.ctx.WithBlockHeight(f.ctx.BlockHeight() + 1)
+	require.NoError(t, f.keeper.HandleExpiredSessions(f.ctx))
+	has, err = f.keeper.SessionExpiryQueue.Has(f.ctx, collections.Join(bad.DeadlineUnix, "corrupt"))
+	require.NoError(t, err)
+	require.True(t, has)
+}
+
+func TestExpiredSessionDefersFailedRefund(t *testing.T) {
+	f := initFixture(t)
+
+	// the escrow holds nothing, so the refund cannot be paid yet
+	sess := f.newSession(t, types.SessionState_SESSION_STATE_DISTRIBUTING)
+	sess.Deposit = sdk.NewInt64Coin("stake", 100)
+	sess.DepositStatus = types.DepositStatus_DEPOSIT_STATUS_LOCKED
+	require.NoError(t, f.keeper.SetSession(f.ctx, sess))
+
+	f.ctx = f.ctx.WithBlockTime(f.ctx.BlockTime().Add(2 * time.Hour))
+	require.NoError(t, f.keeper.HandleExpiredSessions(f.ctx))
+
+	got, err := f.keeper.MustGetSession(f.ctx, sess.SessionId)
+	require.NoError(t, err)
+	require.Equal(t, types.SessionState_SESSION_STATE_CLOSED_FAILED, got.State)
+	require.Equal(t, types.DepositStatus_DEPOSIT_STATUS_LOCKED, got.DepositStatus)
+	has, err := f.keeper.DepositSettleQueue.Has(f.ctx, sess.SessionId)
+	require.NoError(t, err)
+	require.True(t, has)
+}
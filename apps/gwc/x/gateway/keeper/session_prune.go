@@ -0,0 +1,114 @@
+package keeper
+
+import (
+	"cosmossdk.io/collections"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"gwc/x/gateway/types"
+)
+
+// PruneClosedSessions はクローズから session_retention_seconds を過ぎたセッションの補助データを削除し、
+// セッション本体をアーカイブ用の要約に縮小します。1ブロックあたり最大 max_expired_sessions_per_block 件。
+// アーカイブに失敗したセッションはログに残して飛ばします（補助データは残り、セッションは未アーカイブのままです）。
+func (k Keeper) PruneClosedSessions(ctx sdk.Context) error {
+	params := k.getParamsOrDefault(ctx)
+	if params.SessionRetentionSeconds <= 0 {
+		return nil
+	}
+	limit := params.MaxExpiredSessionsPerBlock
+	if limit == 0 {
+		limit = types.DefaultMaxExpiredPerBlock
+	}
+
+	cutoff := ctx.BlockTime().Unix() - params.SessionRetentionSeconds
+	rng := new(collections.Range[collections.Pair[int64, string]]).
+		EndExclusive(collections.Join(cutoff, ""))
+
+	var due []collections.Pair[int64, string]
+	err := k.SessionPruneQueue.Walk(ctx, rng, func(key collections.Pair[int64, string]) (bool, error) {
+		due = append(due, key)
+		return uint32(len(due)) >= limit, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range due {
+		if err := k.SessionPruneQueue.Remove(ctx, key); err != nil {
+			return err
+		}
+		sess, err := k.Sessions.Get(ctx, key.K2())
+		if err != nil || !isSessionClosed(sess) || sess.Archived {
+			continue
+		}
+		cacheCtx, write := ctx.CacheContext()
+		if err := k.archiveSession(cacheCtx, sess); err != nil {
+			ctx.Logger().Error("failed to archive session; skipping", "session_id", sess.SessionId, "error", err)
+			continue
+		}
+		write()
+	}
+	return nil
+}
+
+// archiveSession deletes every auxiliary key of a closed session and stores its compact summary.
+func (k Keeper) archiveSession(ctx sdk.Context, sess types.Session) error {
+	sessionID := sess.SessionId
+
+	// 断片記録に紐づく重複防止キー・(channel, seq) バインドを削除
+	var fragKeys []collections.Triple[string, string, uint64]
+	var recs []types.FragmentDelivery
+	err := k.WalkSessionFragments(ctx, sessionID, func(rec types.FragmentDelivery) (bool, error) {
+		fragKeys = append(fragKeys, collections.Join3(sessionID, rec.Path, rec.Index))
+		recs = append(recs, rec)
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	for i, rec := range recs {
+		if err := k.SessionFragmentSeen.Remove(ctx, MakeFragKey(sessionID, rec.Path, rec.Index)); err != nil {
+			return err
+		}
+		for _, rep := range rec.ReplicaSet() {
+			if err := k.FragmentSeqToFragmentKey.Remove(ctx, MakeChannelSeqKey(rep.ChannelId, rep.PacketSequence)); err != nil {
+				return err
+			}
+		}
+		if err := k.FragmentDeliveries.Remove(ctx, fragKeys[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := k.SessionFiles.Clear(ctx, collections.NewPrefixedPairRange[string, string](sessionID)); err != nil {
+		return err
+	}
+	if err := k.SessionUploadTokenHash.Remove(ctx, sessionID); err != nil {
+		return err
+	}
+	if sess.ManifestSequence != 0 {
+		if err := k.ManifestSeqToSessionID.Remove(ctx, MakeSeqKey(sess.ManifestSequence)); err != nil {
+			return err
+		}
+	}
+
+	summary := types.Session{
+		SessionId:             sessionID,
+		Owner:                 sess.Owner,
+		RootProofHex:          sess.RootProofHex,
+		State:                 sess.State,
+		CloseReason:           sess.CloseReason,
+		ExpectedFragmentCount: sess.ExpectedFragmentCount,
+		ExpectedTotalBytes:    sess.ExpectedTotalBytes,
+		ClosedUnix:            sess.ClosedUnix,
+		Deposit:               sess.Deposit,
+		DepositStatus:         sess.DepositStatus,
+		Archived:              true,
+	}
+	if err := k.SetSession(ctx, summary); err != nil {
+		return err
+	}
+
+	ctx.Logger().Info("Session archived", "session_id", sessionID, "fragments_pruned", len(recs))
+	return nil
+}
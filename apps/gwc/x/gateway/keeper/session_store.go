@@ -21,13 +21,92 @@ func (k Keeper) HasSession(ctx sdk.Context, sessionID string) (bool, error) {
 }
 
 // SetSession stores the full session object (session_id must be set).
+// The expiry queue is kept in step: only sessions that are not closed are indexed by deadline.
+// Closed sessions get closed_unix stamped and are queued for pruning until archived.
+// The (owner, session_id) and (state, session_id) indexes are updated as well.
+// Closed sessions whose deposit is still locked are queued for settlement (see RetryDepositSettlements).
 func (k Keeper) SetSession(ctx sdk.Context, s types.Session) error {
 	if s.SessionId == "" {
 		return fmt.Errorf("session_id is empty")
 	}
+	prev, err := k.Sessions.Get(ctx, s.SessionId)
+	hasPrev := err == nil
+	if hasPrev && prev.Owner != s.Owner {
+		if err := k.SessionsByOwner.Remove(ctx, collections.Join(prev.Owner, prev.SessionId)); err != nil {
+			return err
+		}
+	}
+	if err := k.SessionsByOwner.Set(ctx, collections.Join(s.Owner, s.SessionId)); err != nil {
+		return err
+	}
+	if hasPrev && prev.State != s.State {
+		if err := k.SessionsByState.Remove(ctx, collections.Join(int32(prev.State), prev.SessionId)); err != nil {
+			return err
+		}
+	}
+	if err := k.SessionsByState.Set(ctx, collections.Join(int32(s.State), s.SessionId)); err != nil {
+		return err
+	}
+	if isSessionClosed(s) && s.DepositStatus == types.DepositStatus_DEPOSIT_STATUS_LOCKED {
+		if err := k.DepositSettleQueue.Set(ctx, s.SessionId); err != nil {
+			return err
+		}
+	} else if hasPrev && prev.DepositStatus == types.DepositStatus_DEPOSIT_STATUS_LOCKED {
+		if err := k.DepositSettleQueue.Remove(ctx, s.SessionId); err != nil {
+			return err
+		}
+	}
+	wasOpen := hasPrev && !isSessionClosed(prev)
+	if isOpen := !isSessionClosed(s); wasOpen != isOpen {
+		if err := k.adjustOwnerOpenSessions(ctx, s.Owner, isOpen); err != nil {
+			return err
+		}
+	}
+	if hasPrev && !isSessionClosed(prev) {
+		if err := k.SessionExpiryQueue.Remove(ctx, collections.Join(prev.DeadlineUnix, prev.SessionId)); err != nil {
+			return err
+		}
+	}
+	if !isSessionClosed(s) {
+		if err := k.SessionExpiryQueue.Set(ctx, collections.Join(s.DeadlineUnix, s.SessionId)); err != nil {
+			return err
+		}
+	} else if !s.Archived {
+		if s.ClosedUnix == 0 {
+			s.ClosedUnix = ctx.BlockTime().Unix()
+		}
+		if hasPrev && isSessionClosed(prev) && prev.ClosedUnix != s.ClosedUnix {
+			if err := k.SessionPruneQueue.Remove(ctx, collections.Join(prev.ClosedUnix, prev.SessionId)); err != nil {
+				return err
+			}
+		}
+		if err := k.SessionPruneQueue.Set(ctx, collections.Join(s.ClosedUnix, s.SessionId)); err != nil {
+			return err
+		}
+	}
 	return k.Sessions.Set(ctx, s.SessionId, s)
 }
 
+func isSessionClosed(s types.Session) bool {
+	return s.State == types.SessionState_SESSION_STATE_CLOSED_SUCCESS || s.State == types.SessionState_SESSION_STATE_CLOSED_FAILED
+}
+
+// NextSessionID allocates a new globally unique session_id from the module session sequence.
+//
+// Format:
+//
+//	owner + "-" + seq (decimal)
+//
+// The sequence is monotonically increasing and never reused, so two sessions created by the
+// same owner in the same block get distinct ids.
+func (k Keeper) NextSessionID(ctx sdk.Context, owner string) (string, error) {
+	seq, err := k.SessionSeq.Next(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%d", owner, seq), nil
+}
+
 // MustGetSession is a helper returning a clearer error if not found.
 // (Later Issues should replace this with module-specific typed errors.)
 func (k Keeper) MustGetSession(ctx sdk.Context, sessionID string) (types.Session, error) {
@@ -61,22 +140,22 @@ func (k Keeper) IsFragmentSeen(ctx sdk.Context, sessionID, path string, index ui
 	return k.SessionFragmentSeen.Has(ctx, fragKey)
 }
 
-// BindFragmentSeq binds an IBC fragment packet sequence to a fragment key (for ACK correlation).
-func (k Keeper) BindFragmentSeq(ctx sdk.Context, seq uint64, sessionID, path string, index uint64) error {
-	seqKey := MakeSeqKey(seq)
+// BindFragmentSeq binds an IBC fragment packet (channel, sequence) to a fragment key (for ACK correlation).
+func (k Keeper) BindFragmentSeq(ctx sdk.Context, channelID string, seq uint64, sessionID, path string, index uint64) error {
+	seqKey := MakeChannelSeqKey(channelID, seq)
 	fragKey := MakeFragKey(sessionID, path, index)
 	return k.FragmentSeqToFragmentKey.Set(ctx, seqKey, fragKey)
 }
 
 // UnbindFragmentSeq removes a binding after ACK handling.
-func (k Keeper) UnbindFragmentSeq(ctx sdk.Context, seq uint64) error {
-	seqKey := MakeSeqKey(seq)
+func (k Keeper) UnbindFragmentSeq(ctx sdk.Context, channelID string, seq uint64) error {
+	seqKey := MakeChannelSeqKey(channelID, seq)
 	return k.FragmentSeqToFragmentKey.Remove(ctx, seqKey)
 }
 
-// GetFragmentKeyBySeq resolves a fragment key from an IBC fragment sequence.
-func (k Keeper) GetFragmentKeyBySeq(ctx sdk.Context, seq uint64) (string, error) {
-	seqKey := MakeSeqKey(seq)
+// GetFragmentKeyBySeq resolves a fragment key from an IBC fragment (channel, sequence).
+func (k Keeper) GetFragmentKeyBySeq(ctx sdk.Context, channelID string, seq uint64) (string, error) {
+	seqKey := MakeChannelSeqKey(channelID, seq)
 	return k.FragmentSeqToFragmentKey.Get(ctx, seqKey)
 }
 
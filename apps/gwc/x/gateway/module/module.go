@@ -74,6 +74,14 @@ func (AppModule) RegisterInterfaces(registrar codectypes.InterfaceRegistry) {
 func (am AppModule) RegisterServices(registrar grpc.ServiceRegistrar) error {
 	types.RegisterMsgServer(registrar, keeper.NewMsgServerImpl(am.keeper))
 	types.RegisterQueryServer(registrar, keeper.NewQueryServerImpl(am.keeper))
+
+	// ストアマイグレーションは module.Configurator 経由でのみ登録できます
+	if cfg, ok := registrar.(module.Configurator); ok {
+		m := keeper.NewMigrator(am.keeper)
+		if err := cfg.RegisterMigration(types.ModuleName, 1, m.Migrate1to2); err != nil {
+			return fmt.Errorf("failed to register %s migration from version 1 to 2: %w", types.ModuleName, err)
+		}
+	}
 	return nil
 }
 
@@ -100,16 +108,26 @@ func (am AppModule) ExportGenesis(ctx sdk.Context, _ codec.JSONCodec) json.RawMe
 	return am.cdc.MustMarshalJSON(genState)
 }
 
-func (AppModule) ConsensusVersion() uint64 { return 1 }
+func (AppModule) ConsensusVersion() uint64 { return 2 }
 
 func (am AppModule) BeginBlock(_ context.Context) error {
 	return nil
 }
 
-// EndBlock は期限切れセッションの自動処理を行います。
+// EndBlock は期限切れセッションの自動処理、失敗したデポジット精算の再試行と、保持期間を過ぎたクローズ済みセッションの削除を行います。
+// ここで返したエラーはチェーンを停止させるため、失敗はログに残して次のブロックで再試行します。
 func (am AppModule) EndBlock(goCtx context.Context) error {
 	ctx := sdk.UnwrapSDKContext(goCtx)
-	return am.keeper.HandleExpiredSessions(ctx)
+	if err := am.keeper.HandleExpiredSessions(ctx); err != nil {
+		ctx.Logger().Error("failed to handle expired sessions", "error", err)
+	}
+	if err := am.keeper.RetryDepositSettlements(ctx); err != nil {
+		ctx.Logger().Error("failed to retry deposit settlements", "error", err)
+	}
+	if err := am.keeper.PruneClosedSessions(ctx); err != nil {
+		ctx.Logger().Error("failed to prune closed sessions", "error", err)
+	}
+	return nil
 }
 
 func (AppModule) GetTxCmd() *cobra.Command {
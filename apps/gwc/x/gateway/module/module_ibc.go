@@ -2,7 +2,6 @@ package gateway
 
 import (
 	"fmt"
-	"strings"
 
 	errorsmod "cosmossdk.io/errors"
 
@@ -64,14 +63,6 @@ func (im IBCModule) OnRecvPacket(ctx sdk.Context, channelVersion string, moduleP
 	return channeltypes.NewErrorAcknowledgement(fmt.Errorf("GWC does not expect to receive packets"))
 }
 
-func splitFragKey(fragKey string) (sessionID string, ok bool) {
-	parts := strings.SplitN(fragKey, "\x00", 2)
-	if len(parts) != 2 {
-		return "", false
-	}
-	return parts[0], true
-}
-
 func (im IBCModule) OnAcknowledgementPacket(ctx sdk.Context, channelVersion string, modulePacket channeltypes.Packet, acknowledgement []byte, relayer sdk.AccAddress) error {
 	var ack channeltypes.Acknowledgement
 
@@ -93,13 +84,20 @@ func (im IBCModule) OnAcknowledgementPacket(ctx sdk.Context, channelVersion stri
 	switch packet := modulePacketData.Packet.(type) {
 	case *types.GatewayPacketData_FragmentPacket:
 		seq := modulePacket.Sequence
-		fragKey, err := im.keeper.GetFragmentKeyBySeq(ctx, seq)
+		channelID := modulePacket.SourceChannel
+		// チャネルのヘルスはセッションの状態に関係なく記録します
+		if ack.Success() {
+			_ = im.keeper.RecordChannelAck(ctx, channelID)
+		} else {
+			_ = im.keeper.RecordChannelError(ctx, channelID)
+		}
+		fragKey, err := im.keeper.GetFragmentKeyBySeq(ctx, channelID, seq)
 		if err != nil {
 			return nil
 		}
-		_ = im.keeper.UnbindFragmentSeq(ctx, seq)
+		_ = im.keeper.UnbindFragmentSeq(ctx, channelID, seq)
 
-		sessionID, ok := splitFragKey(fragKey)
+		sessionID, path, index, ok := keeper.ParseFragKey(fragKey)
 		if !ok {
 			return nil
 		}
@@ -109,12 +107,14 @@ func (im IBCModule) OnAcknowledgementPacket(ctx sdk.Context, channelVersion stri
 			return nil
 		}
 
-		// r を使用しないため宣言を削除します
-		switch ack.Response.(type) {
+		switch r := ack.Response.(type) {
 		case *channeltypes.Acknowledgement_Result:
 			sess.AckSuccessCount++
+			chainID := im.keeper.CounterpartyChainID(ctx, modulePacket.SourcePort, channelID)
+			_ = im.keeper.MarkFragmentAcked(ctx, sessionID, path, index, channelID, seq, chainID)
 		case *channeltypes.Acknowledgement_Error:
 			sess.AckErrorCount++
+			_ = im.keeper.UpdateFragmentStatus(ctx, sessionID, path, index, channelID, seq, types.FragmentStatus_FRAGMENT_STATUS_ERROR, r.Error)
 		}
 		_ = im.keeper.SetSession(ctx, sess)
 		return nil
@@ -131,6 +131,10 @@ func (im IBCModule) OnAcknowledgementPacket(ctx sdk.Context, channelVersion stri
 		if err != nil {
 			return nil
 		}
+		// 中断・期限切れで既に閉じたセッションは（デポジットも精算済みのため）状態を変えません
+		if sess.State != types.SessionState_SESSION_STATE_FINALIZING {
+			return nil
+		}
 
 		switch r := ack.Response.(type) {
 		case *channeltypes.Acknowledgement_Result:
@@ -139,9 +143,11 @@ func (im IBCModule) OnAcknowledgementPacket(ctx sdk.Context, channelVersion stri
 			sess.State = types.SessionState_SESSION_STATE_CLOSED_FAILED
 			sess.CloseReason = r.Error
 			// 異常終了時も確実に権限を剥奪します
-			im.keeper.RevokeCSUGrants(ctx, sess.Owner)
+			im.keeper.RevokeCSUGrants(ctx, sess)
 		}
 
+		// デポジット精算: 成功なら報酬プールへ、失敗なら所有者へ返金（精算できなければ EndBlock で再試行）
+		im.keeper.SettleSessionDepositOrDefer(ctx, &sess)
 		_ = im.keeper.SetSession(ctx, sess)
 		return nil
 
@@ -159,13 +165,18 @@ func (im IBCModule) OnTimeoutPacket(ctx sdk.Context, channelVersion string, modu
 	switch packet := modulePacketData.Packet.(type) {
 	case *types.GatewayPacketData_FragmentPacket:
 		seq := modulePacket.Sequence
-		fragKey, err := im.keeper.GetFragmentKeyBySeq(ctx, seq)
+		channelID := modulePacket.SourceChannel
+		// チャネルのヘルスはセッションの状態に関係なく記録します
+		_ = im.keeper.RecordChannelTimeout(ctx, channelID)
+		fragKey, err := im.keeper.GetFragmentKeyBySeq(ctx, channelID, seq)
 		if err == nil {
-			_ = im.keeper.UnbindFragmentSeq(ctx, seq)
-			if sessionID, ok := splitFragKey(fragKey); ok {
-				sess, _ := im.keeper.GetSession(ctx, sessionID)
-				sess.AckErrorCount++
-				_ = im.keeper.SetSession(ctx, sess)
+			_ = im.keeper.UnbindFragmentSeq(ctx, channelID, seq)
+			if sessionID, path, index, ok := keeper.ParseFragKey(fragKey); ok {
+				if sess, err := im.keeper.GetSession(ctx, sessionID); err == nil {
+					sess.AckErrorCount++
+					_ = im.keeper.SetSession(ctx, sess)
+				}
+				_ = im.keeper.UpdateFragmentStatus(ctx, sessionID, path, index, channelID, seq, types.FragmentStatus_FRAGMENT_STATUS_TIMED_OUT, "")
 			}
 		}
 		return nil
@@ -174,12 +185,16 @@ func (im IBCModule) OnTimeoutPacket(ctx sdk.Context, channelVersion string, modu
 		seq := modulePacket.Sequence
 		if sessionID, err := im.keeper.GetSessionIDByManifestSeq(ctx, seq); err == nil {
 			_ = im.keeper.UnbindManifestSeq(ctx, seq)
-			sess, _ := im.keeper.GetSession(ctx, sessionID)
+			sess, err := im.keeper.GetSession(ctx, sessionID)
+			if err != nil || sess.State != types.SessionState_SESSION_STATE_FINALIZING {
+				return nil
+			}
 			sess.State = types.SessionState_SESSION_STATE_CLOSED_FAILED
 			sess.CloseReason = "manifest packet timeout"
+			im.keeper.SettleSessionDepositOrDefer(ctx, &sess)
 			_ = im.keeper.SetSession(ctx, sess)
 			// タイムアウト時も確実に権限を剥奪します
-			im.keeper.RevokeCSUGrants(ctx, sess.Owner)
+			im.keeper.RevokeCSUGrants(ctx, sess)
 		}
 		return nil
 
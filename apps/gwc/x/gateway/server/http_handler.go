@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -58,14 +59,14 @@ func handleRender(clientCtx client.Context, k keeper.Keeper, w http.ResponseWrit
 
 	// 0. ステートからの動的なストレージトポロジー取得
 	queryClient := types.NewQueryClient(clientCtx)
-	res, err := queryClient.StorageEndpoints(req.Context(), &types.QueryStorageEndpointsRequest{})
+	storageInfos, err := types.QueryAllStorageEndpoints(req.Context(), queryClient)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to query storage topology: %v", err), http.StatusServiceUnavailable)
 		return
 	}
 
 	dynamicFDSC := make(map[string]string)
-	for _, info := range res.StorageInfos {
+	for _, info := range storageInfos {
 		if info.ApiEndpoint == "" {
 			continue
 		}
@@ -113,11 +114,19 @@ func handleRender(clientCtx client.Context, k keeper.Keeper, w http.ResponseWrit
 		Manifest struct {
 			Files map[string]struct {
 				MimeType  string `json:"mime_type"`
+				Size      uint64 `json:"size,string"`
 				Fragments []struct {
-					FdscId     string `json:"fdsc_id"`
-					FragmentId string `json:"fragment_id"`
+					FdscId         string   `json:"fdsc_id"`
+					FragmentId     string   `json:"fragment_id"`
+					ReplicaFdscIds []string `json:"replica_fdsc_ids"`
 				} `json:"fragments"`
+				ErasureDataShards   uint32 `json:"erasure_data_shards"`
+				ErasureParityShards uint32 `json:"erasure_parity_shards"`
+				CipherSuite         string `json:"cipher_suite"`
+				FragmentSize        uint64 `json:"fragment_size,string"`
+				Compression         string `json:"compression"`
 			} `json:"files"`
+			FragmentSize uint64 `json:"fragment_size,string"`
 		} `json:"manifest"`
 	}
 
@@ -132,10 +141,57 @@ func handleRender(clientCtx client.Context, k keeper.Keeper, w http.ResponseWrit
 		return
 	}
 
-	// 3. FDSCから断片を並列取得
+	// ファイルを格納したセッションの fragment_size（古いマニフェストではマニフェスト全体の値）
+	fragmentSize := fileInfo.FragmentSize
+	if fragmentSize == 0 {
+		fragmentSize = manifestResp.Manifest.FragmentSize
+	}
+
+	if err := types.ValidateCompression(fileInfo.Compression); err != nil {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	}
+
+	// 暗号化ファイルはコンテンツ鍵（X-Content-Key ヘッダー）が必要です
+	var contentKey []byte
+	if fileInfo.CipherSuite != "" {
+		if err := types.ValidateCipherSuite(fileInfo.CipherSuite); err != nil {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		// クエリ文字列はアクセスログやプロキシ、Referer に残るため、鍵はヘッダーでのみ受け付けます
+		raw := req.Header.Get("X-Content-Key")
+		key, err := hex.DecodeString(raw)
+		if raw == "" || err != nil || len(key) != types.ContentKeySize {
+			http.Error(w, fmt.Sprintf("File '%s' is encrypted: a hex content key is required in the X-Content-Key header", filePath), http.StatusUnauthorized)
+			return
+		}
+		contentKey = key
+	}
+
+	// 3. FDSCから断片を並列取得（レプリカがあれば fdsc_id → replica_fdsc_ids の順にフェイルオーバー）
 	const maxParallel = 16
 	const maxRetries = 2
 
+	// イレイジャーコーディングされたファイルは、取得できた任意の k シャードからストライプごとに復元します
+	if dataShards, parityShards := int(fileInfo.ErasureDataShards), int(fileInfo.ErasureParityShards); dataShards > 0 && parityShards > 0 {
+		shards := types.CollectErasureShards(fileInfo.Size, fragmentSize, dataShards, parityShards, maxParallel, func(index uint64) ([]byte, error) {
+			if index >= uint64(len(fileInfo.Fragments)) {
+				return nil, fmt.Errorf("shard %d is missing from the manifest", index)
+			}
+			frag := fileInfo.Fragments[index]
+			fdscIDs := append([]string{frag.FdscId}, frag.ReplicaFdscIds...)
+			return fetchFragmentFromReplicas(req.Context(), httpClient, config.FDSCEndpoints, fdscIDs, frag.FragmentId, maxRetries)
+		})
+		data, err := types.ReconstructErasureFile(shards, fileInfo.Size, fragmentSize, dataShards, parityShards)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to reconstruct erasure-coded file: %v", err), http.StatusBadGateway)
+			return
+		}
+		writeRenderedFile(w, req, fileInfo.MimeType, filePath, [][]byte{data}, len(fileInfo.Fragments), contentKey, fragmentSize, fileInfo.Compression)
+		return
+	}
+
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, maxParallel)
 	fragmentData := make([][]byte, len(fileInfo.Fragments))
@@ -143,25 +199,19 @@ func handleRender(clientCtx client.Context, k keeper.Keeper, w http.ResponseWrit
 
 	for i, frag := range fileInfo.Fragments {
 		wg.Add(1)
-		go func(i int, fdscID, fragID string) {
+		fdscIDs := append([]string{frag.FdscId}, frag.ReplicaFdscIds...)
+		go func(i int, fdscIDs []string, fragID string) {
 			defer wg.Done()
 			sem <- struct{}{}
 			defer func() { <-sem }()
 
-			endpoint, ok := config.FDSCEndpoints[fdscID]
-			if !ok {
-				errs[i] = fmt.Errorf("endpoint not found for fdsc_id: %s", fdscID)
-				return
-			}
-
-			fragURL := fmt.Sprintf("%s/fdsc/datastore/v1/fragment/%s", endpoint, url.PathEscape(fragID))
-			data, err := fetchFragmentWithRetry(req.Context(), httpClient, fragURL, maxRetries)
+			data, err := fetchFragmentFromReplicas(req.Context(), httpClient, config.FDSCEndpoints, fdscIDs, fragID, maxRetries)
 			if err != nil {
 				errs[i] = err
 				return
 			}
 			fragmentData[i] = data
-		}(i, frag.FdscId, frag.FragmentId)
+		}(i, fdscIDs, frag.FragmentId)
 	}
 
 	wg.Wait()
@@ -174,13 +224,91 @@ func handleRender(clientCtx client.Context, k keeper.Keeper, w http.ResponseWrit
 	}
 
 	// 4. 断片を結合してレスポンスを返却
-	w.Header().Set("Content-Type", fileInfo.MimeType)
+	writeRenderedFile(w, req, fileInfo.MimeType, filePath, fragmentData, len(fileInfo.Fragments), contentKey, fragmentSize, fileInfo.Compression)
+}
+
+// writeRenderedFile は断片を結合して返却します。contentKey があれば暗号文をチャンクごとに復号します。
+// 圧縮ファイルは、クライアントがそのコーデックを受け付ければ Content-Encoding を付けて格納したまま返し、
+// そうでなければ展開して返します。fragments は格納された断片数です（復号・復元後の parts の数とは一致しません）。
+func writeRenderedFile(w http.ResponseWriter, req *http.Request, mimeType, filePath string, parts [][]byte, fragments int, contentKey []byte, fragmentSize uint64, compression string) {
+	if contentKey != nil {
+		var ciphertext []byte
+		for _, p := range parts {
+			ciphertext = append(ciphertext, p...)
+		}
+		plain, err := types.DecryptFileContent(contentKey, filePath, ciphertext, int(fragmentSize))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to decrypt file: %v", err), http.StatusForbidden)
+			return
+		}
+		parts = [][]byte{plain}
+	}
+
+	if compression != "" {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if passThroughEncoding(req.Header.Get("Accept-Encoding"), compression, fragments) {
+			w.Header().Set("Content-Encoding", compression)
+		} else {
+			var stored []byte
+			for _, p := range parts {
+				stored = append(stored, p...)
+			}
+			plain, err := types.DecompressFileContent(compression, stored)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Failed to decompress file: %v", err), http.StatusBadGateway)
+				return
+			}
+			parts = [][]byte{plain}
+		}
+	}
+
+	w.Header().Set("Content-Type", mimeType)
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
-	for _, data := range fragmentData {
+	for _, data := range parts {
 		w.Write(data)
 	}
 }
 
+// passThroughEncoding reports whether the stored fragments can be sent as-is with Content-Encoding.
+// The fragments of a file are concatenated zstd frames / gzip members. Multi-frame zstd is a plain zstd
+// stream, but several HTTP clients only decode the first gzip member, so multi-fragment gzip files are
+// decompressed by the gateway instead.
+func passThroughEncoding(acceptEncoding, compression string, fragments int) bool {
+	if !types.AcceptsEncoding(acceptEncoding, compression) {
+		return false
+	}
+	return compression == types.CompressionZstd || fragments == 1
+}
+
+// fetchFragmentFromReplicas tries the FDSC chains holding a copy of the fragment in order (primary first)
+// and returns the first copy that could be fetched. Only the last replica is retried; earlier ones fail
+// over immediately so that one unreachable FDSC does not stall the render.
+func fetchFragmentFromReplicas(ctx context.Context, client *http.Client, endpoints map[string]string, fdscIDs []string, fragID string, maxRetries int) ([]byte, error) {
+	var failures []string
+	for n, fdscID := range fdscIDs {
+		endpoint, ok := endpoints[fdscID]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("endpoint not found for fdsc_id: %s", fdscID))
+			continue
+		}
+
+		retries := 0
+		if n == len(fdscIDs)-1 {
+			retries = maxRetries
+		}
+		fragURL := fmt.Sprintf("%s/fdsc/datastore/v1/fragment/%s", endpoint, url.PathEscape(fragID))
+		data, err := fetchFragmentWithRetry(ctx, client, fragURL, retries)
+		if err == nil {
+			return data, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", fdscID, err))
+	}
+	return nil, fmt.Errorf("fragment %s unavailable on every replica: %s", fragID, strings.Join(failures, "; "))
+}
+
 func fetchFragmentWithRetry(ctx context.Context, client *http.Client, url string, maxRetries int) ([]byte, error) {
 	var lastErr error
 	for attempt := 0; attempt <= maxRetries; attempt++ {
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gwc/x/gateway/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRenderedFileGzipPassThrough(t *testing.T) {
+	a, err := types.CompressChunk(types.CompressionGzip, []byte("hello, "))
+	require.NoError(t, err)
+	b, err := types.CompressChunk(types.CompressionGzip, []byte("world"))
+	require.NoError(t, err)
+
+	render := func(parts [][]byte, fragments int) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/render/p/v/a.txt", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		writeRenderedFile(rec, req, "text/plain", "a.txt", parts, fragments, nil, 64, types.CompressionGzip)
+		return rec
+	}
+
+	// a single stored gzip member is passed through
+	rec := render([][]byte{a}, 1)
+	require.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	require.Equal(t, a, rec.Body.Bytes())
+
+	// several members are decompressed, also when they reach writeRenderedFile already joined
+	// (decrypted or reconstructed files arrive as one part)
+	for _, parts := range [][][]byte{{a, b}, {append(append([]byte{}, a...), b...)}} {
+		rec = render(parts, 2)
+		require.Empty(t, rec.Header().Get("Content-Encoding"))
+		require.Equal(t, "hello, world", rec.Body.String())
+	}
+}
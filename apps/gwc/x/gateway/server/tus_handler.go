@@ -1,19 +1,37 @@
 package server
 
 import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"path"
 	"strings"
+	"time"
 
 	"gwc/x/gateway/client/executor"
 	"gwc/x/gateway/keeper"
+	"gwc/x/gateway/types"
 
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/tus/tusd/v2/pkg/filestore"
 	tusd "github.com/tus/tusd/v2/pkg/handler"
 )
 
+// TUS Upload-Metadata のキー
+const (
+	MetaKeySessionID   = "session_id"
+	MetaKeyUploadToken = "upload_token"
+	// MetaKeyArchiveFormat はアップロードの形式です（zip / tar / tar.gz / file。省略時は zip）
+	MetaKeyArchiveFormat = "archive_format"
+	// MetaKeyTargetPath は archive_format=file のときのファイルのパスです
+	MetaKeyTargetPath = "target_path"
+)
+
 // GlobalCORSMiddleware は、APIとTUSの両方で必要となるCORSヘッダーを付与し、
 // OPTIONSリクエストを適切に処理します。
 func GlobalCORSMiddleware(next http.Handler) http.Handler {
@@ -28,7 +46,7 @@ func GlobalCORSMiddleware(next http.Handler) http.Handler {
 		h.Set("Access-Control-Allow-Credentials", "true")
 		h.Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, PATCH, HEAD")
 		// TUS特有のヘッダーをすべて許可リストに含める
-		h.Set("Access-Control-Allow-Headers", "Authorization, Origin, X-Requested-With, X-Request-ID, X-HTTP-Method-Override, Content-Type, Upload-Length, Upload-Offset, Tus-Resumable, Upload-Metadata, Cache-Control")
+		h.Set("Access-Control-Allow-Headers", "Authorization, Origin, X-Requested-With, X-Request-ID, X-HTTP-Method-Override, Content-Type, Upload-Length, Upload-Offset, Tus-Resumable, Upload-Metadata, Cache-Control, X-Content-Key")
 		// ブラウザ側で読み取り可能にするヘッダーを指定
 		h.Set("Access-Control-Expose-Headers", "Location, Tus-Resumable, Upload-Offset, Upload-Length, Upload-Metadata, Tus-Version, Tus-Max-Size, Tus-Extension")
 		h.Set("Access-Control-Max-Age", "86400")
@@ -73,6 +91,8 @@ func (w *DebugTusResponseWriter) WriteHeader(statusCode int) {
 // TusWithCorsHandler は tusd.Handler をラップします
 type TusWithCorsHandler struct {
 	baseHandler *tusd.Handler
+	store       filestore.FileStore
+	clientCtx   client.Context
 }
 
 func (h *TusWithCorsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
@@ -81,13 +101,23 @@ func (h *TusWithCorsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request)
 		req.URL.Path = "/upload/tus-stream/"
 	}
 
+	// pre-patch: tusd v2 には PATCH 前のフックが無いため、ここで作成時のメタデータを再検証します
+	if req.Method == http.MethodPatch {
+		if err := h.prePatch(req); err != nil {
+			fmt.Printf("[CSU Phase 3: TUS] ⛔ PATCH rejected | Path: %s | Err: %v\n", req.URL.Path, err)
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
 	// 既にグローバルミドルウェアでOPTIONSは処理されているが、
 	// 安全のため tusd にはデバッグラッパーを被せて渡す
 	wrapper := &DebugTusResponseWriter{ResponseWriter: w, req: req}
 	h.baseHandler.ServeHTTP(wrapper, req)
 }
 
-func NewTusHandler(clientCtx client.Context, k keeper.Keeper, uploadDir, tusBasePath string) (http.Handler, error) {
+// decompressionLimit は Executor が展開するアップロードの合計サイズ上限です（0 なら types.DefaultDecompressionLimit）。
+func NewTusHandler(clientCtx client.Context, k keeper.Keeper, uploadDir, tusBasePath string, decompressionLimit int64) (http.Handler, error) {
 	if uploadDir == "" {
 		uploadDir = "./tmp/uploads"
 	}
@@ -116,6 +146,17 @@ func NewTusHandler(clientCtx client.Context, k keeper.Keeper, uploadDir, tusBase
 		NotifyUploadProgress:    true,
 		NotifyCompleteUploads:   true,
 		RespectForwardedHeaders: true,
+		PreUploadCreateCallback: func(hook tusd.HookEvent) (tusd.HTTPResponse, tusd.FileInfoChanges, error) {
+			if err := validateUploadAccess(hook.Context, clientCtx, hook.Upload.MetaData); err != nil {
+				fmt.Printf("[CSU Phase 3: TUS] ⛔ Upload rejected | SessionID: %s | Err: %v\n", hook.Upload.MetaData[MetaKeySessionID], err)
+				return tusd.HTTPResponse{}, tusd.FileInfoChanges{}, tusd.NewError("ERR_UPLOAD_FORBIDDEN", err.Error(), http.StatusForbidden)
+			}
+			if err := uploadArchiveSpec(hook.Upload.MetaData).Validate(); err != nil {
+				fmt.Printf("[CSU Phase 3: TUS] ⛔ Upload rejected | SessionID: %s | Err: %v\n", hook.Upload.MetaData[MetaKeySessionID], err)
+				return tusd.HTTPResponse{}, tusd.FileInfoChanges{}, tusd.NewError("ERR_INVALID_ARCHIVE_FORMAT", err.Error(), http.StatusBadRequest)
+			}
+			return tusd.HTTPResponse{}, tusd.FileInfoChanges{}, nil
+		},
 	})
 	if err != nil {
 		return nil, err
@@ -127,7 +168,7 @@ func NewTusHandler(clientCtx client.Context, k keeper.Keeper, uploadDir, tusBase
 			select {
 			case event := <-h.CreatedUploads:
 				fmt.Printf("[CSU Phase 3: TUS] 📤 Upload Created | TUS_ID: %s | SessionID: %s\n",
-					event.Upload.ID, event.Upload.MetaData["session_id"])
+					event.Upload.ID, event.Upload.MetaData[MetaKeySessionID])
 			case event := <-h.UploadProgress:
 				var p float64
 				if event.Upload.Size > 0 {
@@ -138,14 +179,90 @@ func NewTusHandler(clientCtx client.Context, k keeper.Keeper, uploadDir, tusBase
 				}
 			case event := <-h.CompleteUploads:
 				fmt.Printf("[CSU Phase 3: TUS] ✅ Upload Completed | TUS_ID: %s\n", event.Upload.ID)
-				if err := processCompletedUpload(clientCtx, k, event.Upload); err != nil {
+				if err := processCompletedUpload(clientCtx, k, event.Upload, decompressionLimit); err != nil {
 					fmt.Printf("[CSU Phase 3: TUS] ❌ Error processing upload: %v\n", err)
 				}
 			}
 		}
 	}()
 
-	return &TusWithCorsHandler{baseHandler: h}, nil
+	return &TusWithCorsHandler{baseHandler: h, store: store, clientCtx: clientCtx}, nil
+}
+
+// prePatch は PATCH 対象アップロードの保存済みメタデータを読み出し、トークンとセッション状態を再検証します。
+func (h *TusWithCorsHandler) prePatch(req *http.Request) error {
+	uploadID := path.Base(strings.TrimSuffix(req.URL.Path, "/"))
+	if uploadID == "" || uploadID == "." || uploadID == "/" {
+		return fmt.Errorf("missing upload id")
+	}
+
+	upload, err := h.store.GetUpload(req.Context(), uploadID)
+	if errors.Is(err, tusd.ErrNotFound) {
+		// 存在しないアップロードは tusd 側で 404 を返させます
+		return nil
+	}
+	if err != nil {
+		// それ以外の読み出し失敗は検証できないため拒否します（fail closed）
+		return fmt.Errorf("failed to load upload: %w", err)
+	}
+	info, err := upload.GetInfo(req.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load upload info: %w", err)
+	}
+	return validateUploadAccess(req.Context(), h.clientCtx, info.MetaData)
+}
+
+// validateUploadAccess は Upload-Metadata の upload_token がオンチェーンのハッシュと一致し、
+// セッションがクローズ・期限切れでなく、その executor の鍵をこのゲートウェイが保持していることを確認します。
+func validateUploadAccess(ctx context.Context, clientCtx client.Context, meta tusd.MetaData) error {
+	sessionID := meta[MetaKeySessionID]
+	token := meta[MetaKeyUploadToken]
+	if sessionID == "" {
+		return fmt.Errorf("missing %s in upload metadata", MetaKeySessionID)
+	}
+	if token == "" {
+		return fmt.Errorf("missing %s in upload metadata", MetaKeyUploadToken)
+	}
+
+	queryClient := types.NewQueryClient(clientCtx)
+
+	hashRes, err := queryClient.SessionUploadTokenHash(ctx, &types.QuerySessionUploadTokenHashRequest{SessionId: sessionID})
+	if err != nil {
+		return fmt.Errorf("upload token hash not found for session %s", sessionID)
+	}
+	sessRes, err := queryClient.Session(ctx, &types.QuerySessionRequest{SessionId: sessionID})
+	if err != nil {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	if err := checkUploadAccess(token, hashRes.TokenHashHex, sessRes.Session, time.Now()); err != nil {
+		return err
+	}
+	// 受け付けたアップロードはこのゲートウェイの executor 鍵で配布・確定するため、鍵を持たないセッションは拒否します
+	if err := executor.HasExecutorKey(clientCtx, sessRes.Session.Executor); err != nil {
+		return fmt.Errorf("session %s executor %s is not served by this gateway: %w", sessionID, sessRes.Session.Executor, err)
+	}
+	return nil
+}
+
+// checkUploadAccess はトークンとオンチェーンのハッシュ、セッションの状態・期限を照合します。
+func checkUploadAccess(token, tokenHashHex string, sess types.Session, now time.Time) error {
+	sessionID := sess.SessionId
+	expected, err := hex.DecodeString(tokenHashHex)
+	if err != nil || len(expected) != sha256.Size {
+		return fmt.Errorf("invalid stored upload token hash")
+	}
+	sum := sha256.Sum256([]byte(token))
+	if subtle.ConstantTimeCompare(sum[:], expected) != 1 {
+		return fmt.Errorf("invalid upload token")
+	}
+
+	if sess.State == types.SessionState_SESSION_STATE_CLOSED_SUCCESS || sess.State == types.SessionState_SESSION_STATE_CLOSED_FAILED {
+		return fmt.Errorf("session %s is closed", sessionID)
+	}
+	if sess.DeadlineUnix > 0 && now.Unix() > sess.DeadlineUnix {
+		return fmt.Errorf("session %s is expired", sessionID)
+	}
+	return nil
 }
 
 func TusMiddleware(tusMount http.Handler) func(http.Handler) http.Handler {
@@ -160,9 +277,9 @@ func TusMiddleware(tusMount http.Handler) func(http.Handler) http.Handler {
 	}
 }
 
-func processCompletedUpload(clientCtx client.Context, k keeper.Keeper, upload tusd.FileInfo) error {
+func processCompletedUpload(clientCtx client.Context, k keeper.Keeper, upload tusd.FileInfo, decompressionLimit int64) error {
 	meta := upload.MetaData
-	sessionID := meta["session_id"]
+	sessionID := meta[MetaKeySessionID]
 	projectName := meta["project_name"]
 	version := meta["version"]
 
@@ -175,6 +292,15 @@ func processCompletedUpload(clientCtx client.Context, k keeper.Keeper, upload tu
 		return fmt.Errorf("unable to resolve file path for upload %s", upload.ID)
 	}
 
-	fmt.Printf("[CSU Phase 3: TUS] 🔄 Triggering Executor for SessionID: %s\n", sessionID)
-	return executor.ExecuteSessionUpload(clientCtx, sessionID, filePath, projectName, version)
+	spec := uploadArchiveSpec(meta)
+	fmt.Printf("[CSU Phase 3: TUS] 🔄 Triggering Executor for SessionID: %s (format: %q)\n", sessionID, spec.Format)
+	return executor.ExecuteSessionUpload(clientCtx, sessionID, filePath, spec, projectName, version, decompressionLimit)
+}
+
+// uploadArchiveSpec は Upload-Metadata からアップロードの形式を読み出します。
+func uploadArchiveSpec(meta tusd.MetaData) types.ArchiveSpec {
+	return types.ArchiveSpec{
+		Format:     meta[MetaKeyArchiveFormat],
+		TargetPath: meta[MetaKeyTargetPath],
+	}
 }
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"gwc/x/gateway/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckUploadAccess(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	sum := sha256.Sum256([]byte("secret-token"))
+	hashHex := hex.EncodeToString(sum[:])
+	open := types.Session{
+		SessionId:    "cosmos1owner-1",
+		State:        types.SessionState_SESSION_STATE_ROOT_COMMITTED,
+		DeadlineUnix: now.Unix() + 60,
+	}
+
+	require.NoError(t, checkUploadAccess("secret-token", hashHex, open, now))
+	// no deadline
+	noDeadline := open
+	noDeadline.DeadlineUnix = 0
+	require.NoError(t, checkUploadAccess("secret-token", hashHex, noDeadline, now))
+
+	// token mismatch
+	require.ErrorContains(t, checkUploadAccess("other-token", hashHex, open, now), "invalid upload token")
+	require.ErrorContains(t, checkUploadAccess("secret-token", "zz", open, now), "invalid stored upload token hash")
+	require.ErrorContains(t, checkUploadAccess("secret-token", hashHex[:32], open, now), "invalid stored upload token hash")
+
+	// closed session
+	for _, state := range []types.SessionState{types.SessionState_SESSION_STATE_CLOSED_SUCCESS, types.SessionState_SESSION_STATE_CLOSED_FAILED} {
+		closed := open
+		closed.State = state
+		require.ErrorContains(t, checkUploadAccess("secret-token", hashHex, closed, now), "is closed")
+	}
+
+	// expired session
+	expired := open
+	expired.DeadlineUnix = now.Unix() - 1
+	require.ErrorContains(t, checkUploadAccess("secret-token", hashHex, expired, now), "is expired")
+	require.NoError(t, checkUploadAccess("secret-token", hashHex, expired, now.Add(-time.Second)))
+}
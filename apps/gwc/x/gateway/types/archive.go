@@ -0,0 +1,238 @@
+package types
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// アップロードの形式（TUS Upload-Metadata の archive_format）。"" は ArchiveFormatZip と同じです。
+const (
+	ArchiveFormatZip   = "zip"
+	ArchiveFormatTar   = "tar"
+	ArchiveFormatTarGz = "tar.gz"
+	// ArchiveFormatFile は単一ファイルのアップロードです。パスは ArchiveSpec.TargetPath で指定します。
+	ArchiveFormatFile = "file"
+)
+
+// ArchiveSpec はアップロードされたファイルの形式です。
+type ArchiveSpec struct {
+	Format     string
+	TargetPath string // ArchiveFormatFile のときのファイルのパス
+}
+
+// Validate は形式が対応しているか、単一ファイルのときパスが指定されているかを検証します。
+func (s ArchiveSpec) Validate() error {
+	switch s.Format {
+	case "", ArchiveFormatZip, ArchiveFormatTar, ArchiveFormatTarGz:
+		return nil
+	case ArchiveFormatFile:
+		if s.TargetPath == "" {
+			return fmt.Errorf("target path is required for a single file upload")
+		}
+		if _, err := normalizeArchivePath(s.TargetPath); err != nil {
+			return err
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported archive format %q", s.Format)
+	}
+}
+
+// Archive はアップロードされたファイル集合です。形式によらず Walk で通常ファイルを列挙し、
+// パスの正規化・サイズ制限・ソートは共通の処理（ProcessArchiveAndSplit / OpenArchiveStream）が行います。
+type Archive interface {
+	// Walk は通常ファイルをアーカイブ内の順に fn に渡します（ディレクトリは含みません）。
+	// size は宣言されたサイズで、分からなければ -1 です。何度でも先頭から走査し直せます。
+	Walk(fn func(name string, size int64, r io.Reader) error) error
+	Close() error
+}
+
+// OpenArchive はディスク上のアップロードを spec の形式で開きます。
+func OpenArchive(path string, spec ArchiveSpec) (Archive, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	switch spec.Format {
+	case "", ArchiveFormatZip:
+		reader, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zip reader: %w", err)
+		}
+		return &zipArchive{reader: &reader.Reader, closer: reader}, nil
+	case ArchiveFormatTar, ArchiveFormatTarGz:
+		return &tarArchive{path: path, gzipped: spec.Format == ArchiveFormatTarGz}, nil
+	default:
+		return &fileArchive{path: path, name: spec.TargetPath}, nil
+	}
+}
+
+// NewZipArchive はメモリ上の ZIP を Archive として開きます。
+func NewZipArchive(zipData []byte) (Archive, error) {
+	reader, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zip reader: %w", err)
+	}
+	return &zipArchive{reader: reader}, nil
+}
+
+type zipArchive struct {
+	reader *zip.Reader
+	closer io.Closer
+}
+
+func (a *zipArchive) Walk(fn func(name string, size int64, r io.Reader) error) error {
+	for _, file := range a.reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open file in zip: %w", err)
+		}
+		err = fn(file.Name, int64(file.UncompressedSize64), rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *zipArchive) Close() error {
+	if a.closer == nil {
+		return nil
+	}
+	return a.closer.Close()
+}
+
+type tarArchive struct {
+	path    string
+	gzipped bool
+}
+
+func (a *tarArchive) Walk(fn func(name string, size int64, r io.Reader) error) error {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if a.gzipped {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar: %w", err)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			if err := fn(hdr.Name, hdr.Size, tr); err != nil {
+				return err
+			}
+		case tar.TypeDir, tar.TypeXGlobalHeader:
+			continue
+		default:
+			// シンボリックリンク等は展開先が ZIP と揃わないため受け付けません
+			return fmt.Errorf("tar contains unsupported entry type %q: %s", hdr.Typeflag, hdr.Name)
+		}
+	}
+}
+
+func (a *tarArchive) Close() error { return nil }
+
+type fileArchive struct {
+	path string
+	name string
+}
+
+func (a *fileArchive) Walk(fn func(name string, size int64, r io.Reader) error) error {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	size := int64(-1)
+	if info, err := f.Stat(); err == nil {
+		size = info.Size()
+	}
+	return fn(a.name, size, f)
+}
+
+func (a *fileArchive) Close() error { return nil }
+
+// ProcessArchiveAndSplit はアーカイブを展開し、正規化・検証を行った上で断片化します。
+// 形式によらず ProcessZipAndSplit と同じパスの正規化・展開総量上限（DefaultDecompressionLimit）を適用し、
+// 決定論的な順序を保証するためファイルパスでソートします。
+func ProcessArchiveAndSplit(archive Archive, chunkSize int) ([]ProcessedFile, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("chunk size must be greater than 0")
+	}
+
+	var processedFiles []ProcessedFile
+	var totalDecompressedSize int64
+	err := archive.Walk(func(name string, size int64, r io.Reader) error {
+		cleanPath, err := normalizeArchivePath(name)
+		if err != nil {
+			return err
+		}
+
+		// 解凍サイズ制限の事前チェック
+		if size > 0 && totalDecompressedSize+size > DefaultDecompressionLimit {
+			return fmt.Errorf("decompression limit exceeded")
+		}
+
+		// 実際の読み込み時にサイズ制限を適用
+		limitReader := io.LimitReader(r, DefaultDecompressionLimit-totalDecompressedSize+1)
+		content, err := io.ReadAll(limitReader)
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+
+		totalDecompressedSize += int64(len(content))
+		if totalDecompressedSize > DefaultDecompressionLimit {
+			return fmt.Errorf("decompression limit exceeded")
+		}
+
+		// データを断片化
+		chunks, err := SplitDataIntoFragments(content, chunkSize)
+		if err != nil {
+			return fmt.Errorf("failed to split file: %w", err)
+		}
+
+		processedFiles = append(processedFiles, ProcessedFile{
+			Filename: name,
+			Path:     cleanPath,
+			Content:  content,
+			Chunks:   chunks,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 決定論的な順序を保証するため、パスで昇順ソートを実行します
+	sort.Slice(processedFiles, func(i, j int) bool {
+		return processedFiles[i].Path < processedFiles[j].Path
+	})
+
+	return processedFiles, nil
+}
@@ -0,0 +1,427 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// FragmentLayout は格納される断片の形（断片サイズ・圧縮・イレイジャーコーディング・暗号化）です。
+// ストリーム処理はこの形に従い、ProcessZipAndSplit → ApplyCompression / ApplyErasureCoding →
+// BuildCSUProofs と同じ断片・RootProof を生成します。
+type FragmentLayout struct {
+	FragmentSize        int
+	Compression         string
+	ErasureDataShards   int
+	ErasureParityShards int
+	// Encrypted のとき各断片が暗号化チャンクの形をしているかを検査します（ValidateEncryptedChunks と同じ）。
+	Encrypted bool
+}
+
+// FragmentLayout はセッションの断片の形を返します。
+func (s Session) FragmentLayout() FragmentLayout {
+	return FragmentLayout{
+		FragmentSize:        int(s.FragmentSize),
+		Compression:         s.Compression,
+		ErasureDataShards:   int(s.ErasureDataShards),
+		ErasureParityShards: int(s.ErasureParityShards),
+		Encrypted:           s.IsEncrypted(),
+	}
+}
+
+// Validate は断片の形が処理可能かを検証します。
+func (l FragmentLayout) Validate() error {
+	if l.FragmentSize <= 0 {
+		return fmt.Errorf("fragment size must be greater than 0")
+	}
+	if err := ValidateCompression(l.Compression); err != nil {
+		return err
+	}
+	if l.Compression != "" && CompressionChunkSize(l.FragmentSize) <= 0 {
+		return fmt.Errorf("fragment size %d is too small for compression", l.FragmentSize)
+	}
+	if (l.ErasureDataShards > 0) != (l.ErasureParityShards > 0) {
+		return fmt.Errorf("erasure data and parity shards must be set together")
+	}
+	if l.Compression != "" && l.ErasureDataShards > 0 {
+		return fmt.Errorf("compression cannot be combined with erasure coding")
+	}
+	return nil
+}
+
+// rawChunkSize はファイルを読み出す単位（圧縮前のチャンクサイズ）です。
+func (l FragmentLayout) rawChunkSize() int {
+	if l.Compression != "" {
+		return CompressionChunkSize(l.FragmentSize)
+	}
+	return l.FragmentSize
+}
+
+// encodeFile は r を断片の形に従って断片化し、格納される断片ごとに emit を呼びます。
+// メモリに保持するのは 1 チャンク（イレイジャーコーディングでは 1 ストライプ）だけです。
+// 返り値は file_size（圧縮時は圧縮後の、それ以外は元データのバイト数）です。
+func (l FragmentLayout) encodeFile(path string, r io.Reader, emit func(index uint64, fragment []byte) error) (uint64, error) {
+	chunkSize := l.rawChunkSize()
+	var rawSize, storedSize, index, chunkIndex uint64
+	var stripe [][]byte
+
+	flushStripe := func() error {
+		if len(stripe) == 0 {
+			return nil
+		}
+		shards, err := EncodeErasureShards(stripe, l.ErasureDataShards, l.ErasureParityShards)
+		if err != nil {
+			return fmt.Errorf("failed to erasure-code %s: %w", path, err)
+		}
+		for _, s := range shards {
+			if err := emit(index, s); err != nil {
+				return err
+			}
+			index++
+		}
+		stripe = stripe[:0]
+		return nil
+	}
+
+	for {
+		chunk := make([]byte, chunkSize)
+		n, err := io.ReadFull(r, chunk)
+		if n > 0 {
+			chunk = chunk[:n]
+			rawSize += uint64(n)
+			if l.Encrypted && n < EncryptionOverhead {
+				return 0, fmt.Errorf("%s: chunk %d (%d bytes) is not an encrypted chunk", path, chunkIndex, n)
+			}
+			chunkIndex++
+
+			switch {
+			case l.Compression != "":
+				z, cerr := CompressChunk(l.Compression, chunk)
+				if cerr != nil {
+					return 0, fmt.Errorf("failed to compress %s chunk %d: %w", path, chunkIndex-1, cerr)
+				}
+				if len(z) > l.FragmentSize {
+					return 0, fmt.Errorf("%s chunk %d: compressed size %d exceeds fragment size %d", path, chunkIndex-1, len(z), l.FragmentSize)
+				}
+				storedSize += uint64(len(z))
+				if err := emit(index, z); err != nil {
+					return 0, err
+				}
+				index++
+			case l.ErasureDataShards > 0:
+				stripe = append(stripe, chunk)
+				if len(stripe) == l.ErasureDataShards {
+					if err := flushStripe(); err != nil {
+						return 0, err
+					}
+				}
+			default:
+				if err := emit(index, chunk); err != nil {
+					return 0, err
+				}
+				index++
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	}
+	if err := flushStripe(); err != nil {
+		return 0, err
+	}
+
+	if l.Compression != "" {
+		return storedSize, nil
+	}
+	return rawSize, nil
+}
+
+// StreamedFile は ArchiveStream の 1 ファイルです。断片のバイト列は保持せず、葉と証明に必要な木だけを持ちます。
+type StreamedFile struct {
+	Filename      string
+	Path          string // 正規化されたパス
+	FileSize      uint64
+	FileRoot      string
+	FragmentCount uint64
+
+	contentIDs []string
+	fragTree   *MerkleTree
+	fileProof  *MerkleProof
+}
+
+// ArchiveStream はディスク上のアップロード（Archive）を 2 パスで処理します。
+//
+//  1. OpenArchiveStream: 全ファイルを 1 度読み、断片のハッシュから Merkle Tree と RootProof を構築する
+//  2. ForEachFragment: アーカイブをもう一度読み、断片を証明付きで順に渡す
+//
+// メモリ使用量は断片数に比例する葉（ハッシュ）と 1 チャンク分のバッファに抑えられ、
+// アーカイブ全体やファイルの内容は保持しません。
+type ArchiveStream struct {
+	RootProofHex  string
+	Files         []StreamedFile // パス昇順
+	FragmentCount uint64
+	TotalBytes    uint64
+
+	archive Archive
+	layout  FragmentLayout
+	byPath  map[string]int
+}
+
+// errStopStream は断片の走査を途中で打ち切るための内部エラーです。
+var errStopStream = errors.New("stop stream")
+
+// OpenArchiveStream はアーカイブの 1 パス目（ハッシュと Merkle Tree の構築）を実行します。
+// パスは ProcessArchiveAndSplit と同じく正規化・検証され、展開後の合計サイズが limit（0 なら
+// DefaultDecompressionLimit）を超えるとエラーになります。Close は archive を閉じます。
+func OpenArchiveStream(archive Archive, layout FragmentLayout, limit int64) (*ArchiveStream, error) {
+	if err := layout.Validate(); err != nil {
+		archive.Close()
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultDecompressionLimit
+	}
+
+	s := &ArchiveStream{archive: archive, layout: layout, byPath: make(map[string]int)}
+	if err := s.scan(limit); err != nil {
+		archive.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// OpenZipStream は zipPath の ZIP を開き、OpenArchiveStream の 1 パス目を実行します。
+func OpenZipStream(zipPath string, layout FragmentLayout, limit int64) (*ArchiveStream, error) {
+	archive, err := OpenArchive(zipPath, ArchiveSpec{Format: ArchiveFormatZip})
+	if err != nil {
+		return nil, err
+	}
+	return OpenArchiveStream(archive, layout, limit)
+}
+
+// Close はアーカイブを閉じます。
+func (s *ArchiveStream) Close() error {
+	return s.archive.Close()
+}
+
+// scan は 1 パス目です。各ファイルの断片の葉から file root を、file leaf から RootProof を求めます。
+func (s *ArchiveStream) scan(limit int64) error {
+	var totalDecompressed int64
+	fragLeaves := make(map[string][]string)
+	err := s.archive.Walk(func(name string, size int64, r io.Reader) error {
+		cleanPath, err := normalizeArchivePath(name)
+		if err != nil {
+			return err
+		}
+		if _, dup := s.byPath[cleanPath]; dup {
+			return fmt.Errorf("archive contains duplicate file path: %s", cleanPath)
+		}
+
+		// 解凍サイズ制限の事前チェック
+		if size > 0 && totalDecompressed+size > limit {
+			return fmt.Errorf("decompression limit exceeded")
+		}
+		counter := &countingReader{r: io.LimitReader(r, limit-totalDecompressed+1)}
+
+		f := StreamedFile{Filename: name, Path: cleanPath}
+		var leaves []string
+		fileSize, err := s.layout.encodeFile(cleanPath, counter, func(index uint64, fragment []byte) error {
+			contentID := MakeContentID(fragment)
+			leaves = append(leaves, fragmentLeaf(cleanPath, index, contentID))
+			f.contentIDs = append(f.contentIDs, contentID)
+			s.TotalBytes += uint64(len(fragment))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		totalDecompressed += counter.n
+		if totalDecompressed > limit {
+			return fmt.Errorf("decompression limit exceeded")
+		}
+
+		// 空ファイルは BuildCSUProofs と同様に RootProof に含めません
+		if len(leaves) > 0 {
+			f.FileSize = fileSize
+			f.FragmentCount = uint64(len(leaves))
+			fragLeaves[cleanPath] = leaves
+		}
+		s.byPath[cleanPath] = len(s.Files)
+		s.Files = append(s.Files, f)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 決定論的な順序を保証するため、パスで昇順ソートを実行します
+	sort.Slice(s.Files, func(i, j int) bool {
+		return s.Files[i].Path < s.Files[j].Path
+	})
+
+	var fileLeaves []string
+	var leafFiles []int
+	for i := range s.Files {
+		f := &s.Files[i]
+		s.byPath[f.Path] = i
+		if f.FragmentCount == 0 {
+			continue
+		}
+		f.fragTree = NewMerkleTree(fragLeaves[f.Path])
+		f.FileRoot = f.fragTree.Root()
+		s.FragmentCount += f.FragmentCount
+
+		rawFileLeaf := fmt.Sprintf("FILE:%s:%d:%s", f.Path, f.FileSize, f.FileRoot)
+		fileLeafHash := sha256.Sum256([]byte(rawFileLeaf))
+		fileLeaves = append(fileLeaves, hex.EncodeToString(fileLeafHash[:]))
+		leafFiles = append(leafFiles, i)
+	}
+
+	rootTree := NewMerkleTree(fileLeaves)
+	s.RootProofHex = rootTree.Root()
+	for leafIdx, i := range leafFiles {
+		proof, err := rootTree.GenerateProof(leafIdx)
+		if err != nil {
+			return fmt.Errorf("failed to generate file proof for %s: %w", s.Files[i].Path, err)
+		}
+		s.Files[i].fileProof = proof
+	}
+	return nil
+}
+
+// FileProofs は空でない各ファイルの file leaf とその証明を返します（差分デプロイの再利用判定に使います）。
+func (s *ArchiveStream) FileProofs() []CSUFileProofData {
+	var out []CSUFileProofData
+	for _, f := range s.Files {
+		if f.FragmentCount == 0 {
+			continue
+		}
+		out = append(out, CSUFileProofData{
+			Path:          f.Path,
+			FileSize:      f.FileSize,
+			FileRoot:      f.FileRoot,
+			FileProof:     f.fileProof,
+			FragmentCount: f.FragmentCount,
+		})
+	}
+	return out
+}
+
+// ContentIDs は include が true を返すファイルの断片の content_id を、断片の順に返します（重複を含む）。
+func (s *ArchiveStream) ContentIDs(include func(path string) bool) []string {
+	var out []string
+	for _, f := range s.Files {
+		if include == nil || include(f.Path) {
+			out = append(out, f.contentIDs...)
+		}
+	}
+	return out
+}
+
+// ForEachFragment は 2 パス目です。include が true を返すファイルの断片を、アーカイブ内のファイル順・
+// インデックス順に証明付きで fn に渡します。1 パス目と内容が異なる（アーカイブが書き換えられた）場合はエラーになります。
+func (s *ArchiveStream) ForEachFragment(include func(path string) bool, fn func(frag CSUFragmentProofData) error) error {
+	return s.archive.Walk(func(name string, _ int64, r io.Reader) error {
+		f, err := s.lookup(name)
+		if err != nil {
+			return err
+		}
+		if f.FragmentCount == 0 || (include != nil && !include(f.Path)) {
+			return nil
+		}
+		return s.streamFile(f, r, fn)
+	})
+}
+
+// Fragment は 1 断片をアーカイブから読み直して返します（再送用）。ファイルの先頭から該当断片まで読み進めます。
+func (s *ArchiveStream) Fragment(path string, index uint64) (*CSUFragmentProofData, error) {
+	i, ok := s.byPath[path]
+	if !ok || index >= s.Files[i].FragmentCount {
+		return nil, fmt.Errorf("fragment not found: %s#%d", path, index)
+	}
+	var found *CSUFragmentProofData
+	err := s.archive.Walk(func(name string, _ int64, r io.Reader) error {
+		f, err := s.lookup(name)
+		if err != nil || f.Path != path {
+			return err
+		}
+		return s.streamFile(f, r, func(frag CSUFragmentProofData) error {
+			if frag.Index == index {
+				found = &frag
+				return errStopStream
+			}
+			return nil
+		})
+	})
+	if err != nil && !errors.Is(err, errStopStream) {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("fragment not found: %s#%d", path, index)
+	}
+	return found, nil
+}
+
+// lookup はアーカイブ内の名前から 1 パス目のファイルを引きます。
+func (s *ArchiveStream) lookup(name string) (*StreamedFile, error) {
+	cleanPath, err := normalizeArchivePath(name)
+	if err != nil {
+		return nil, err
+	}
+	i, ok := s.byPath[cleanPath]
+	if !ok {
+		return nil, fmt.Errorf("%s: file not found in the first pass", cleanPath)
+	}
+	return &s.Files[i], nil
+}
+
+// streamFile はファイルを読み、断片を 1 パス目の葉と照合しながら証明付きで fn に渡します。
+func (s *ArchiveStream) streamFile(f *StreamedFile, r io.Reader, fn func(frag CSUFragmentProofData) error) error {
+	var emitted uint64
+	_, err := s.layout.encodeFile(f.Path, r, func(index uint64, fragment []byte) error {
+		emitted++
+		if index >= f.FragmentCount || MakeContentID(fragment) != f.contentIDs[index] {
+			return fmt.Errorf("%s: fragment %d changed since the first pass", f.Path, index)
+		}
+		proof, err := f.fragTree.GenerateProof(int(index))
+		if err != nil {
+			return fmt.Errorf("failed to generate fragment proof for %s[%d]: %w", f.Path, index, err)
+		}
+		return fn(CSUFragmentProofData{
+			Path:          f.Path,
+			Index:         index,
+			FragmentBytes: fragment,
+			FragmentProof: proof,
+			FileSize:      f.FileSize,
+			FileProof:     f.fileProof,
+		})
+	})
+	if err == nil && emitted != f.FragmentCount {
+		return fmt.Errorf("%s: fragment count changed since the first pass", f.Path)
+	}
+	return err
+}
+
+// fragmentLeaf は SHA256("FRAG:{path}:{index}:{hex(SHA256(bytes))}") を返します（BuildCSUProofs と同じ）。
+func fragmentLeaf(path string, index uint64, chunkHashHex string) string {
+	leafHash := sha256.Sum256([]byte(fmt.Sprintf("FRAG:%s:%d:%s", path, index, chunkHashHex)))
+	return hex.EncodeToString(leafHash[:])
+}
+
+// countingReader は読み出したバイト数を数えます。
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,144 @@
+package types_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gwc/x/gateway/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeTar writes the entries as a tar (gzip-compressed if gz) in a temp dir and returns its path.
+func writeTar(t *testing.T, gz bool, entries ...zipEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "assets/", Typeflag: tar.TypeDir, Mode: 0o755}))
+	for _, e := range entries {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: e.name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(e.data))}))
+		_, err := tw.Write(e.data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	data := buf.Bytes()
+	name := "site.tar"
+	if gz {
+		var zbuf bytes.Buffer
+		zw := gzip.NewWriter(&zbuf)
+		_, err := zw.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+		data = zbuf.Bytes()
+		name += ".gz"
+	}
+	p := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(p, data, 0o644))
+	return p
+}
+
+func processArchive(t *testing.T, path string, spec types.ArchiveSpec) []types.ProcessedFile {
+	t.Helper()
+	archive, err := types.OpenArchive(path, spec)
+	require.NoError(t, err)
+	defer archive.Close()
+	files, err := types.ProcessArchiveAndSplit(archive, 64)
+	require.NoError(t, err)
+	return files
+}
+
+// TestArchiveFormatsMatchZip checks that tar and tar.gz uploads of the same site produce the same
+// normalized file list and the same RootProof as the ZIP.
+func TestArchiveFormatsMatchZip(t *testing.T) {
+	layout := types.FragmentLayout{FragmentSize: 64}
+	zipPath := writeZip(t, sampleSite()...)
+	want := processArchive(t, zipPath, types.ArchiveSpec{})
+	zipStream, err := types.OpenZipStream(zipPath, layout, 0)
+	require.NoError(t, err)
+	defer zipStream.Close()
+
+	for _, spec := range []types.ArchiveSpec{{Format: types.ArchiveFormatTar}, {Format: types.ArchiveFormatTarGz}} {
+		path := writeTar(t, spec.Format == types.ArchiveFormatTarGz, sampleSite()...)
+		got := processArchive(t, path, spec)
+		require.Equal(t, len(want), len(got))
+		for i := range want {
+			require.Equal(t, want[i].Path, got[i].Path)
+			require.Equal(t, want[i].Chunks, got[i].Chunks)
+		}
+
+		archive, err := types.OpenArchive(path, spec)
+		require.NoError(t, err)
+		stream, err := types.OpenArchiveStream(archive, layout, 0)
+		require.NoError(t, err)
+		require.Equal(t, zipStream.RootProofHex, stream.RootProofHex)
+		require.Equal(t, zipStream.FileProofs(), stream.FileProofs())
+
+		frag, err := stream.Fragment("b/app.js", 3)
+		require.NoError(t, err)
+		wantFrag, err := zipStream.Fragment("b/app.js", 3)
+		require.NoError(t, err)
+		require.Equal(t, *wantFrag, *frag)
+		require.NoError(t, stream.Close())
+	}
+}
+
+func TestArchiveSingleFile(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "upload.bin")
+	data := patternBytes(200, 5)
+	require.NoError(t, os.WriteFile(p, data, 0o644))
+
+	files := processArchive(t, p, types.ArchiveSpec{Format: types.ArchiveFormatFile, TargetPath: "./docs\\manual.pdf"})
+	require.Len(t, files, 1)
+	require.Equal(t, "docs/manual.pdf", files[0].Path)
+	require.Equal(t, data, files[0].Content)
+	require.Len(t, files[0].Chunks, 4)
+
+	archive, err := types.OpenArchive(p, types.ArchiveSpec{Format: types.ArchiveFormatFile, TargetPath: "docs/manual.pdf"})
+	require.NoError(t, err)
+	stream, err := types.OpenArchiveStream(archive, types.FragmentLayout{FragmentSize: 64}, 0)
+	require.NoError(t, err)
+	defer stream.Close()
+	require.Equal(t, uint64(4), stream.FragmentCount)
+	require.Equal(t, uint64(len(data)), stream.TotalBytes)
+
+	// the declared size is checked against the limit before reading
+	archive, err = types.OpenArchive(p, types.ArchiveSpec{Format: types.ArchiveFormatFile, TargetPath: "a.bin"})
+	require.NoError(t, err)
+	_, err = types.OpenArchiveStream(archive, types.FragmentLayout{FragmentSize: 64}, 199)
+	require.Error(t, err)
+}
+
+func TestArchiveSpecValidate(t *testing.T) {
+	require.NoError(t, types.ArchiveSpec{}.Validate())
+	require.NoError(t, types.ArchiveSpec{Format: types.ArchiveFormatTarGz}.Validate())
+	require.Error(t, types.ArchiveSpec{Format: "rar"}.Validate())
+	require.Error(t, types.ArchiveSpec{Format: types.ArchiveFormatFile}.Validate())
+	require.Error(t, types.ArchiveSpec{Format: types.ArchiveFormatFile, TargetPath: "../etc/passwd"}.Validate())
+}
+
+func TestArchiveRejectsUnsafeTarEntries(t *testing.T) {
+	spec := types.ArchiveSpec{Format: types.ArchiveFormatTar}
+
+	p := writeTar(t, false, zipEntry{"../evil.txt", []byte("x")})
+	archive, err := types.OpenArchive(p, spec)
+	require.NoError(t, err)
+	_, err = types.ProcessArchiveAndSplit(archive, 64)
+	require.Error(t, err)
+
+	// symbolic links are not followed
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}))
+	require.NoError(t, tw.Close())
+	p = filepath.Join(t.TempDir(), "link.tar")
+	require.NoError(t, os.WriteFile(p, buf.Bytes(), 0o644))
+	archive, err = types.OpenArchive(p, spec)
+	require.NoError(t, err)
+	_, err = types.ProcessArchiveAndSplit(archive, 64)
+	require.Error(t, err)
+}
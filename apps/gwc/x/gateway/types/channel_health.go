@@ -0,0 +1,28 @@
+package types
+
+// IsRoutable reports whether default routing may pick the channel at nowUnix.
+// Degraded channels are skipped until their cooldown ends; afterwards they are tried again
+// (a success ack clears the degraded flag, a failure restarts the cooldown).
+func (h ChannelHealth) IsRoutable(nowUnix int64) bool {
+	return !h.Degraded || nowUnix >= h.DegradedUntilUnix
+}
+
+// RoutableChannels filters channels (order preserved) down to the ones default routing may use.
+// Channels without a health record are healthy. If every channel is degraded the input is returned
+// unchanged so that distribution can still make progress.
+//
+// Shared by the keeper (DistributeBatch / RedistributeFragments) and the executor client so that
+// both pick channels from the same health view.
+func RoutableChannels(channels []string, health map[string]ChannelHealth, nowUnix int64) []string {
+	out := make([]string, 0, len(channels))
+	for _, ch := range channels {
+		if h, ok := health[ch]; ok && !h.IsRoutable(nowUnix) {
+			continue
+		}
+		out = append(out, ch)
+	}
+	if len(out) == 0 {
+		return channels
+	}
+	return out
+}
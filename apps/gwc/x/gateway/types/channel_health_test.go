@@ -0,0 +1,30 @@
+package types_test
+
+import (
+	"testing"
+
+	"gwc/x/gateway/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutableChannels(t *testing.T) {
+	channels := []string{"channel-1", "channel-2", "channel-3"}
+	health := map[string]types.ChannelHealth{
+		"channel-1": {ChannelId: "channel-1", Acks: 3},
+		"channel-2": {ChannelId: "channel-2", Degraded: true, DegradedUntilUnix: 200},
+	}
+
+	// degraded channel is skipped during its cooldown
+	require.Equal(t, []string{"channel-1", "channel-3"}, types.RoutableChannels(channels, health, 100))
+	// and tried again once the cooldown ended
+	require.Equal(t, channels, types.RoutableChannels(channels, health, 200))
+
+	// every channel degraded: fall back to all channels
+	allDegraded := map[string]types.ChannelHealth{
+		"channel-1": {Degraded: true, DegradedUntilUnix: 200},
+		"channel-2": {Degraded: true, DegradedUntilUnix: 200},
+		"channel-3": {Degraded: true, DegradedUntilUnix: 200},
+	}
+	require.Equal(t, channels, types.RoutableChannels(channels, allDegraded, 100))
+}
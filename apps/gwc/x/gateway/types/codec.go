@@ -13,9 +13,13 @@ func RegisterInterfaces(registry types.InterfaceRegistry) {
 		&MsgInitSession{},
 		&MsgCommitRootProof{},
 		&MsgDistributeBatch{},
+		&MsgRedistributeFragments{},
 		&MsgFinalizeAndCloseSession{},
 		&MsgAbortAndCloseSession{},
 		&MsgRegisterStorage{},
+		&MsgSetExecutor{},
+		&MsgCancelSession{},
+		&MsgExtendSessionDeadline{},
 	)
 
 	// Authz: session-bound authorization
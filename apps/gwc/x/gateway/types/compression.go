@@ -0,0 +1,163 @@
+package types
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Fragment compression codecs. Every chunk of a file is compressed separately into one fragment (a complete
+// gzip member / zstd frame), so a fragment can be decoded on its own and the concatenated fragments form a
+// single valid gzip (RFC 1952 multi-member) / zstd (RFC 8878 multi-frame) stream.
+const (
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// IsCompressed reports whether the session's fragments are compressed chunks.
+func (s Session) IsCompressed() bool {
+	return s.Compression != ""
+}
+
+// ValidateCompression returns an error for codecs this gateway cannot decode.
+func ValidateCompression(codec string) error {
+	if codec != "" && codec != CompressionGzip && codec != CompressionZstd {
+		return fmt.Errorf("unsupported compression %q", codec)
+	}
+	return nil
+}
+
+// CompressionOverhead bounds how much a chunk of fragmentSize bytes can grow when it does not compress
+// (container header / trailer and the stored-block headers of both codecs).
+func CompressionOverhead(fragmentSize int) int {
+	return 64 + fragmentSize/256
+}
+
+// CompressionChunkSize returns the raw bytes per chunk, chosen so that a compressed chunk never exceeds
+// fragmentSize even for incompressible data.
+func CompressionChunkSize(fragmentSize int) int {
+	return fragmentSize - CompressionOverhead(fragmentSize)
+}
+
+// CompressChunk compresses one chunk into a single gzip member / zstd frame.
+// The output is deterministic for a given codec implementation, since the owner and the executor must
+// derive the same fragments (and so the same RootProof) from the same ZIP.
+func CompressChunk(codec string, chunk []byte) ([]byte, error) {
+	switch codec {
+	case CompressionGzip:
+		var buf bytes.Buffer
+		zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := zw.Write(chunk); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionZstd:
+		enc, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+		if err != nil {
+			return nil, err
+		}
+		defer enc.Close()
+		return enc.EncodeAll(chunk, nil), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", codec)
+	}
+}
+
+// NewDecompressReader decodes a stream of concatenated fragments (gzip members / zstd frames) of codec.
+func NewDecompressReader(codec string, r io.Reader) (io.ReadCloser, error) {
+	switch codec {
+	case CompressionGzip:
+		return gzip.NewReader(r)
+	case CompressionZstd:
+		dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", codec)
+	}
+}
+
+// DecompressFileContent decodes the concatenated fragments of a compressed file. The output is limited to
+// DefaultDecompressionLimit bytes (the limit on a whole upload) so that a crafted fragment cannot exhaust memory.
+func DecompressFileContent(codec string, data []byte) ([]byte, error) {
+	zr, err := NewDecompressReader(codec, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(io.LimitReader(zr, DefaultDecompressionLimit+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > DefaultDecompressionLimit {
+		return nil, fmt.Errorf("decompressed size exceeds %d bytes", DefaultDecompressionLimit)
+	}
+	return out, nil
+}
+
+// ApplyCompression replaces the chunks of each file with its separately compressed chunks: the content is
+// split into CompressionChunkSize(fragmentSize) pieces and each piece becomes one fragment of at most
+// fragmentSize bytes. Content is replaced by the concatenated fragments, since file_size and the proofs
+// refer to the stored (compressed) bytes.
+func ApplyCompression(files []ProcessedFile, codec string, fragmentSize int) error {
+	chunkSize := CompressionChunkSize(fragmentSize)
+	if chunkSize <= 0 {
+		return fmt.Errorf("fragment size %d is too small for compression", fragmentSize)
+	}
+	for i := range files {
+		raw, err := SplitDataIntoFragments(files[i].Content, chunkSize)
+		if err != nil {
+			return err
+		}
+		chunks := make([][]byte, 0, len(raw))
+		var content []byte
+		for j, c := range raw {
+			z, err := CompressChunk(codec, c)
+			if err != nil {
+				return fmt.Errorf("failed to compress %s chunk %d: %w", files[i].Path, j, err)
+			}
+			if len(z) > fragmentSize {
+				return fmt.Errorf("%s chunk %d: compressed size %d exceeds fragment size %d", files[i].Path, j, len(z), fragmentSize)
+			}
+			chunks = append(chunks, z)
+			content = append(content, z...)
+		}
+		files[i].Chunks = chunks
+		files[i].Content = content
+	}
+	return nil
+}
+
+// AcceptsEncoding reports whether an Accept-Encoding header value allows the codec
+// (ignoring q-values other than an explicit q=0).
+func AcceptsEncoding(acceptEncoding, codec string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name != codec && name != "*" {
+			continue
+		}
+		rejected := false
+		for _, p := range fields[1:] {
+			if q := strings.ReplaceAll(strings.TrimSpace(p), " ", ""); q == "q=0" || q == "q=0.0" || q == "q=0.00" || q == "q=0.000" {
+				rejected = true
+			}
+		}
+		if !rejected {
+			return true
+		}
+	}
+	return false
+}
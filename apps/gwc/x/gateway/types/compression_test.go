@@ -0,0 +1,71 @@
+package types_test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"gwc/x/gateway/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyCompressionRoundTrip(t *testing.T) {
+	text := bytes.Repeat([]byte("<div class=\"card\">hello</div>\n"), 2000)
+	random := make([]byte, 10_000)
+	rand.New(rand.NewSource(7)).Read(random)
+
+	const fragSize = 4096
+	for _, codec := range []string{types.CompressionGzip, types.CompressionZstd} {
+		for _, content := range [][]byte{text, random} {
+			files := []types.ProcessedFile{{Path: "index.html", Content: content}}
+			require.NoError(t, types.ApplyCompression(files, codec, fragSize))
+
+			// one fragment per raw chunk, none larger than fragment_size even for incompressible data
+			chunkSize := types.CompressionChunkSize(fragSize)
+			require.Len(t, files[0].Chunks, (len(content)+chunkSize-1)/chunkSize)
+			var joined []byte
+			for _, c := range files[0].Chunks {
+				require.LessOrEqual(t, len(c), fragSize)
+				joined = append(joined, c...)
+			}
+			require.Equal(t, joined, files[0].Content)
+
+			// every fragment decodes on its own, and the concatenation is one stream of the codec
+			first, err := types.DecompressFileContent(codec, files[0].Chunks[0])
+			require.NoError(t, err)
+			require.Equal(t, content[:chunkSize], first)
+			out, err := types.DecompressFileContent(codec, files[0].Content)
+			require.NoError(t, err)
+			require.Equal(t, content, out)
+		}
+	}
+}
+
+func TestApplyCompressionDeterministic(t *testing.T) {
+	content := bytes.Repeat([]byte("body { color: red; }\n"), 500)
+	for _, codec := range []string{types.CompressionGzip, types.CompressionZstd} {
+		a := []types.ProcessedFile{{Path: "a.css", Content: content}}
+		b := []types.ProcessedFile{{Path: "a.css", Content: content}}
+		require.NoError(t, types.ApplyCompression(a, codec, 1024))
+		require.NoError(t, types.ApplyCompression(b, codec, 1024))
+		require.Equal(t, a[0].Chunks, b[0].Chunks)
+		require.Less(t, len(a[0].Content), len(content))
+	}
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	require.True(t, types.AcceptsEncoding("gzip, deflate, br, zstd", types.CompressionZstd))
+	require.True(t, types.AcceptsEncoding("gzip;q=0.8", types.CompressionGzip))
+	require.True(t, types.AcceptsEncoding("*", types.CompressionGzip))
+	require.False(t, types.AcceptsEncoding("gzip;q=0, br", types.CompressionGzip))
+	require.False(t, types.AcceptsEncoding("br", types.CompressionZstd))
+	require.False(t, types.AcceptsEncoding("", types.CompressionGzip))
+}
+
+func TestValidateCompression(t *testing.T) {
+	require.NoError(t, types.ValidateCompression(""))
+	require.NoError(t, types.ValidateCompression(types.CompressionGzip))
+	require.NoError(t, types.ValidateCompression(types.CompressionZstd))
+	require.Error(t, types.ValidateCompression("brotli"))
+}
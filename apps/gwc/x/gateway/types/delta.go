@@ -0,0 +1,49 @@
+package types
+
+import "fmt"
+
+// StoredFileSize returns the number of fragments and fragment bytes the session stores for a file of
+// fileSize bytes: plain (or encrypted) chunks of fragment_size, or the data + parity shards when erasure coded.
+// For compressed sessions count is the minimum (every fragment full); see ReusedFileSize.
+// Reused files of an incremental deploy count toward expected_fragment_count / expected_total_bytes
+// with these values although they are not distributed again.
+func (s Session) StoredFileSize(fileSize uint64) (count, bytes uint64) {
+	if fileSize == 0 || s.FragmentSize == 0 {
+		return 0, 0
+	}
+	if s.IsErasureCoded() {
+		return ErasureStoredSize(fileSize, s.FragmentSize, int(s.ErasureDataShards), int(s.ErasureParityShards))
+	}
+	return (fileSize + s.FragmentSize - 1) / s.FragmentSize, fileSize
+}
+
+// ReusedFileSize returns the fragments and bytes a reused file counts toward the committed totals.
+// Compressed chunks have variable sizes, so compressed sessions take fragment_count from the entry
+// (bounded by what file_size allows); otherwise it is derived from file_size and must match if given.
+func (s Session) ReusedFileSize(f ReusedFile) (count, bytes uint64, err error) {
+	count, bytes = s.StoredFileSize(f.FileSize)
+	if s.IsCompressed() {
+		if f.FragmentCount < count || f.FragmentCount > f.FileSize {
+			return 0, 0, fmt.Errorf("reused file %s: fragment_count %d is impossible for %d bytes in fragments of <= %d bytes", f.Path, f.FragmentCount, f.FileSize, s.FragmentSize)
+		}
+		return f.FragmentCount, bytes, nil
+	}
+	if f.FragmentCount != 0 && f.FragmentCount != count {
+		return 0, 0, fmt.Errorf("reused file %s: fragment_count %d != %d", f.Path, f.FragmentCount, count)
+	}
+	return count, bytes, nil
+}
+
+// Validate checks the shape of a reused file entry (the proof itself is verified on-chain at finalize).
+func (f ReusedFile) Validate() error {
+	if f.Path == "" {
+		return fmt.Errorf("reused file path cannot be empty")
+	}
+	if f.FileSize == 0 {
+		return fmt.Errorf("reused file %s: file_size must be positive", f.Path)
+	}
+	if !IsContentID(f.FileRoot) {
+		return fmt.Errorf("reused file %s: file_root must be 64 lowercase hex characters", f.Path)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package types_test
+
+import (
+	"strings"
+	"testing"
+
+	"gwc/x/gateway/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionStoredFileSize(t *testing.T) {
+	plain := types.Session{FragmentSize: 100}
+	count, total := plain.StoredFileSize(250)
+	require.Equal(t, uint64(3), count)
+	require.Equal(t, uint64(250), total)
+
+	count, total = plain.StoredFileSize(200)
+	require.Equal(t, uint64(2), count)
+	require.Equal(t, uint64(200), total)
+
+	ec := types.Session{FragmentSize: 100, ErasureDataShards: 3, ErasureParityShards: 2}
+	count, total = ec.StoredFileSize(650)
+	wantCount, wantTotal := types.ErasureStoredSize(650, 100, 3, 2)
+	require.Equal(t, wantCount, count)
+	require.Equal(t, wantTotal, total)
+
+	count, total = plain.StoredFileSize(0)
+	require.Zero(t, count)
+	require.Zero(t, total)
+}
+
+func TestReusedFileValidate(t *testing.T) {
+	root := strings.Repeat("ab", 32)
+	require.NoError(t, types.ReusedFile{Path: "index.html", FileSize: 10, FileRoot: root}.Validate())
+
+	require.Error(t, types.ReusedFile{FileSize: 10, FileRoot: root}.Validate())
+	require.Error(t, types.ReusedFile{Path: "index.html", FileRoot: root}.Validate())
+	require.Error(t, types.ReusedFile{Path: "index.html", FileSize: 10, FileRoot: "abc"}.Validate())
+	require.Error(t, types.ReusedFile{Path: "index.html", FileSize: 10, FileRoot: strings.ToUpper(root)}.Validate())
+}
+
+func TestSessionReusedFileSize(t *testing.T) {
+	root := strings.Repeat("ab", 32)
+
+	plain := types.Session{FragmentSize: 100}
+	count, total, err := plain.ReusedFileSize(types.ReusedFile{Path: "a", FileSize: 250, FileRoot: root})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), count)
+	require.Equal(t, uint64(250), total)
+	_, _, err = plain.ReusedFileSize(types.ReusedFile{Path: "a", FileSize: 250, FileRoot: root, FragmentCount: 4})
+	require.Error(t, err)
+
+	// compressed fragments are at most fragment_size bytes but may be shorter
+	compressed := types.Session{FragmentSize: 100, Compression: types.CompressionGzip}
+	count, total, err = compressed.ReusedFileSize(types.ReusedFile{Path: "a", FileSize: 250, FileRoot: root, FragmentCount: 5})
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), count)
+	require.Equal(t, uint64(250), total)
+	_, _, err = compressed.ReusedFileSize(types.ReusedFile{Path: "a", FileSize: 250, FileRoot: root, FragmentCount: 2})
+	require.Error(t, err)
+	_, _, err = compressed.ReusedFileSize(types.ReusedFile{Path: "a", FileSize: 250, FileRoot: root})
+	require.Error(t, err)
+}
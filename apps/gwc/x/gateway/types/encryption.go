@@ -0,0 +1,267 @@
+package types
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// CipherSuiteAES256GCMChunkV1 encrypts every chunk of a file separately with AES-256-GCM under the project
+// content key. A chunk is stored as nonce(12) || ciphertext || tag(16), so each ciphertext chunk is exactly
+// one fragment (fragment_size bytes, except the file's last one). The additional data binds the chunk to
+// its file, position and the file's chunk count, so chunks cannot be swapped, reordered or truncated.
+const CipherSuiteAES256GCMChunkV1 = "aes-256-gcm-chunk-v1"
+
+const (
+	// ContentKeySize is the length of a project content key (AES-256).
+	ContentKeySize = 32
+	// EncryptionOverhead is the number of bytes a chunk grows by when encrypted (nonce + GCM tag).
+	EncryptionOverhead = 12 + 16
+	// MaxWrappedKeys bounds the reader entries of a session.
+	MaxWrappedKeys = 64
+)
+
+const keyWrapInfo = "csu-content-key-wrap-v1"
+
+// IsEncrypted reports whether the session's files are client-side encrypted.
+func (s Session) IsEncrypted() bool {
+	return s.CipherSuite != ""
+}
+
+// ValidateCipherSuite returns an error for cipher suites this gateway cannot decrypt.
+func ValidateCipherSuite(suite string) error {
+	if suite != "" && suite != CipherSuiteAES256GCMChunkV1 {
+		return fmt.Errorf("unsupported cipher suite %q", suite)
+	}
+	return nil
+}
+
+// PlaintextChunkSize returns the plaintext bytes per chunk that encrypt to fragmentSize ciphertext bytes.
+func PlaintextChunkSize(fragmentSize int) int {
+	return fragmentSize - EncryptionOverhead
+}
+
+// EncryptFileContent encrypts one file chunk by chunk. The result is split by SplitDataIntoFragments
+// (with fragmentSize) into exactly one fragment per encrypted chunk.
+func EncryptFileContent(key []byte, path string, content []byte, fragmentSize int) ([]byte, error) {
+	aead, err := newContentAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	chunkSize := PlaintextChunkSize(fragmentSize)
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("fragment size %d must be larger than the encryption overhead %d", fragmentSize, EncryptionOverhead)
+	}
+	count := (len(content) + chunkSize - 1) / chunkSize
+	out := make([]byte, 0, len(content)+count*EncryptionOverhead)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * chunkSize
+		if end > len(content) {
+			end = len(content)
+		}
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		out = append(out, nonce...)
+		out = aead.Seal(out, nonce, content[i*chunkSize:end], chunkAdditionalData(path, i, count))
+	}
+	return out, nil
+}
+
+// DecryptFileContent reverses EncryptFileContent: ciphertext is the whole stored file (all fragments in order).
+func DecryptFileContent(key []byte, path string, ciphertext []byte, fragmentSize int) ([]byte, error) {
+	aead, err := newContentAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if fragmentSize <= EncryptionOverhead {
+		return nil, fmt.Errorf("fragment size %d must be larger than the encryption overhead %d", fragmentSize, EncryptionOverhead)
+	}
+	count := (len(ciphertext) + fragmentSize - 1) / fragmentSize
+	out := make([]byte, 0, len(ciphertext))
+	for i := 0; i < count; i++ {
+		end := (i + 1) * fragmentSize
+		if end > len(ciphertext) {
+			end = len(ciphertext)
+		}
+		chunk := ciphertext[i*fragmentSize : end]
+		if len(chunk) < EncryptionOverhead {
+			return nil, fmt.Errorf("%s: chunk %d is too short (%d bytes)", path, i, len(chunk))
+		}
+		ns := aead.NonceSize()
+		out, err = aead.Open(out, chunk[:ns], chunk[ns:], chunkAdditionalData(path, i, count))
+		if err != nil {
+			return nil, fmt.Errorf("%s: chunk %d: %w", path, i, err)
+		}
+	}
+	return out, nil
+}
+
+// ValidateEncryptedChunks checks that every chunk is at least as long as the encryption overhead,
+// i.e. the ZIP was produced by EncryptZip with the session's fragment size. The executor cannot
+// authenticate the chunks (it does not hold the key); readers do on decryption.
+func ValidateEncryptedChunks(files []ProcessedFile) error {
+	for _, f := range files {
+		for i, c := range f.Chunks {
+			if len(c) < EncryptionOverhead {
+				return fmt.Errorf("%s: chunk %d (%d bytes) is not an encrypted chunk", f.Path, i, len(c))
+			}
+		}
+	}
+	return nil
+}
+
+// EncryptZip rewrites a ZIP so that every file holds its chunk-wise ciphertext (EncryptFileContent).
+// Paths are normalized as in ProcessZipAndSplit; entries are stored uncompressed since ciphertext does
+// not compress. The owner uploads the result and computes the RootProof over it.
+func EncryptZip(zipData, key []byte, fragmentSize int) ([]byte, error) {
+	chunkSize := PlaintextChunkSize(fragmentSize)
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("fragment size %d must be larger than the encryption overhead %d", fragmentSize, EncryptionOverhead)
+	}
+	files, err := ProcessZipAndSplit(zipData, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		ct, err := EncryptFileContent(key, f.Path, f.Content, fragmentSize)
+		if err != nil {
+			return nil, err
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.Path, Method: zip.Store})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(ct); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WrapContentKey encrypts the content key to a reader's X25519 public key.
+func WrapContentKey(contentKey, readerPublicKey []byte) (WrappedKey, error) {
+	if len(contentKey) != ContentKeySize {
+		return WrappedKey{}, fmt.Errorf("content key must be %d bytes", ContentKeySize)
+	}
+	pub, err := ecdh.X25519().NewPublicKey(readerPublicKey)
+	if err != nil {
+		return WrappedKey{}, fmt.Errorf("invalid reader public key: %w", err)
+	}
+	eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return WrappedKey{}, err
+	}
+	aead, err := keyWrapAEAD(eph, pub, eph.PublicKey().Bytes())
+	if err != nil {
+		return WrappedKey{}, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return WrappedKey{}, err
+	}
+	return WrappedKey{
+		Recipient:          hex.EncodeToString(readerPublicKey),
+		EphemeralPublicKey: eph.PublicKey().Bytes(),
+		WrappedKey:         aead.Seal(nonce, nonce, contentKey, []byte(keyWrapInfo)),
+	}, nil
+}
+
+// UnwrapContentKey recovers the content key from the entry addressed to readerPrivateKey.
+func UnwrapContentKey(wk WrappedKey, readerPrivateKey []byte) ([]byte, error) {
+	priv, err := ecdh.X25519().NewPrivateKey(readerPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reader private key: %w", err)
+	}
+	eph, err := ecdh.X25519().NewPublicKey(wk.EphemeralPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral public key: %w", err)
+	}
+	aead, err := keyWrapAEAD(priv, eph, wk.EphemeralPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(wk.WrappedKey) < aead.NonceSize() {
+		return nil, fmt.Errorf("wrapped key is too short")
+	}
+	ns := aead.NonceSize()
+	return aead.Open(nil, wk.WrappedKey[:ns], wk.WrappedKey[ns:], []byte(keyWrapInfo))
+}
+
+// FindWrappedKey returns the entry addressed to the reader's private key.
+func FindWrappedKey(keys []WrappedKey, readerPrivateKey []byte) (WrappedKey, bool) {
+	pub, err := ReaderPublicKey(readerPrivateKey)
+	if err != nil {
+		return WrappedKey{}, false
+	}
+	for _, wk := range keys {
+		if wk.Recipient == hex.EncodeToString(pub) {
+			return wk, true
+		}
+	}
+	return WrappedKey{}, false
+}
+
+// ReaderPublicKey returns the X25519 public key of a reader private key.
+func ReaderPublicKey(readerPrivateKey []byte) ([]byte, error) {
+	priv, err := ecdh.X25519().NewPrivateKey(readerPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return priv.PublicKey().Bytes(), nil
+}
+
+// Validate checks the shape of a wrapped key entry (the content itself can only be checked by the reader).
+func (wk WrappedKey) Validate() error {
+	if b, err := hex.DecodeString(wk.Recipient); err != nil || len(b) != 32 {
+		return fmt.Errorf("recipient must be a hex X25519 public key")
+	}
+	if len(wk.EphemeralPublicKey) != 32 {
+		return fmt.Errorf("ephemeral_public_key must be 32 bytes")
+	}
+	if len(wk.WrappedKey) != 12+ContentKeySize+16 {
+		return fmt.Errorf("wrapped_key must be %d bytes", 12+ContentKeySize+16)
+	}
+	return nil
+}
+
+func newContentAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != ContentKeySize {
+		return nil, fmt.Errorf("content key must be %d bytes", ContentKeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func keyWrapAEAD(priv *ecdh.PrivateKey, pub *ecdh.PublicKey, ephemeral []byte) (cipher.AEAD, error) {
+	shared, err := priv.ECDH(pub)
+	if err != nil {
+		return nil, err
+	}
+	kek, err := hkdf.Key(sha256.New, shared, ephemeral, keyWrapInfo, ContentKeySize)
+	if err != nil {
+		return nil, err
+	}
+	return newContentAEAD(kek)
+}
+
+func chunkAdditionalData(path string, index, count int) []byte {
+	return []byte(fmt.Sprintf("%s:%s:%d:%d", CipherSuiteAES256GCMChunkV1, path, index, count))
+}
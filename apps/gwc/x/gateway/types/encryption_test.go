@@ -0,0 +1,98 @@
+package types_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"testing"
+
+	"gwc/x/gateway/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptFileContentRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, types.ContentKeySize)
+	content := bytes.Repeat([]byte("private project "), 50) // 800 bytes
+	const fragSize = 100
+
+	ct, err := types.EncryptFileContent(key, "index.html", content, fragSize)
+	require.NoError(t, err)
+
+	// every ciphertext chunk is one fragment: 800 / 72 -> 12 chunks (11 full + 8 bytes)
+	frags, err := types.SplitDataIntoFragments(ct, fragSize)
+	require.NoError(t, err)
+	require.Len(t, frags, 12)
+	require.Len(t, frags[11], 8+types.EncryptionOverhead)
+
+	out, err := types.DecryptFileContent(key, "index.html", ct, fragSize)
+	require.NoError(t, err)
+	require.Equal(t, content, out)
+
+	// wrong key / wrong path / swapped or truncated chunks fail to authenticate
+	_, err = types.DecryptFileContent(bytes.Repeat([]byte{8}, types.ContentKeySize), "index.html", ct, fragSize)
+	require.Error(t, err)
+	_, err = types.DecryptFileContent(key, "other.html", ct, fragSize)
+	require.Error(t, err)
+	swapped := append(append(append([]byte{}, ct[fragSize:2*fragSize]...), ct[:fragSize]...), ct[2*fragSize:]...)
+	_, err = types.DecryptFileContent(key, "index.html", swapped, fragSize)
+	require.Error(t, err)
+	_, err = types.DecryptFileContent(key, "index.html", ct[:len(ct)-len(frags[11])], fragSize)
+	require.Error(t, err)
+}
+
+func TestEncryptZip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("./site/app.js")
+	require.NoError(t, err)
+	_, err = w.Write(bytes.Repeat([]byte("x"), 300))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	key := bytes.Repeat([]byte{1}, types.ContentKeySize)
+	encrypted, err := types.EncryptZip(buf.Bytes(), key, 128)
+	require.NoError(t, err)
+
+	files, err := types.ProcessZipAndSplit(encrypted, 128)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "site/app.js", files[0].Path)
+	require.Len(t, files[0].Chunks, 3) // 300 bytes in 100-byte plaintext chunks
+	require.NoError(t, types.ValidateEncryptedChunks(files))
+
+	out, err := types.DecryptFileContent(key, files[0].Path, files[0].Content, 128)
+	require.NoError(t, err)
+	require.Equal(t, bytes.Repeat([]byte("x"), 300), out)
+
+	// a plaintext ZIP split with a tiny last chunk is rejected
+	files[0].Chunks = append(files[0].Chunks, []byte("tail"))
+	require.Error(t, types.ValidateEncryptedChunks(files))
+}
+
+func TestWrapContentKey(t *testing.T) {
+	reader, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	other, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	contentKey := bytes.Repeat([]byte{9}, types.ContentKeySize)
+
+	wk, err := types.WrapContentKey(contentKey, reader.PublicKey().Bytes())
+	require.NoError(t, err)
+	require.NoError(t, wk.Validate())
+
+	found, ok := types.FindWrappedKey([]types.WrappedKey{wk}, reader.Bytes())
+	require.True(t, ok)
+	got, err := types.UnwrapContentKey(found, reader.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, contentKey, got)
+
+	_, ok = types.FindWrappedKey([]types.WrappedKey{wk}, other.Bytes())
+	require.False(t, ok)
+	_, err = types.UnwrapContentKey(wk, other.Bytes())
+	require.Error(t, err)
+
+	wk.WrappedKey = wk.WrappedKey[1:]
+	require.Error(t, wk.Validate())
+}
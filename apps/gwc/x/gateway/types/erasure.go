@@ -0,0 +1,231 @@
+package types
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ErasureStripe is one group of up to k data chunks of a file followed by its m parity shards.
+//
+// Shards are numbered per file in stripe order: the stripe's data chunks (as produced by
+// SplitDataIntoFragments, unpadded) and then its parity shards. The last stripe of a file may hold
+// fewer than k data chunks; the missing ones are treated as all-zero shards that are never stored.
+type ErasureStripe struct {
+	// FirstIndex is the fragment index of the stripe's first shard.
+	FirstIndex uint64
+	// DataCount is the number of stored data shards (k, or fewer in the file's last stripe).
+	DataCount int
+	// ShardSize is the length of the parity shards (= length of the stripe's first data chunk).
+	ShardSize uint64
+}
+
+// IsErasureCoded reports whether the session stores Reed-Solomon shards instead of plain chunks.
+func (s Session) IsErasureCoded() bool {
+	return s.ErasureDataShards > 0 && s.ErasureParityShards > 0
+}
+
+// ErasureStripes returns the shard layout of a file of fileSize bytes split into fragmentSize chunks.
+func ErasureStripes(fileSize, fragmentSize uint64, dataShards, parityShards int) []ErasureStripe {
+	if fileSize == 0 || fragmentSize == 0 || dataShards <= 0 {
+		return nil
+	}
+	chunks := (fileSize + fragmentSize - 1) / fragmentSize
+	k := uint64(dataShards)
+	var stripes []ErasureStripe
+	var index uint64
+	for first := uint64(0); first < chunks; first += k {
+		d := chunks - first
+		if d > k {
+			d = k
+		}
+		shardSize := fileSize - first*fragmentSize
+		if shardSize > fragmentSize {
+			shardSize = fragmentSize
+		}
+		stripes = append(stripes, ErasureStripe{FirstIndex: index, DataCount: int(d), ShardSize: shardSize})
+		index += d + uint64(parityShards)
+	}
+	return stripes
+}
+
+// ErasureStoredSize returns the number of shards and the total shard bytes stored for a file.
+func ErasureStoredSize(fileSize, fragmentSize uint64, dataShards, parityShards int) (count, bytes uint64) {
+	bytes = fileSize
+	for _, s := range ErasureStripes(fileSize, fragmentSize, dataShards, parityShards) {
+		count += uint64(s.DataCount + parityShards)
+		bytes += uint64(parityShards) * s.ShardSize
+	}
+	return count, bytes
+}
+
+// LocateErasureShard returns the stripe number of fragment index and the shard's position in the stripe
+// (data shards first, then parity). ok is false if the index lies beyond the file's shards.
+func LocateErasureShard(fileSize, fragmentSize uint64, dataShards, parityShards int, index uint64) (stripe, pos int, ok bool) {
+	for s, st := range ErasureStripes(fileSize, fragmentSize, dataShards, parityShards) {
+		if n := uint64(st.DataCount + parityShards); index < st.FirstIndex+n {
+			return s, int(index - st.FirstIndex), true
+		}
+	}
+	return 0, 0, false
+}
+
+// ParityIndexes returns the fragment indexes of the stripe's parity shards.
+func (s ErasureStripe) ParityIndexes(parityShards int) []uint64 {
+	out := make([]uint64, parityShards)
+	for p := range out {
+		out[p] = s.FirstIndex + uint64(s.DataCount+p)
+	}
+	return out
+}
+
+// EncodeErasureShards turns a file's chunks (SplitDataIntoFragments output) into the shard sequence
+// that is committed by BuildCSUProofs and distributed: per stripe the data chunks, then the parity shards.
+func EncodeErasureShards(chunks [][]byte, dataShards, parityShards int) ([][]byte, error) {
+	rs, err := newReedSolomon(dataShards, parityShards)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]byte, 0, len(chunks)+(len(chunks)/dataShards+1)*parityShards)
+	for first := 0; first < len(chunks); first += dataShards {
+		end := first + dataShards
+		if end > len(chunks) {
+			end = len(chunks)
+		}
+		size := len(chunks[first])
+		shards := make([][]byte, dataShards+parityShards)
+		for j := 0; j < dataShards; j++ {
+			shards[j] = make([]byte, size)
+			if first+j < end {
+				if len(chunks[first+j]) > size {
+					return nil, fmt.Errorf("chunk %d is longer than the first chunk of its stripe", first+j)
+				}
+				copy(shards[j], chunks[first+j])
+			}
+		}
+		if err := rs.encode(shards); err != nil {
+			return nil, err
+		}
+		out = append(out, chunks[first:end]...)
+		out = append(out, shards[dataShards:]...)
+	}
+	return out, nil
+}
+
+// ApplyErasureCoding replaces the chunks of every file with its erasure-coded shard sequence.
+// Call it between ProcessZipAndSplit and BuildCSUProofs for erasure-coded sessions.
+func ApplyErasureCoding(files []ProcessedFile, dataShards, parityShards int) error {
+	for i := range files {
+		shards, err := EncodeErasureShards(files[i].Chunks, dataShards, parityShards)
+		if err != nil {
+			return fmt.Errorf("failed to erasure-code %s: %w", files[i].Path, err)
+		}
+		files[i].Chunks = shards
+	}
+	return nil
+}
+
+// ReconstructErasureFile rebuilds a file from its shards. shards[i] is the shard stored at fragment
+// index i, or nil if it could not be fetched. Every stripe needs at least as many shards as it has
+// stored data shards (i.e. any k of its shards, counting the implicit zero shards of a short last stripe).
+func ReconstructErasureFile(shards [][]byte, fileSize, fragmentSize uint64, dataShards, parityShards int) ([]byte, error) {
+	rs, err := newReedSolomon(dataShards, parityShards)
+	if err != nil {
+		return nil, err
+	}
+	stripes := ErasureStripes(fileSize, fragmentSize, dataShards, parityShards)
+	if want, _ := ErasureStoredSize(fileSize, fragmentSize, dataShards, parityShards); uint64(len(shards)) != want {
+		return nil, fmt.Errorf("expected %d shards, got %d", want, len(shards))
+	}
+
+	out := make([]byte, 0, fileSize)
+	for s, stripe := range stripes {
+		work := make([][]byte, dataShards+parityShards)
+		for j := 0; j < dataShards; j++ {
+			if j >= stripe.DataCount {
+				work[j] = make([]byte, stripe.ShardSize)
+				continue
+			}
+			if b := shards[stripe.FirstIndex+uint64(j)]; b != nil {
+				want := chunkLen(fileSize, fragmentSize, uint64(s*dataShards+j))
+				if uint64(len(b)) != want {
+					return nil, fmt.Errorf("stripe %d: data shard %d has length %d, expected %d", s, j, len(b), want)
+				}
+				padded := make([]byte, stripe.ShardSize)
+				copy(padded, b)
+				work[j] = padded
+			}
+		}
+		for p, idx := range stripe.ParityIndexes(parityShards) {
+			if b := shards[idx]; b != nil {
+				if uint64(len(b)) != stripe.ShardSize {
+					return nil, fmt.Errorf("stripe %d: parity shard %d has length %d, expected %d", s, p, len(b), stripe.ShardSize)
+				}
+				work[dataShards+p] = b
+			}
+		}
+		if err := rs.reconstructData(work); err != nil {
+			return nil, fmt.Errorf("stripe %d: %w", s, err)
+		}
+		for j := 0; j < stripe.DataCount; j++ {
+			out = append(out, work[j][:chunkLen(fileSize, fragmentSize, uint64(s*dataShards+j))]...)
+		}
+	}
+	return out, nil
+}
+
+// CollectErasureShards fetches the shards needed to rebuild a file: every data shard first, then the
+// parity shards of the stripes that lost a data shard. fetch is called for up to parallel indexes at
+// once; failed fetches leave a nil entry. The result is meant for ReconstructErasureFile.
+func CollectErasureShards(fileSize, fragmentSize uint64, dataShards, parityShards, parallel int, fetch func(index uint64) ([]byte, error)) [][]byte {
+	stripes := ErasureStripes(fileSize, fragmentSize, dataShards, parityShards)
+	total, _ := ErasureStoredSize(fileSize, fragmentSize, dataShards, parityShards)
+	shards := make([][]byte, total)
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	fetchAll := func(indexes []uint64) {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, parallel)
+		for _, idx := range indexes {
+			wg.Add(1)
+			go func(idx uint64) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				if b, err := fetch(idx); err == nil {
+					shards[idx] = b
+				}
+			}(idx)
+		}
+		wg.Wait()
+	}
+
+	var data []uint64
+	for _, s := range stripes {
+		for j := 0; j < s.DataCount; j++ {
+			data = append(data, s.FirstIndex+uint64(j))
+		}
+	}
+	fetchAll(data)
+
+	var parity []uint64
+	for _, s := range stripes {
+		for j := 0; j < s.DataCount; j++ {
+			if shards[s.FirstIndex+uint64(j)] == nil {
+				parity = append(parity, s.ParityIndexes(parityShards)...)
+				break
+			}
+		}
+	}
+	fetchAll(parity)
+	return shards
+}
+
+func chunkLen(fileSize, fragmentSize, chunk uint64) uint64 {
+	n := fileSize - chunk*fragmentSize
+	if n > fragmentSize {
+		return fragmentSize
+	}
+	return n
+}
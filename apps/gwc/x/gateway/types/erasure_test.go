@@ -0,0 +1,156 @@
+package types_test
+
+import (
+	"bytes"
+	"fmt"
+	"math/bits"
+	"testing"
+
+	"gwc/x/gateway/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErasureStripes(t *testing.T) {
+	// 7 chunks (last one short) with k=3, m=2: stripes of 3, 3 and 1 data chunks
+	stripes := types.ErasureStripes(650, 100, 3, 2)
+	require.Equal(t, []types.ErasureStripe{
+		{FirstIndex: 0, DataCount: 3, ShardSize: 100},
+		{FirstIndex: 5, DataCount: 3, ShardSize: 100},
+		{FirstIndex: 10, DataCount: 1, ShardSize: 50},
+	}, stripes)
+	require.Equal(t, []uint64{11, 12}, stripes[2].ParityIndexes(2))
+
+	count, total := types.ErasureStoredSize(650, 100, 3, 2)
+	require.Equal(t, uint64(7+3*2), count)
+	require.Equal(t, uint64(650+2*100+2*100+2*50), total)
+
+	require.Empty(t, types.ErasureStripes(0, 100, 3, 2))
+}
+
+func TestErasureRoundTrip(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i*7 + i/13)
+	}
+	const fragSize, k, m = 64, 4, 2
+
+	chunks, err := types.SplitDataIntoFragments(data, fragSize)
+	require.NoError(t, err)
+	shards, err := types.EncodeErasureShards(chunks, k, m)
+	require.NoError(t, err)
+
+	count, total := types.ErasureStoredSize(uint64(len(data)), fragSize, k, m)
+	require.Equal(t, int(count), len(shards))
+	var sum uint64
+	for _, s := range shards {
+		sum += uint64(len(s))
+	}
+	require.Equal(t, total, sum)
+
+	// all shards present
+	out, err := types.ReconstructErasureFile(shards, uint64(len(data)), fragSize, k, m)
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(data, out))
+
+	// drop m shards of every stripe (data shards first)
+	for drop := 0; drop < 3; drop++ {
+		t.Run(fmt.Sprintf("drop-%d", drop), func(t *testing.T) {
+			lossy := make([][]byte, len(shards))
+			copy(lossy, shards)
+			for _, s := range types.ErasureStripes(uint64(len(data)), fragSize, k, m) {
+				n := s.DataCount + m
+				for i := 0; i < m; i++ {
+					lossy[s.FirstIndex+uint64((drop+i)%n)] = nil
+				}
+			}
+			out, err := types.ReconstructErasureFile(lossy, uint64(len(data)), fragSize, k, m)
+			require.NoError(t, err)
+			require.True(t, bytes.Equal(data, out))
+		})
+	}
+
+	// m+1 losses in a full stripe cannot be recovered
+	lossy := make([][]byte, len(shards))
+	copy(lossy, shards)
+	lossy[0], lossy[1], lossy[2] = nil, nil, nil
+	_, err = types.ReconstructErasureFile(lossy, uint64(len(data)), fragSize, k, m)
+	require.Error(t, err)
+}
+
+func TestCollectErasureShards(t *testing.T) {
+	data := bytes.Repeat([]byte("cryptomeria"), 40)
+	const fragSize, k, m = 50, 3, 2
+	chunks, err := types.SplitDataIntoFragments(data, fragSize)
+	require.NoError(t, err)
+	shards, err := types.EncodeErasureShards(chunks, k, m)
+	require.NoError(t, err)
+
+	// shard 1 (data of the first stripe) is unreachable: only that stripe's parity is fetched
+	var fetched []uint64
+	collected := types.CollectErasureShards(uint64(len(data)), fragSize, k, m, 1, func(index uint64) ([]byte, error) {
+		fetched = append(fetched, index)
+		if index == 1 {
+			return nil, fmt.Errorf("unreachable")
+		}
+		return shards[index], nil
+	})
+	require.Contains(t, fetched, uint64(3))
+	require.Contains(t, fetched, uint64(4))
+	require.NotContains(t, fetched, uint64(8))
+
+	out, err := types.ReconstructErasureFile(collected, uint64(len(data)), fragSize, k, m)
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(data, out))
+}
+
+// TestErasureReconstructEveryShardSubset rebuilds a file from every subset of exactly the needed number of shards
+// of each stripe (and checks that one shard fewer is rejected), for a full stripe and a short last stripe.
+func TestErasureReconstructEveryShardSubset(t *testing.T) {
+	configs := []struct{ k, m int }{
+		{1, 1}, {1, 3}, {2, 1}, {2, 2}, {3, 2}, {4, 2}, {4, 4}, {5, 3}, {6, 3}, {8, 4}, {10, 4},
+	}
+	const fragSize = 16
+	for _, c := range configs {
+		t.Run(fmt.Sprintf("%d+%d", c.k, c.m), func(t *testing.T) {
+			// a full stripe and a short last stripe whose last chunk is short as well
+			short := c.k - 1
+			if short == 0 {
+				short = 1
+			}
+			data := make([]byte, fragSize*(c.k+short)-5)
+			for i := range data {
+				data[i] = byte(i*31 + i/7 + c.k*c.m)
+			}
+			fileSize := uint64(len(data))
+			chunks, err := types.SplitDataIntoFragments(data, fragSize)
+			require.NoError(t, err)
+			shards, err := types.EncodeErasureShards(chunks, c.k, c.m)
+			require.NoError(t, err)
+
+			for s, stripe := range types.ErasureStripes(fileSize, fragSize, c.k, c.m) {
+				stored := stripe.DataCount + c.m
+				for mask := 0; mask < 1<<stored; mask++ {
+					kept := bits.OnesCount(uint(mask))
+					if kept != stripe.DataCount && kept != stripe.DataCount-1 {
+						continue
+					}
+					lossy := make([][]byte, len(shards))
+					copy(lossy, shards)
+					for j := 0; j < stored; j++ {
+						if mask&(1<<j) == 0 {
+							lossy[stripe.FirstIndex+uint64(j)] = nil
+						}
+					}
+					out, err := types.ReconstructErasureFile(lossy, fileSize, fragSize, c.k, c.m)
+					if kept < stripe.DataCount {
+						require.Error(t, err, "stripe %d mask %b", s, mask)
+						continue
+					}
+					require.NoError(t, err, "stripe %d mask %b", s, mask)
+					require.True(t, bytes.Equal(data, out), "stripe %d mask %b", s, mask)
+				}
+			}
+		})
+	}
+}
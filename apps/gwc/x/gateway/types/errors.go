@@ -36,6 +36,48 @@ var (
 	// authz session-bound checks (Issue8)
 	ErrAuthzMissingOrInvalid = errors.Register(ModuleName, 1116, "authz missing or invalid")
 
+	// session id allocation
+	ErrSessionAlreadyExists = errors.Register(ModuleName, 1117, "session already exists")
+
+	// fragment redistribution
+	ErrFragmentNotRedistributable = errors.Register(ModuleName, 1118, "fragment is not redistributable")
+	ErrFragmentRetriesExceeded    = errors.Register(ModuleName, 1119, "fragment retries exceeded")
+
+	// finalize completeness
+	ErrSessionIncomplete = errors.Register(ModuleName, 1120, "session fragments incomplete")
+
+	// per-owner quotas / rate limits
+	ErrOwnerQuotaExceeded = errors.Register(ModuleName, 1121, "owner quota exceeded")
+	ErrOwnerRateLimited   = errors.Register(ModuleName, 1122, "owner rate limited")
+
+	// storage deposit
+	ErrDepositInsufficient = errors.Register(ModuleName, 1123, "storage deposit insufficient")
+
+	// executor registry
+	ErrExecutorNotActive = errors.Register(ModuleName, 1124, "executor not active")
+
+	// session lifetime (owner cancel / deadline extension)
+	ErrOwnerMismatch   = errors.Register(ModuleName, 1125, "owner mismatch")
+	ErrInvalidDeadline = errors.Register(ModuleName, 1126, "invalid session deadline")
+
+	// fragment replication
+	ErrInvalidReplicationFactor = errors.Register(ModuleName, 1127, "invalid replication factor")
+
+	// erasure coding
+	ErrInvalidErasureCoding = errors.Register(ModuleName, 1128, "invalid erasure coding")
+
+	// client-side encryption
+	ErrInvalidEncryption = errors.Register(ModuleName, 1129, "invalid encryption parameters")
+
+	// content-addressed dedup
+	ErrInvalidFragmentReference = errors.Register(ModuleName, 1130, "invalid fragment reference")
+
+	// incremental deploy
+	ErrInvalidReusedFile = errors.Register(ModuleName, 1131, "invalid reused file")
+
+	// fragment compression
+	ErrInvalidCompression = errors.Register(ModuleName, 1132, "invalid compression")
+
 	ErrInvalidPacketTimeout = errors.Register(ModuleName, 1500, "invalid packet timeout")
 	ErrInvalidVersion       = errors.Register(ModuleName, 1501, "invalid version")
 )
@@ -14,6 +14,9 @@ import (
 type AccountKeeper interface {
 	// GetAccount は指定されたアドレスのアカウント情報を取得します。
 	GetAccount(context.Context, sdk.AccAddress) sdk.AccountI
+
+	// GetModuleAddress はモジュールアカウント（エスクロー・報酬プール）のアドレスを返します。
+	GetModuleAddress(moduleName string) sdk.AccAddress
 }
 
 // AuthKeeper defines the expected interface for the Auth module.
@@ -26,6 +29,12 @@ type AuthKeeper interface {
 // BankKeeper defines the expected interface for the Bank module.
 type BankKeeper interface {
 	SpendableCoins(context.Context, sdk.AccAddress) sdk.Coins
+	GetAllBalances(ctx context.Context, addr sdk.AccAddress) sdk.Coins
+
+	// storage deposit escrow
+	SendCoinsFromAccountToModule(ctx context.Context, senderAddr sdk.AccAddress, recipientModule string, amt sdk.Coins) error
+	SendCoinsFromModuleToAccount(ctx context.Context, senderModule string, recipientAddr sdk.AccAddress, amt sdk.Coins) error
+	SendCoinsFromModuleToModule(ctx context.Context, senderModule, recipientModule string, amt sdk.Coins) error
 	// Methods imported from bank should be defined here
 }
 
@@ -38,6 +47,9 @@ type AuthzKeeper interface {
 	// DeleteGrant revokes a single msgTypeURL grant.
 	// NOTE: SDK v0.50+ renamed Revoke to DeleteGrant.
 	DeleteGrant(ctx context.Context, granter sdk.AccAddress, grantee sdk.AccAddress, msgTypeURL string) error
+
+	// SaveGrant stores (overwrites) a grant; used to write back consumed SessionBoundAuthorization budgets.
+	SaveGrant(ctx context.Context, grantee sdk.AccAddress, granter sdk.AccAddress, authorization authz.Authorization, expiration *time.Time) error
 }
 
 // FeegrantKeeper defines the expected interface for the Feegrant module (Issue7).
@@ -0,0 +1,42 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// MakeFragmentID derives the FDSC fragment_id of a CSU fragment.
+// MUST stay identical to fdsc/x/datastore/types.MakeFragmentID:
+//
+//	fragment_id = hex( sha256( "FDSC_FRAG_ID:{session_id}:{path}:{index_decimal}" ) )
+func MakeFragmentID(sessionID, path string, index uint64) string {
+	payload := []byte(fmt.Sprintf("FDSC_FRAG_ID:%s:%s:%d", sessionID, path, index))
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// MaxFragmentContentsQuery bounds the content ids of one FragmentContents query.
+const MaxFragmentContentsQuery = 1000
+
+// MakeContentID returns the content address of fragment bytes.
+// MUST stay identical to fdsc/x/datastore/types.MakeContentID:
+//
+//	content_id = hex( sha256( data ) )
+func MakeContentID(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// IsContentID reports whether s has the shape of a content id (64 lowercase hex characters).
+func IsContentID(s string) bool {
+	if len(s) != 2*sha256.Size {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
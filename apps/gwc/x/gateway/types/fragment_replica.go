@@ -0,0 +1,78 @@
+package types
+
+// ReplicaCount returns how many copies of each fragment the session stores (replication_factor, at least 1).
+func (s Session) ReplicaCount() int {
+	if s.ReplicationFactor == 0 {
+		return 1
+	}
+	return int(s.ReplicationFactor)
+}
+
+// ReplicaSet returns the per-replica delivery state of the fragment.
+// Records written before replication existed carry no replicas; their top-level
+// channel / sequence / status are returned as the single replica.
+func (d FragmentDelivery) ReplicaSet() []FragmentReplica {
+	if len(d.Replicas) > 0 {
+		return d.Replicas
+	}
+	if d.ChannelId == "" {
+		return nil
+	}
+	return []FragmentReplica{{
+		ChannelId:      d.ChannelId,
+		PacketSequence: d.PacketSequence,
+		Status:         d.Status,
+		Error:          d.Error,
+		ChainId:        d.ChainId,
+		Attempts:       d.Attempts,
+	}}
+}
+
+// FindReplica returns the position of the replica that was sent as (channelID, seq), or -1.
+func (d FragmentDelivery) FindReplica(channelID string, seq uint64) int {
+	for i, r := range d.ReplicaSet() {
+		if r.ChannelId == channelID && r.PacketSequence == seq {
+			return i
+		}
+	}
+	return -1
+}
+
+// SyncReplicas recomputes the aggregate status / error and the replicas[0] mirror fields from Replicas.
+//
+// A failed replica (error ack or timeout) makes the whole fragment redistributable even while other
+// replicas still await their ack; the fragment counts as ACKED only once every replica is acked.
+func (d *FragmentDelivery) SyncReplicas() {
+	if len(d.Replicas) == 0 {
+		return
+	}
+	status := FragmentStatus_FRAGMENT_STATUS_ACKED
+	errMsg := ""
+	pending := false
+	for _, r := range d.Replicas {
+		switch r.Status {
+		case FragmentStatus_FRAGMENT_STATUS_ACKED:
+		case FragmentStatus_FRAGMENT_STATUS_ERROR:
+			if status != FragmentStatus_FRAGMENT_STATUS_ERROR {
+				status = FragmentStatus_FRAGMENT_STATUS_ERROR
+				errMsg = r.Error
+			}
+		case FragmentStatus_FRAGMENT_STATUS_TIMED_OUT:
+			if status != FragmentStatus_FRAGMENT_STATUS_ERROR {
+				status = FragmentStatus_FRAGMENT_STATUS_TIMED_OUT
+			}
+		default:
+			pending = true
+		}
+	}
+	if status == FragmentStatus_FRAGMENT_STATUS_ACKED && pending {
+		status = FragmentStatus_FRAGMENT_STATUS_PENDING
+	}
+	d.Status = status
+	d.Error = errMsg
+
+	primary := d.Replicas[0]
+	d.ChannelId = primary.ChannelId
+	d.PacketSequence = primary.PacketSequence
+	d.ChainId = primary.ChainId
+}
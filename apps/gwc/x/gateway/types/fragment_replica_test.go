@@ -0,0 +1,62 @@
+package types_test
+
+import (
+	"testing"
+
+	"gwc/x/gateway/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFragmentDeliverySyncReplicas(t *testing.T) {
+	rec := types.FragmentDelivery{
+		Replicas: []types.FragmentReplica{
+			{ChannelId: "channel-1", PacketSequence: 7, Status: types.FragmentStatus_FRAGMENT_STATUS_ACKED, ChainId: "fdsc-1"},
+			{ChannelId: "channel-2", PacketSequence: 3, Status: types.FragmentStatus_FRAGMENT_STATUS_PENDING},
+		},
+	}
+
+	// one replica still in flight
+	rec.SyncReplicas()
+	require.Equal(t, types.FragmentStatus_FRAGMENT_STATUS_PENDING, rec.Status)
+	require.Equal(t, "channel-1", rec.ChannelId)
+	require.Equal(t, uint64(7), rec.PacketSequence)
+	require.Equal(t, "fdsc-1", rec.ChainId)
+
+	// every replica acked
+	rec.Replicas[1].Status = types.FragmentStatus_FRAGMENT_STATUS_ACKED
+	rec.SyncReplicas()
+	require.Equal(t, types.FragmentStatus_FRAGMENT_STATUS_ACKED, rec.Status)
+
+	// a failed replica wins over acked / pending ones; error beats timeout
+	rec.Replicas[1].Status = types.FragmentStatus_FRAGMENT_STATUS_TIMED_OUT
+	rec.SyncReplicas()
+	require.Equal(t, types.FragmentStatus_FRAGMENT_STATUS_TIMED_OUT, rec.Status)
+
+	rec.Replicas[0] = types.FragmentReplica{ChannelId: "channel-1", PacketSequence: 8, Status: types.FragmentStatus_FRAGMENT_STATUS_ERROR, Error: "disk full"}
+	rec.SyncReplicas()
+	require.Equal(t, types.FragmentStatus_FRAGMENT_STATUS_ERROR, rec.Status)
+	require.Equal(t, "disk full", rec.Error)
+
+	require.Equal(t, 1, rec.FindReplica("channel-2", 3))
+	require.Equal(t, -1, rec.FindReplica("channel-2", 4))
+}
+
+func TestFragmentDeliveryLegacyReplicaSet(t *testing.T) {
+	// records written before replication carry their single copy in the top-level fields
+	legacy := types.FragmentDelivery{
+		ChannelId:      "channel-3",
+		PacketSequence: 11,
+		Status:         types.FragmentStatus_FRAGMENT_STATUS_PENDING,
+		Attempts:       2,
+	}
+	set := legacy.ReplicaSet()
+	require.Len(t, set, 1)
+	require.Equal(t, "channel-3", set[0].ChannelId)
+	require.Equal(t, uint32(2), set[0].Attempts)
+	require.Equal(t, 0, legacy.FindReplica("channel-3", 11))
+
+	require.Empty(t, types.FragmentDelivery{}.ReplicaSet())
+	require.Equal(t, 1, types.Session{}.ReplicaCount())
+	require.Equal(t, 3, types.Session{ReplicationFactor: 3}.ReplicaCount())
+}
@@ -1,6 +1,10 @@
 package types
 
-import host "github.com/cosmos/ibc-go/v10/modules/core/24-host"
+import (
+	"fmt"
+
+	host "github.com/cosmos/ibc-go/v10/modules/core/24-host"
+)
 
 // DefaultGenesis returns the default genesis state
 func DefaultGenesis() *GenesisState {
@@ -15,6 +19,236 @@ func (gs GenesisState) Validate() error {
 	if err := host.PortIdentifierValidator(gs.PortId); err != nil {
 		return err
 	}
+	if err := gs.validateStorage(); err != nil {
+		return err
+	}
+	if err := gs.validateSessions(); err != nil {
+		return err
+	}
+	if err := gs.validateOwnerQuotas(); err != nil {
+		return err
+	}
+
+	executors := make(map[string]struct{})
+	for _, e := range gs.Executors {
+		if e.Address == "" {
+			return fmt.Errorf("executor with empty address")
+		}
+		if _, ok := executors[e.Address]; ok {
+			return fmt.Errorf("duplicated executor %s", e.Address)
+		}
+		executors[e.Address] = struct{}{}
+	}
 
 	return gs.Params.Validate()
 }
+
+func (gs GenesisState) validateStorage() error {
+	if gs.MetastoreChannel != "" {
+		if err := host.ChannelIdentifierValidator(gs.MetastoreChannel); err != nil {
+			return fmt.Errorf("invalid metastore_channel: %w", err)
+		}
+	}
+
+	datastore := make(map[string]struct{})
+	for _, ch := range gs.DatastoreChannels {
+		if err := host.ChannelIdentifierValidator(ch); err != nil {
+			return fmt.Errorf("invalid datastore channel %q: %w", ch, err)
+		}
+		if _, ok := datastore[ch]; ok {
+			return fmt.Errorf("duplicated datastore channel %s", ch)
+		}
+		if ch == gs.MetastoreChannel {
+			return fmt.Errorf("channel %s is registered as both metastore and datastore", ch)
+		}
+		datastore[ch] = struct{}{}
+	}
+
+	infos := make(map[string]struct{})
+	for _, info := range gs.StorageInfos {
+		if err := host.ChannelIdentifierValidator(info.ChannelId); err != nil {
+			return fmt.Errorf("invalid storage info channel %q: %w", info.ChannelId, err)
+		}
+		if _, ok := infos[info.ChannelId]; ok {
+			return fmt.Errorf("duplicated storage info for channel %s", info.ChannelId)
+		}
+		infos[info.ChannelId] = struct{}{}
+	}
+
+	health := make(map[string]struct{})
+	for _, h := range gs.ChannelHealth {
+		if err := host.ChannelIdentifierValidator(h.ChannelId); err != nil {
+			return fmt.Errorf("invalid channel health channel %q: %w", h.ChannelId, err)
+		}
+		if _, ok := health[h.ChannelId]; ok {
+			return fmt.Errorf("duplicated channel health for channel %s", h.ChannelId)
+		}
+		if h.LastSentSeq > gs.ChannelSendSeq {
+			return fmt.Errorf("channel %s last_sent_seq %d exceeds channel_send_seq %d", h.ChannelId, h.LastSentSeq, gs.ChannelSendSeq)
+		}
+		health[h.ChannelId] = struct{}{}
+	}
+
+	locations := make(map[string]struct{})
+	for _, l := range gs.ContentIndex {
+		if !IsContentID(l.ContentId) {
+			return fmt.Errorf("invalid content index content_id %q", l.ContentId)
+		}
+		if err := host.ChannelIdentifierValidator(l.ChannelId); err != nil {
+			return fmt.Errorf("invalid content index channel %q: %w", l.ChannelId, err)
+		}
+		key := l.ContentId + "/" + l.ChannelId
+		if _, ok := locations[key]; ok {
+			return fmt.Errorf("duplicated content index entry %s", key)
+		}
+		locations[key] = struct{}{}
+	}
+	return nil
+}
+
+func (gs GenesisState) validateSessions() error {
+	sessions := make(map[string]struct{})
+	for _, s := range gs.Sessions {
+		if s.SessionId == "" {
+			return fmt.Errorf("session with empty session_id")
+		}
+		if _, ok := sessions[s.SessionId]; ok {
+			return fmt.Errorf("duplicated session %s", s.SessionId)
+		}
+		if s.Owner == "" {
+			return fmt.Errorf("session %s has empty owner", s.SessionId)
+		}
+		if _, ok := SessionState_name[int32(s.State)]; !ok || s.State == SessionState_SESSION_STATE_UNSPECIFIED {
+			return fmt.Errorf("session %s has invalid state %d", s.SessionId, s.State)
+		}
+		sessions[s.SessionId] = struct{}{}
+	}
+	requireSession := func(kind, sessionID string) error {
+		if _, ok := sessions[sessionID]; !ok {
+			return fmt.Errorf("%s references unknown session %q", kind, sessionID)
+		}
+		return nil
+	}
+
+	type fragKey struct {
+		sessionID string
+		path      string
+		index     uint64
+	}
+
+	deliveries := make(map[fragKey]struct{})
+	for _, d := range gs.FragmentDeliveries {
+		if err := requireSession("fragment delivery", d.SessionId); err != nil {
+			return err
+		}
+		k := fragKey{d.SessionId, d.Path, d.Index}
+		if _, ok := deliveries[k]; ok {
+			return fmt.Errorf("duplicated fragment delivery %s/%s/%d", d.SessionId, d.Path, d.Index)
+		}
+		deliveries[k] = struct{}{}
+		replicaChannels := make(map[string]struct{}, len(d.Replicas))
+		for _, r := range d.Replicas {
+			if _, ok := replicaChannels[r.ChannelId]; ok {
+				return fmt.Errorf("fragment delivery %s/%s/%d has two replicas on channel %s", d.SessionId, d.Path, d.Index, r.ChannelId)
+			}
+			replicaChannels[r.ChannelId] = struct{}{}
+		}
+	}
+
+	files := make(map[fragKey]struct{})
+	for _, f := range gs.SessionFiles {
+		if err := requireSession("session file", f.SessionId); err != nil {
+			return err
+		}
+		k := fragKey{sessionID: f.SessionId, path: f.Path}
+		if _, ok := files[k]; ok {
+			return fmt.Errorf("duplicated session file %s/%s", f.SessionId, f.Path)
+		}
+		files[k] = struct{}{}
+	}
+
+	seen := make(map[fragKey]struct{})
+	for _, f := range gs.SeenFragments {
+		if err := requireSession("seen fragment", f.SessionId); err != nil {
+			return err
+		}
+		k := fragKey{f.SessionId, f.Path, f.Index}
+		if _, ok := seen[k]; ok {
+			return fmt.Errorf("duplicated seen fragment %s/%s/%d", f.SessionId, f.Path, f.Index)
+		}
+		seen[k] = struct{}{}
+	}
+
+	type channelSeq struct {
+		channelID string
+		sequence  uint64
+	}
+	fragSeqs := make(map[channelSeq]struct{})
+	for _, b := range gs.FragmentSeqBindings {
+		if err := host.ChannelIdentifierValidator(b.ChannelId); err != nil {
+			return fmt.Errorf("invalid fragment seq binding channel %q: %w", b.ChannelId, err)
+		}
+		if err := requireSession("fragment seq binding", b.Fragment.SessionId); err != nil {
+			return err
+		}
+		k := channelSeq{b.ChannelId, b.Sequence}
+		if _, ok := fragSeqs[k]; ok {
+			return fmt.Errorf("duplicated fragment seq binding %s/%d", b.ChannelId, b.Sequence)
+		}
+		fragSeqs[k] = struct{}{}
+	}
+
+	manifestSeqs := make(map[uint64]struct{})
+	for _, b := range gs.ManifestSeqBindings {
+		if err := requireSession("manifest seq binding", b.SessionId); err != nil {
+			return err
+		}
+		if _, ok := manifestSeqs[b.Sequence]; ok {
+			return fmt.Errorf("duplicated manifest seq binding %d", b.Sequence)
+		}
+		manifestSeqs[b.Sequence] = struct{}{}
+	}
+
+	tokens := make(map[string]struct{})
+	for _, t := range gs.UploadTokenHashes {
+		if err := requireSession("upload token hash", t.SessionId); err != nil {
+			return err
+		}
+		if _, ok := tokens[t.SessionId]; ok {
+			return fmt.Errorf("duplicated upload token hash for session %s", t.SessionId)
+		}
+		tokens[t.SessionId] = struct{}{}
+	}
+	return nil
+}
+
+func (gs GenesisState) validateOwnerQuotas() error {
+	type usageKey struct {
+		owner     string
+		unix      int64
+		sessionID string
+	}
+	usage := make(map[usageKey]struct{})
+	for _, e := range gs.OwnerUsageEntries {
+		if e.Owner == "" {
+			return fmt.Errorf("owner usage entry with empty owner")
+		}
+		k := usageKey{e.Owner, e.CommittedUnix, e.SessionId}
+		if _, ok := usage[k]; ok {
+			return fmt.Errorf("duplicated owner usage entry %s/%d/%s", e.Owner, e.CommittedUnix, e.SessionId)
+		}
+		usage[k] = struct{}{}
+	}
+
+	lastInits := make(map[string]struct{})
+	for _, l := range gs.OwnerLastInits {
+		if l.Owner == "" {
+			return fmt.Errorf("owner last init with empty owner")
+		}
+		if _, ok := lastInits[l.Owner]; ok {
+			return fmt.Errorf("duplicated owner last init %s", l.Owner)
+		}
+		lastInits[l.Owner] = struct{}{}
+	}
+	return nil
+}
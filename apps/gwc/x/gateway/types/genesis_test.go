@@ -0,0 +1,131 @@
+package types_test
+
+import (
+	"testing"
+
+	"gwc/x/gateway/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenesisState_Validate(t *testing.T) {
+	session := types.Session{
+		SessionId: "cosmos1owner-1",
+		Owner:     "cosmos1owner",
+		State:     types.SessionState_SESSION_STATE_DISTRIBUTING,
+	}
+	withDefaults := func(gs types.GenesisState) *types.GenesisState {
+		gs.PortId = types.PortID
+		gs.Params = types.DefaultParams()
+		return &gs
+	}
+
+	tests := []struct {
+		desc     string
+		genState *types.GenesisState
+		valid    bool
+	}{
+		{
+			desc:     "default is valid",
+			genState: types.DefaultGenesis(),
+			valid:    true,
+		},
+		{
+			desc: "valid genesis state",
+			genState: withDefaults(types.GenesisState{
+				StorageInfos:      []types.StorageInfo{{ChannelId: "channel-0", ConnectionType: "mdsc"}, {ChannelId: "channel-1", ConnectionType: "fdsc"}},
+				DatastoreChannels: []string{"channel-1"},
+				MetastoreChannel:  "channel-0",
+				Sessions:          []types.Session{session},
+				SessionSeq:        2,
+				FragmentDeliveries: []types.FragmentDelivery{{
+					SessionId: session.SessionId, Path: "index.html", Index: 0,
+					Status: types.FragmentStatus_FRAGMENT_STATUS_PENDING, ChannelId: "channel-1", PacketSequence: 4,
+				}},
+				SessionFiles:  []types.SessionFile{{SessionId: session.SessionId, Path: "index.html", FileSize: 10}},
+				SeenFragments: []types.FragmentRef{{SessionId: session.SessionId, Path: "index.html", Index: 0}},
+				FragmentSeqBindings: []types.FragmentSeqBinding{{
+					ChannelId: "channel-1", Sequence: 4,
+					Fragment: types.FragmentRef{SessionId: session.SessionId, Path: "index.html", Index: 0},
+				}},
+				UploadTokenHashes: []types.UploadTokenHash{{SessionId: session.SessionId, TokenHash: []byte{1, 2, 3}}},
+				ContentIndex:      []types.ContentLocation{{ContentId: types.MakeContentID([]byte("x")), ChannelId: "channel-1", Size: 1}},
+			}),
+			valid: true,
+		},
+		{
+			desc: "channel is both metastore and datastore",
+			genState: withDefaults(types.GenesisState{
+				DatastoreChannels: []string{"channel-0"},
+				MetastoreChannel:  "channel-0",
+			}),
+			valid: false,
+		},
+		{
+			desc: "duplicated session",
+			genState: withDefaults(types.GenesisState{
+				Sessions: []types.Session{session, session},
+			}),
+			valid: false,
+		},
+		{
+			desc: "binding references unknown session",
+			genState: withDefaults(types.GenesisState{
+				ManifestSeqBindings: []types.ManifestSeqBinding{{Sequence: 1, SessionId: "missing"}},
+			}),
+			valid: false,
+		},
+		{
+			desc: "duplicated fragment seq binding",
+			genState: withDefaults(types.GenesisState{
+				Sessions: []types.Session{session},
+				FragmentSeqBindings: []types.FragmentSeqBinding{
+					{ChannelId: "channel-1", Sequence: 4, Fragment: types.FragmentRef{SessionId: session.SessionId, Path: "a", Index: 0}},
+					{ChannelId: "channel-1", Sequence: 4, Fragment: types.FragmentRef{SessionId: session.SessionId, Path: "a", Index: 1}},
+				},
+			}),
+			valid: false,
+		},
+		{
+			desc: "two replicas on one channel",
+			genState: withDefaults(types.GenesisState{
+				Sessions: []types.Session{session},
+				FragmentDeliveries: []types.FragmentDelivery{{
+					SessionId: session.SessionId, Path: "a", Index: 0,
+					Replicas: []types.FragmentReplica{
+						{ChannelId: "channel-1", PacketSequence: 4},
+						{ChannelId: "channel-1", PacketSequence: 5},
+					},
+				}},
+			}),
+			valid: false,
+		},
+		{
+			desc: "duplicated content index entry",
+			genState: withDefaults(types.GenesisState{
+				ContentIndex: []types.ContentLocation{
+					{ContentId: types.MakeContentID([]byte("x")), ChannelId: "channel-1", Size: 1},
+					{ContentId: types.MakeContentID([]byte("x")), ChannelId: "channel-1", Size: 1},
+				},
+			}),
+			valid: false,
+		},
+		{
+			desc: "content index entry without a content id",
+			genState: withDefaults(types.GenesisState{
+				ContentIndex: []types.ContentLocation{{ContentId: "index.html", ChannelId: "channel-1", Size: 1}},
+			}),
+			valid: false,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := tc.genState.Validate()
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
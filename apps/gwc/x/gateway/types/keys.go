@@ -17,6 +17,10 @@ const (
 
 	// PortID is the default port id that module binds to
 	PortID = "gateway"
+
+	// StorageRewardPoolName is the module account receiving the deposits of successfully finalized sessions.
+	// Deposits are escrowed in the gateway module account (ModuleName) until the session closes.
+	StorageRewardPoolName = "gateway_storage_rewards"
 )
 
 var (
@@ -38,6 +42,7 @@ var (
 	StorageEndpointKey = collections.NewPrefix("storage_endpoint")
 
 	// --- CSU Session management ---
+	// NOTE: プレフィックスは互いに前方一致してはいけません（SessionKey の "session" と衝突しないよう補助ストアは "sess_" を使います）。
 
 	// SessionKey: セッション本体 (Key: session_id, Value: types.Session)
 	SessionKey = collections.NewPrefix("session")
@@ -45,8 +50,9 @@ var (
 	// SessionFragmentSeenKey: (session_id, path, index) の重複防止 (Key: frag_key, Value: empty)
 	SessionFragmentSeenKey = collections.NewPrefix("sess_frag_seen")
 
-	// FragmentSeqToFragmentKey: FDSCへ送った FragmentPacket の IBC sequence -> frag_key
-	// Key: seq_key (zero-padded decimal string), Value: frag_key
+	// FragmentSeqToFragmentKey: FDSCへ送った FragmentPacket の IBC (channel, sequence) -> frag_key
+	// Key: channel_seq_key (channel_id + "/" + zero-padded decimal), Value: frag_key
+	// NOTE: IBC sequence はチャネルごとに独立しているため channel_id を含めます。
 	FragmentSeqToFragmentKey = collections.NewPrefix("seq_frag")
 
 	// ManifestSeqToSessionKey: MDSCへ送った ManifestPacket の IBC sequence -> session_id
@@ -56,4 +62,51 @@ var (
 	// SessionUploadTokenHashKey: off-chain upload token の hash を保存（平文保存しない）
 	// Key: session_id, Value: sha256(token) など
 	SessionUploadTokenHashKey = collections.NewPrefix("sess_upload_token_hash")
+
+	// SessionSeqKey: session_id 採番用の単調増加シーケンス
+	SessionSeqKey = collections.NewPrefix("sess_seq")
+
+	// FragmentDeliveryKey: 断片ごとの配送記録 (Key: (session_id, path, index), Value: types.FragmentDelivery)
+	FragmentDeliveryKey = collections.NewPrefix("frag_delivery")
+
+	// SessionFileKey: DistributeBatch で証明されたファイル情報 (Key: (session_id, path), Value: types.SessionFile)
+	SessionFileKey = collections.NewPrefix("sess_file")
+
+	// SessionExpiryQueueKey: 未クローズセッションの期限順インデックス (Key: (deadline_unix, session_id), Value: empty)
+	SessionExpiryQueueKey = collections.NewPrefix("sess_expiry")
+
+	// SessionPruneQueueKey: クローズ済み・未アーカイブセッションのクローズ時刻順インデックス (Key: (closed_unix, session_id), Value: empty)
+	SessionPruneQueueKey = collections.NewPrefix("sess_prune")
+
+	// SessionOwnerIndexKey: 所有者別セッションインデックス (Key: (owner, session_id), Value: empty)
+	SessionOwnerIndexKey = collections.NewPrefix("sess_owner")
+
+	// SessionStateIndexKey: 状態別セッションインデックス (Key: (state, session_id), Value: empty)
+	SessionStateIndexKey = collections.NewPrefix("sess_state")
+
+	// DepositSettleQueueKey: デポジット精算待ち（クローズ済みで LOCKED のまま）のセッション (Key: session_id, Value: empty)
+	DepositSettleQueueKey = collections.NewPrefix("sess_settle")
+
+	// --- Per-owner quotas ---
+
+	// OwnerOpenSessionsKey: 所有者ごとの未クローズセッション数 (Key: owner, Value: uint64)
+	OwnerOpenSessionsKey = collections.NewPrefix("owner_open")
+
+	// OwnerLastInitKey: 所有者ごとの最終 InitSession 時刻 (Key: owner, Value: unix)
+	OwnerLastInitKey = collections.NewPrefix("owner_last_init")
+
+	// OwnerUsageKey: 所有者ごとの確定済み使用量ログ (Key: (owner, committed_unix, session_id), Value: types.OwnerUsageEntry)
+	OwnerUsageKey = collections.NewPrefix("owner_usage")
+
+	// ExecutorKey: 承認済み executor のレジストリ (Key: address, Value: types.Executor)
+	ExecutorKey = collections.NewPrefix("executor")
+
+	// ChannelHealthKey: FDSC チャネルごとの配送ヘルス (Key: channel_id, Value: types.ChannelHealth)
+	ChannelHealthKey = collections.NewPrefix("channel_health")
+
+	// ChannelSendSeqKey: FragmentPacket 送信ごとに進むカウンタ (LRU 配置用)
+	ChannelSendSeqKey = collections.NewPrefix("channel_send_seq")
+
+	// ContentIndexKey: dedup 索引 (Key: (content_id, channel_id), Value: fragment size)
+	ContentIndexKey = collections.NewPrefix("content_index")
 )
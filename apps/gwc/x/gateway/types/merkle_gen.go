@@ -16,10 +16,20 @@ type CSUFragmentProofData struct {
 	FileProof     *MerkleProof // types. を削除
 }
 
+// CSUFileProofData は1ファイルの file leaf とその証明です（差分デプロイで再利用するファイルに使います）。
+type CSUFileProofData struct {
+	Path          string
+	FileSize      uint64
+	FileRoot      string
+	FileProof     *MerkleProof
+	FragmentCount uint64
+}
+
 // CSUSessionProofData はセッション全体の証明データです。
 type CSUSessionProofData struct {
 	RootProofHex string
 	Fragments    []CSUFragmentProofData
+	Files        []CSUFileProofData
 }
 
 // BuildCSUProofs は解凍されたファイル群からCSU仕様のRootProofと全断片のProofを生成します。
@@ -29,6 +39,9 @@ type CSUSessionProofData struct {
 // 2. File root: MerkleRoot(fragment_leaves)
 // 3. File leaf: SHA256("FILE:{path}:{file_size}:{file_root}")
 // 4. RootProof: MerkleRoot(file_leaves)
+//
+// イレイジャーコーディングのセッションでは ApplyErasureCoding 後の Chunks（データ + パリティのシャード列）を
+// そのまま断片として扱います。file_size は元ファイルのサイズのままです。
 func BuildCSUProofs(files []ProcessedFile) (*CSUSessionProofData, error) {
 	// 決定論的順序のため、パス昇順でソート
 	sort.Slice(files, func(i, j int) bool {
@@ -120,6 +133,13 @@ func BuildCSUProofs(files []ProcessedFile) (*CSUSessionProofData, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate file proof for %s: %w", f.Path, err)
 		}
+		result.Files = append(result.Files, CSUFileProofData{
+			Path:          f.Path,
+			FileSize:      info.fileSize,
+			FileRoot:      info.fileRoot,
+			FileProof:     fileProof,
+			FragmentCount: uint64(len(info.fragments)),
+		})
 
 		for fragIdx, frag := range info.fragments {
 			// この断片の FragmentProof (Fragment Treeに対する証明)
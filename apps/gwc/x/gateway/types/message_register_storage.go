@@ -8,6 +8,10 @@ import (
 
 var _ sdk.Msg = &MsgRegisterStorage{}
 
+// MaxCapacityWeight は capacity_weight の上限です。
+// weighted 配置で全チャネルの重みを uint64 で合計してもオーバーフローしないよう制限します。
+const MaxCapacityWeight uint64 = 1 << 32
+
 // NewMsgRegisterStorage は MsgRegisterStorage の新しいインスタンスを作成します。
 func NewMsgRegisterStorage(authority string, storageInfos []*StorageInfo) *MsgRegisterStorage {
 	return &MsgRegisterStorage{
@@ -34,6 +38,9 @@ func (msg *MsgRegisterStorage) ValidateBasic() error {
 		if info.ChannelId == "" {
 			return errorsmod.Wrap(sdkerrors.ErrInvalidRequest, "channel_id cannot be empty")
 		}
+		if info.CapacityWeight > MaxCapacityWeight {
+			return errorsmod.Wrapf(sdkerrors.ErrInvalidRequest, "capacity_weight exceeds %d: %d", MaxCapacityWeight, info.CapacityWeight)
+		}
 	}
 
 	return nil
@@ -0,0 +1,33 @@
+package types
+
+import (
+	errorsmod "cosmossdk.io/errors"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+)
+
+var _ sdk.Msg = &MsgSetExecutor{}
+
+// NewMsgSetExecutor は MsgSetExecutor の新しいインスタンスを作成します。
+func NewMsgSetExecutor(authority, address string, enabled bool, moniker string) *MsgSetExecutor {
+	return &MsgSetExecutor{
+		Authority: authority,
+		Address:   address,
+		Enabled:   enabled,
+		Moniker:   moniker,
+	}
+}
+
+// ValidateBasic はメッセージの基本的な整合性チェックを行います。
+func (msg *MsgSetExecutor) ValidateBasic() error {
+	if _, err := sdk.AccAddressFromBech32(msg.Authority); err != nil {
+		return errorsmod.Wrapf(sdkerrors.ErrInvalidAddress, "invalid authority address (%s)", err)
+	}
+	if _, err := sdk.AccAddressFromBech32(msg.Address); err != nil {
+		return errorsmod.Wrapf(sdkerrors.ErrInvalidAddress, "invalid executor address (%s)", err)
+	}
+	if len(msg.Moniker) > 64 {
+		return errorsmod.Wrap(sdkerrors.ErrInvalidRequest, "moniker too long (max 64)")
+	}
+	return nil
+}
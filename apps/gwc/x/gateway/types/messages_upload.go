@@ -12,8 +12,11 @@ import (
 var _ sdk.Msg = &MsgInitSession{}
 var _ sdk.Msg = &MsgCommitRootProof{}
 var _ sdk.Msg = &MsgDistributeBatch{}
+var _ sdk.Msg = &MsgRedistributeFragments{}
 var _ sdk.Msg = &MsgFinalizeAndCloseSession{}
 var _ sdk.Msg = &MsgAbortAndCloseSession{}
+var _ sdk.Msg = &MsgCancelSession{}
+var _ sdk.Msg = &MsgExtendSessionDeadline{}
 
 // MsgInitSession
 func (msg *MsgInitSession) ValidateBasic() error {
@@ -21,7 +24,11 @@ func (msg *MsgInitSession) ValidateBasic() error {
 	if err != nil {
 		return errors.Wrapf(sdkerrors.ErrInvalidAddress, "invalid owner address (%s)", err)
 	}
-	// Executorフィールド削除に伴い検証削除
+	if msg.PreferredExecutor != "" {
+		if _, err := sdk.AccAddressFromBech32(msg.PreferredExecutor); err != nil {
+			return errors.Wrapf(sdkerrors.ErrInvalidAddress, "invalid preferred_executor address (%s)", err)
+		}
+	}
 
 	if msg.FragmentSize == 0 {
 		return errors.Wrap(sdkerrors.ErrInvalidRequest, "fragment_size must be > 0")
@@ -29,6 +36,71 @@ func (msg *MsgInitSession) ValidateBasic() error {
 	if msg.DeadlineUnix < 0 {
 		return errors.Wrap(sdkerrors.ErrInvalidRequest, "deadline_unix must be >= 0")
 	}
+	if _, ok := PlacementStrategy_name[int32(msg.PlacementStrategy)]; !ok {
+		return errors.Wrapf(sdkerrors.ErrInvalidRequest, "unknown placement_strategy: %d", msg.PlacementStrategy)
+	}
+	if msg.NumFdscChains > 0 && msg.ReplicationFactor > msg.NumFdscChains {
+		return errors.Wrapf(ErrInvalidReplicationFactor, "replication_factor %d exceeds num_fdsc_chains %d", msg.ReplicationFactor, msg.NumFdscChains)
+	}
+	if (msg.ErasureDataShards == 0) != (msg.ErasureParityShards == 0) {
+		return errors.Wrap(ErrInvalidErasureCoding, "erasure_data_shards and erasure_parity_shards must both be set or both be 0")
+	}
+	if msg.ErasureDataShards > 0 {
+		shards := uint64(msg.ErasureDataShards) + uint64(msg.ErasureParityShards)
+		if shards > 256 {
+			return errors.Wrapf(ErrInvalidErasureCoding, "too many shards per stripe: %d > 256", shards)
+		}
+		if msg.FragmentSize == 0 {
+			return errors.Wrap(ErrInvalidErasureCoding, "fragment_size must be > 0 for erasure-coded sessions")
+		}
+		if msg.ReplicationFactor > 1 {
+			return errors.Wrap(ErrInvalidErasureCoding, "erasure coding cannot be combined with replication_factor > 1")
+		}
+		if msg.NumFdscChains > 0 && shards > uint64(msg.NumFdscChains) {
+			return errors.Wrapf(ErrInvalidErasureCoding, "%d shards per stripe need as many FDSC chains, num_fdsc_chains is %d", shards, msg.NumFdscChains)
+		}
+	}
+	if err := ValidateCipherSuite(msg.CipherSuite); err != nil {
+		return errors.Wrap(ErrInvalidEncryption, err.Error())
+	}
+	if msg.CipherSuite == "" && len(msg.WrappedKeys) > 0 {
+		return errors.Wrap(ErrInvalidEncryption, "wrapped_keys require a cipher_suite")
+	}
+	if msg.CipherSuite != "" && msg.FragmentSize <= EncryptionOverhead {
+		return errors.Wrapf(ErrInvalidEncryption, "fragment_size must be > %d for encrypted sessions", EncryptionOverhead)
+	}
+	if err := ValidateCompression(msg.Compression); err != nil {
+		return errors.Wrap(ErrInvalidCompression, err.Error())
+	}
+	if msg.Compression != "" {
+		// 圧縮チャンクは可変長のため、固定長チャンクを前提とするイレイジャーコーディング・暗号化とは併用できません
+		if msg.ErasureDataShards > 0 {
+			return errors.Wrap(ErrInvalidCompression, "compression cannot be combined with erasure coding")
+		}
+		if msg.CipherSuite != "" {
+			return errors.Wrap(ErrInvalidCompression, "compression cannot be combined with client-side encryption")
+		}
+		if CompressionChunkSize(int(msg.FragmentSize)) <= 0 {
+			return errors.Wrapf(ErrInvalidCompression, "fragment_size %d is too small for compression", msg.FragmentSize)
+		}
+	}
+	if len(msg.WrappedKeys) > MaxWrappedKeys {
+		return errors.Wrapf(ErrInvalidEncryption, "too many wrapped_keys: %d > %d", len(msg.WrappedKeys), MaxWrappedKeys)
+	}
+	recipients := make(map[string]struct{}, len(msg.WrappedKeys))
+	for i, wk := range msg.WrappedKeys {
+		if err := wk.Validate(); err != nil {
+			return errors.Wrapf(ErrInvalidEncryption, "wrapped_keys[%d]: %v", i, err)
+		}
+		if _, dup := recipients[wk.Recipient]; dup {
+			return errors.Wrapf(ErrInvalidEncryption, "duplicate wrapped key for recipient %s", wk.Recipient)
+		}
+		recipients[wk.Recipient] = struct{}{}
+	}
+	// upload token はクライアントが生成し、チェーンにはハッシュだけを渡します（必須）
+	if b, err := hex.DecodeString(msg.UploadTokenHashHex); err != nil || len(b) != 32 {
+		return errors.Wrap(sdkerrors.ErrInvalidRequest, "upload_token_hash_hex must be 32-byte hex")
+	}
 	return nil
 }
 
@@ -47,6 +119,12 @@ func (msg *MsgCommitRootProof) ValidateBasic() error {
 	if _, err := hex.DecodeString(msg.RootProofHex); err != nil {
 		return errors.Wrap(sdkerrors.ErrInvalidRequest, "root_proof_hex must be valid hex")
 	}
+	if msg.ExpectedFragmentCount == 0 {
+		return errors.Wrap(sdkerrors.ErrInvalidRequest, "expected_fragment_count must be > 0")
+	}
+	if msg.ExpectedTotalBytes < msg.ExpectedFragmentCount {
+		return errors.Wrap(sdkerrors.ErrInvalidRequest, "expected_total_bytes must be >= expected_fragment_count")
+	}
 	return nil
 }
 
@@ -66,7 +144,11 @@ func (msg *MsgDistributeBatch) ValidateBasic() error {
 		if it.Path == "" {
 			return errors.Wrap(sdkerrors.ErrInvalidRequest, "item.path cannot be empty")
 		}
-		if len(it.FragmentBytes) == 0 {
+		if it.ContentId != "" && !IsContentID(it.ContentId) {
+			return errors.Wrap(ErrInvalidFragmentReference, "item.content_id must be 64 lowercase hex characters")
+		}
+		// fragment_bytes may only be omitted by a dedup reference (content_id set)
+		if len(it.FragmentBytes) == 0 && it.ContentId == "" {
 			return errors.Wrap(sdkerrors.ErrInvalidRequest, "item.fragment_bytes cannot be empty")
 		}
 		// proofs may be empty in single-leaf case; on-chain verification enforced in handler (Issue4)
@@ -74,6 +156,29 @@ func (msg *MsgDistributeBatch) ValidateBasic() error {
 	return nil
 }
 
+// MsgRedistributeFragments
+func (msg *MsgRedistributeFragments) ValidateBasic() error {
+	_, err := sdk.AccAddressFromBech32(msg.Executor)
+	if err != nil {
+		return errors.Wrapf(sdkerrors.ErrInvalidAddress, "invalid executor address (%s)", err)
+	}
+	if msg.SessionId == "" {
+		return errors.Wrap(sdkerrors.ErrInvalidRequest, "session_id cannot be empty")
+	}
+	if len(msg.Items) == 0 {
+		return errors.Wrap(sdkerrors.ErrInvalidRequest, "items cannot be empty")
+	}
+	for _, it := range msg.Items {
+		if it.Path == "" {
+			return errors.Wrap(sdkerrors.ErrInvalidRequest, "item.path cannot be empty")
+		}
+		if len(it.FragmentBytes) == 0 {
+			return errors.Wrap(sdkerrors.ErrInvalidRequest, "item.fragment_bytes cannot be empty")
+		}
+	}
+	return nil
+}
+
 // MsgFinalizeAndCloseSession
 func (msg *MsgFinalizeAndCloseSession) ValidateBasic() error {
 	_, err := sdk.AccAddressFromBech32(msg.Executor)
@@ -83,20 +188,44 @@ func (msg *MsgFinalizeAndCloseSession) ValidateBasic() error {
 	if msg.SessionId == "" {
 		return errors.Wrap(sdkerrors.ErrInvalidRequest, "session_id cannot be empty")
 	}
-	if msg.Manifest.ProjectName == "" {
-		return errors.Wrap(sdkerrors.ErrInvalidRequest, "manifest.project_name cannot be empty")
+	if msg.ProjectName == "" {
+		return errors.Wrap(sdkerrors.ErrInvalidRequest, "project_name cannot be empty")
 	}
-	if msg.Manifest.Version == "" {
-		return errors.Wrap(sdkerrors.ErrInvalidRequest, "manifest.version cannot be empty")
+	if msg.Version == "" {
+		return errors.Wrap(sdkerrors.ErrInvalidRequest, "version cannot be empty")
 	}
-	if msg.Manifest.RootProof == "" {
-		return errors.Wrap(sdkerrors.ErrInvalidRequest, "manifest.root_proof cannot be empty")
+	seen := make(map[string]struct{}, len(msg.MimeOverrides))
+	for _, o := range msg.MimeOverrides {
+		if o.Path == "" || o.MimeType == "" {
+			return errors.Wrap(sdkerrors.ErrInvalidRequest, "mime_overrides entries need path and mime_type")
+		}
+		if _, dup := seen[o.Path]; dup {
+			return errors.Wrapf(sdkerrors.ErrInvalidRequest, "duplicate mime override for %s", o.Path)
+		}
+		seen[o.Path] = struct{}{}
 	}
-	if msg.Manifest.Owner == "" {
-		return errors.Wrap(sdkerrors.ErrInvalidRequest, "manifest.owner cannot be empty")
+	reused := make(map[string]struct{}, len(msg.ReusedFiles))
+	for _, f := range msg.ReusedFiles {
+		if err := f.Validate(); err != nil {
+			return errors.Wrap(ErrInvalidReusedFile, err.Error())
+		}
+		if _, dup := reused[f.Path]; dup {
+			return errors.Wrapf(ErrInvalidReusedFile, "duplicate reused file %s", f.Path)
+		}
+		reused[f.Path] = struct{}{}
 	}
-	if msg.Manifest.SessionId == "" {
-		return errors.Wrap(sdkerrors.ErrInvalidRequest, "manifest.session_id cannot be empty")
+	empty := make(map[string]struct{}, len(msg.EmptyFiles))
+	for _, p := range msg.EmptyFiles {
+		if p == "" {
+			return errors.Wrap(sdkerrors.ErrInvalidRequest, "empty_files entries need a path")
+		}
+		if _, dup := empty[p]; dup {
+			return errors.Wrapf(sdkerrors.ErrInvalidRequest, "duplicate empty file %s", p)
+		}
+		if _, dup := reused[p]; dup {
+			return errors.Wrapf(sdkerrors.ErrInvalidRequest, "%s is listed as both empty and reused", p)
+		}
+		empty[p] = struct{}{}
 	}
 	return nil
 }
@@ -113,6 +242,33 @@ func (msg *MsgAbortAndCloseSession) ValidateBasic() error {
 	return nil
 }
 
+// MsgCancelSession
+func (msg *MsgCancelSession) ValidateBasic() error {
+	_, err := sdk.AccAddressFromBech32(msg.Owner)
+	if err != nil {
+		return errors.Wrapf(sdkerrors.ErrInvalidAddress, "invalid owner address (%s)", err)
+	}
+	if msg.SessionId == "" {
+		return errors.Wrap(sdkerrors.ErrInvalidRequest, "session_id cannot be empty")
+	}
+	return nil
+}
+
+// MsgExtendSessionDeadline
+func (msg *MsgExtendSessionDeadline) ValidateBasic() error {
+	_, err := sdk.AccAddressFromBech32(msg.Owner)
+	if err != nil {
+		return errors.Wrapf(sdkerrors.ErrInvalidAddress, "invalid owner address (%s)", err)
+	}
+	if msg.SessionId == "" {
+		return errors.Wrap(sdkerrors.ErrInvalidRequest, "session_id cannot be empty")
+	}
+	if msg.NewDeadlineUnix <= 0 {
+		return errors.Wrap(sdkerrors.ErrInvalidRequest, "new_deadline_unix must be > 0")
+	}
+	return nil
+}
+
 // DecodeBase64Std is a small helper for CLI (keeps imports localized)
 func DecodeBase64Std(s string) ([]byte, error) {
 	return base64.StdEncoding.DecodeString(s)
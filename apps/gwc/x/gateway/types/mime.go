@@ -0,0 +1,49 @@
+package types
+
+import (
+	"path"
+	"strings"
+)
+
+// defaultMimeTypes is a fixed extension -> MIME table.
+// mime.TypeByExtension depends on the host's mime.types files, so it must not be used on-chain.
+var defaultMimeTypes = map[string]string{
+	".html":  "text/html; charset=utf-8",
+	".htm":   "text/html; charset=utf-8",
+	".css":   "text/css; charset=utf-8",
+	".js":    "text/javascript; charset=utf-8",
+	".mjs":   "text/javascript; charset=utf-8",
+	".json":  "application/json",
+	".map":   "application/json",
+	".txt":   "text/plain; charset=utf-8",
+	".md":    "text/markdown; charset=utf-8",
+	".xml":   "text/xml; charset=utf-8",
+	".csv":   "text/csv; charset=utf-8",
+	".svg":   "image/svg+xml",
+	".png":   "image/png",
+	".jpg":   "image/jpeg",
+	".jpeg":  "image/jpeg",
+	".gif":   "image/gif",
+	".webp":  "image/webp",
+	".avif":  "image/avif",
+	".ico":   "image/vnd.microsoft.icon",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+	".ttf":   "font/ttf",
+	".otf":   "font/otf",
+	".wasm":  "application/wasm",
+	".pdf":   "application/pdf",
+	".zip":   "application/zip",
+	".mp4":   "video/mp4",
+	".webm":  "video/webm",
+	".mp3":   "audio/mpeg",
+	".wav":   "audio/wav",
+}
+
+// DefaultMimeType returns the deterministic MIME type used for manifest entries without an override.
+func DefaultMimeType(p string) string {
+	if m, ok := defaultMimeTypes[strings.ToLower(path.Ext(p))]; ok {
+		return m
+	}
+	return "application/octet-stream"
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	errorsmod "cosmossdk.io/errors"
+	"cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
 )
 
@@ -13,6 +15,33 @@ const (
 	DefaultMaxFragmentBytes       uint64 = 10_485_7600 // 100 MiBs
 	DefaultMaxFragmentsPerSession uint64 = 50_000
 	DefaultDeadlineSeconds        int64  = 1 * 60 * 60 // 1h
+	DefaultMaxFragmentRetries     uint32 = 3
+	DefaultMaxExpiredPerBlock     uint32 = 100
+	DefaultSessionRetentionSecs   int64  = 24 * 60 * 60 // 1d
+
+	DefaultMaxOpenSessionsPerOwner    uint32 = 16
+	DefaultOwnerQuotaWindowSeconds    int64  = 24 * 60 * 60 // 1d
+	DefaultMaxBytesPerOwnerWindow     uint64 = 10 << 30     // 10 GiB
+	DefaultMaxFragmentsPerOwnerWindow uint64 = 200_000
+	DefaultMinInitSessionIntervalSecs int64  = 0 // disabled
+
+	DefaultDepositDenom = "stake"
+
+	DefaultMaxSessionDurationSecs int64 = 24 * 60 * 60 // 1d
+
+	DefaultChannelFailureThreshold     uint32 = 5
+	DefaultChannelHealthWindowSeconds  int64  = 60 * 60 // 1h
+	DefaultChannelDegradedCooldownSecs int64  = 5 * 60  // 5m
+
+	DefaultMaxReplicationFactor uint32 = 3
+)
+
+// Upper bounds enforced by Params.Validate.
+const (
+	// MaxChannelFailureThreshold bounds channel_failure_threshold; a larger value would never mark a channel degraded in practice.
+	MaxChannelFailureThreshold uint32 = 1000
+	// MaxReplicationFactorLimit bounds max_replication_factor: every replica is a separate FragmentPacket.
+	MaxReplicationFactorLimit uint32 = 16
 )
 
 // NewParams creates a new Params instance.
@@ -22,12 +51,48 @@ func NewParams(
 	defaultDeadlineSeconds int64,
 	enableLegacyUpload bool,
 	localAdmin string,
+	maxFragmentRetries uint32,
+	maxExpiredSessionsPerBlock uint32,
+	sessionRetentionSeconds int64,
+	maxOpenSessionsPerOwner uint32,
+	ownerQuotaWindowSeconds int64,
+	maxBytesPerOwnerWindow uint64,
+	maxFragmentsPerOwnerWindow uint64,
+	minInitSessionIntervalSeconds int64,
+	depositPricePerByte sdk.DecCoin,
+	maxSessionDurationSeconds int64,
+	allowGenericAuthorization bool,
+	channelFailureThreshold uint32,
+	channelHealthWindowSeconds int64,
+	channelDegradedCooldownSeconds int64,
+	maxReplicationFactor uint32,
 ) Params {
 	return Params{
 		MaxFragmentBytes:       maxFragmentBytes,
 		MaxFragmentsPerSession: maxFragmentsPerSession,
 		DefaultDeadlineSeconds: defaultDeadlineSeconds,
 		LocalAdmin:             localAdmin,
+		MaxFragmentRetries:     maxFragmentRetries,
+
+		MaxExpiredSessionsPerBlock: maxExpiredSessionsPerBlock,
+		SessionRetentionSeconds:    sessionRetentionSeconds,
+
+		MaxOpenSessionsPerOwner:       maxOpenSessionsPerOwner,
+		OwnerQuotaWindowSeconds:       ownerQuotaWindowSeconds,
+		MaxBytesPerOwnerWindow:        maxBytesPerOwnerWindow,
+		MaxFragmentsPerOwnerWindow:    maxFragmentsPerOwnerWindow,
+		MinInitSessionIntervalSeconds: minInitSessionIntervalSeconds,
+
+		DepositPricePerByte: depositPricePerByte,
+
+		MaxSessionDurationSeconds: maxSessionDurationSeconds,
+		AllowGenericAuthorization: allowGenericAuthorization,
+
+		ChannelFailureThreshold:        channelFailureThreshold,
+		ChannelHealthWindowSeconds:     channelHealthWindowSeconds,
+		ChannelDegradedCooldownSeconds: channelDegradedCooldownSeconds,
+
+		MaxReplicationFactor: maxReplicationFactor,
 	}
 }
 
@@ -40,6 +105,21 @@ func DefaultParams() Params {
 		DefaultDeadlineSeconds,
 		false, // enable_legacy_upload default: false
 		"",    // local_admin must be set externally
+		DefaultMaxFragmentRetries,
+		DefaultMaxExpiredPerBlock,
+		DefaultSessionRetentionSecs,
+		DefaultMaxOpenSessionsPerOwner,
+		DefaultOwnerQuotaWindowSeconds,
+		DefaultMaxBytesPerOwnerWindow,
+		DefaultMaxFragmentsPerOwnerWindow,
+		DefaultMinInitSessionIntervalSecs,
+		sdk.NewDecCoinFromDec(DefaultDepositDenom, math.LegacyZeroDec()), // deposits disabled by default
+		DefaultMaxSessionDurationSecs,
+		false, // allow_generic_authorization default: false (session-bound grants only)
+		DefaultChannelFailureThreshold,
+		DefaultChannelHealthWindowSeconds,
+		DefaultChannelDegradedCooldownSecs,
+		DefaultMaxReplicationFactor,
 	)
 }
 
@@ -61,6 +141,59 @@ func (p Params) Validate() error {
 		return errorsmod.Wrap(sdkerrors.ErrInvalidRequest, fmt.Sprintf("default_deadline_seconds too large: %d", p.DefaultDeadlineSeconds))
 	}
 
+	// max_fragment_retries == 0 is valid (redistribution disabled). Keep an upper bound to avoid
+	// unbounded packet spam for a single fragment.
+	if p.MaxFragmentRetries > 100 {
+		return errorsmod.Wrap(sdkerrors.ErrInvalidRequest, fmt.Sprintf("max_fragment_retries too large: %d", p.MaxFragmentRetries))
+	}
+
+	if p.MaxExpiredSessionsPerBlock == 0 {
+		return errorsmod.Wrap(sdkerrors.ErrInvalidRequest, "max_expired_sessions_per_block must be > 0")
+	}
+	if p.SessionRetentionSeconds < 0 {
+		return errorsmod.Wrap(sdkerrors.ErrInvalidRequest, "session_retention_seconds must be >= 0")
+	}
+
+	if p.OwnerQuotaWindowSeconds < 0 {
+		return errorsmod.Wrap(sdkerrors.ErrInvalidRequest, "owner_quota_window_seconds must be >= 0")
+	}
+	if p.MinInitSessionIntervalSeconds < 0 {
+		return errorsmod.Wrap(sdkerrors.ErrInvalidRequest, "min_init_session_interval_seconds must be >= 0")
+	}
+
+	if p.DepositPricePerByte.Amount.IsNil() {
+		return errorsmod.Wrap(sdkerrors.ErrInvalidRequest, "deposit_price_per_byte must be set")
+	}
+	if err := p.DepositPricePerByte.Validate(); err != nil {
+		return errorsmod.Wrapf(sdkerrors.ErrInvalidRequest, "invalid deposit_price_per_byte: %v", err)
+	}
+
+	if p.MaxSessionDurationSeconds < p.DefaultDeadlineSeconds {
+		return errorsmod.Wrapf(sdkerrors.ErrInvalidRequest,
+			"max_session_duration_seconds (%d) must be >= default_deadline_seconds (%d)",
+			p.MaxSessionDurationSeconds, p.DefaultDeadlineSeconds)
+	}
+
+	if p.ChannelHealthWindowSeconds < 0 {
+		return errorsmod.Wrap(sdkerrors.ErrInvalidRequest, "channel_health_window_seconds must be >= 0")
+	}
+	if p.ChannelDegradedCooldownSeconds < 0 {
+		return errorsmod.Wrap(sdkerrors.ErrInvalidRequest, "channel_degraded_cooldown_seconds must be >= 0")
+	}
+	// channel_failure_threshold == 0 is valid (degraded-channel exclusion disabled).
+	if p.ChannelFailureThreshold > MaxChannelFailureThreshold {
+		return errorsmod.Wrap(sdkerrors.ErrInvalidRequest, fmt.Sprintf("channel_failure_threshold too large: %d", p.ChannelFailureThreshold))
+	}
+	// A degraded channel is skipped until now + cooldown, so exclusion without a cooldown would never take effect.
+	if p.ChannelFailureThreshold > 0 && p.ChannelDegradedCooldownSeconds == 0 {
+		return errorsmod.Wrap(sdkerrors.ErrInvalidRequest, "channel_degraded_cooldown_seconds must be > 0 when channel_failure_threshold is set")
+	}
+
+	// max_replication_factor == 0 is valid (no cap beyond the session's FDSC channels).
+	if p.MaxReplicationFactor > MaxReplicationFactorLimit {
+		return errorsmod.Wrap(sdkerrors.ErrInvalidRequest, fmt.Sprintf("max_replication_factor too large: %d (max %d)", p.MaxReplicationFactor, MaxReplicationFactorLimit))
+	}
+
 	// local_admin validation is intentionally NOT strict here to avoid genesis defaults failing.
 	// CSU handlers enforce local_admin != "".
 
@@ -0,0 +1,41 @@
+package types_test
+
+import (
+	"testing"
+
+	"gwc/x/gateway/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParams_Validate(t *testing.T) {
+	cases := []struct {
+		name    string
+		modify  func(p *types.Params)
+		wantErr string
+	}{
+		{name: "defaults", modify: func(*types.Params) {}},
+		{name: "channel exclusion disabled", modify: func(p *types.Params) {
+			p.ChannelFailureThreshold = 0
+			p.ChannelDegradedCooldownSeconds = 0
+		}},
+		{name: "max channel failure threshold", modify: func(p *types.Params) { p.ChannelFailureThreshold = types.MaxChannelFailureThreshold }},
+		{name: "channel failure threshold too large", modify: func(p *types.Params) { p.ChannelFailureThreshold = types.MaxChannelFailureThreshold + 1 }, wantErr: "channel_failure_threshold too large"},
+		{name: "channel failure threshold without cooldown", modify: func(p *types.Params) { p.ChannelDegradedCooldownSeconds = 0 }, wantErr: "channel_degraded_cooldown_seconds must be > 0"},
+		{name: "no replication cap", modify: func(p *types.Params) { p.MaxReplicationFactor = 0 }},
+		{name: "max replication factor", modify: func(p *types.Params) { p.MaxReplicationFactor = types.MaxReplicationFactorLimit }},
+		{name: "replication factor too large", modify: func(p *types.Params) { p.MaxReplicationFactor = types.MaxReplicationFactorLimit + 1 }, wantErr: "max_replication_factor too large"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := types.DefaultParams()
+			tc.modify(&p)
+			err := p.Validate()
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package types
+
+import "fmt"
+
+// GF(2^8) arithmetic over the polynomial x^8 + x^4 + x^3 + x^2 + 1 (0x11d).
+var (
+	gfExp [510]byte
+	gfLog [256]byte
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+// gfInv returns the multiplicative inverse of a (a must be non-zero).
+func gfInv(a byte) byte {
+	return gfExp[255-int(gfLog[a])]
+}
+
+func gfPow(a byte, n int) byte {
+	if n == 0 {
+		return 1
+	}
+	if a == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])*n)%255]
+}
+
+// reedSolomon is a systematic Reed-Solomon code with dataShards data and parityShards parity shards.
+//
+// The encoding matrix is a (k+m) x k Vandermonde matrix multiplied by the inverse of its top k rows,
+// so the top k rows are the identity (data shards are stored as-is) and any k rows are invertible
+// (any k of the k+m shards rebuild the data).
+type reedSolomon struct {
+	dataShards   int
+	parityShards int
+	matrix       [][]byte
+}
+
+func newReedSolomon(dataShards, parityShards int) (*reedSolomon, error) {
+	if dataShards <= 0 || parityShards <= 0 {
+		return nil, fmt.Errorf("data and parity shard counts must be > 0, got %d+%d", dataShards, parityShards)
+	}
+	if dataShards+parityShards > 256 {
+		return nil, fmt.Errorf("too many shards: %d+%d > 256", dataShards, parityShards)
+	}
+	total := dataShards + parityShards
+	vandermonde := make([][]byte, total)
+	for r := range vandermonde {
+		vandermonde[r] = make([]byte, dataShards)
+		for c := range vandermonde[r] {
+			vandermonde[r][c] = gfPow(byte(r), c)
+		}
+	}
+	topInv, err := gfInvertMatrix(vandermonde[:dataShards])
+	if err != nil {
+		return nil, err
+	}
+	return &reedSolomon{
+		dataShards:   dataShards,
+		parityShards: parityShards,
+		matrix:       gfMulMatrix(vandermonde, topInv),
+	}, nil
+}
+
+// encode computes the parity shards from the data shards.
+// shards must hold dataShards+parityShards entries; the data shards must be non-nil and of equal length.
+func (rs *reedSolomon) encode(shards [][]byte) error {
+	if len(shards) != rs.dataShards+rs.parityShards {
+		return fmt.Errorf("expected %d shards, got %d", rs.dataShards+rs.parityShards, len(shards))
+	}
+	size := len(shards[0])
+	for i := 0; i < rs.dataShards; i++ {
+		if len(shards[i]) != size {
+			return fmt.Errorf("data shard %d has length %d, expected %d", i, len(shards[i]), size)
+		}
+	}
+	for p := 0; p < rs.parityShards; p++ {
+		shards[rs.dataShards+p] = rs.combine(rs.matrix[rs.dataShards+p], shards[:rs.dataShards], size)
+	}
+	return nil
+}
+
+// reconstructData fills in the missing (nil) data shards from any dataShards present shards.
+// All present shards must have the same length. Parity shards are left as they are.
+func (rs *reedSolomon) reconstructData(shards [][]byte) error {
+	if len(shards) != rs.dataShards+rs.parityShards {
+		return fmt.Errorf("expected %d shards, got %d", rs.dataShards+rs.parityShards, len(shards))
+	}
+	missing := false
+	for i := 0; i < rs.dataShards; i++ {
+		if shards[i] == nil {
+			missing = true
+			break
+		}
+	}
+	if !missing {
+		return nil
+	}
+
+	rows := make([][]byte, 0, rs.dataShards)
+	inputs := make([][]byte, 0, rs.dataShards)
+	size := -1
+	for i := 0; i < len(shards) && len(rows) < rs.dataShards; i++ {
+		if shards[i] == nil {
+			continue
+		}
+		if size >= 0 && len(shards[i]) != size {
+			return fmt.Errorf("shard %d has length %d, expected %d", i, len(shards[i]), size)
+		}
+		size = len(shards[i])
+		rows = append(rows, rs.matrix[i])
+		inputs = append(inputs, shards[i])
+	}
+	if len(rows) < rs.dataShards {
+		return fmt.Errorf("too few shards: have %d, need %d", len(rows), rs.dataShards)
+	}
+
+	decode, err := gfInvertMatrix(rows)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < rs.dataShards; i++ {
+		if shards[i] == nil {
+			shards[i] = rs.combine(decode[i], inputs, size)
+		}
+	}
+	return nil
+}
+
+// combine returns sum_j coeffs[j] * inputs[j] (byte-wise, in GF(2^8)).
+func (rs *reedSolomon) combine(coeffs []byte, inputs [][]byte, size int) []byte {
+	out := make([]byte, size)
+	for j, in := range inputs {
+		c := coeffs[j]
+		if c == 0 {
+			continue
+		}
+		for b := 0; b < size; b++ {
+			out[b] ^= gfMul(c, in[b])
+		}
+	}
+	return out
+}
+
+func gfMulMatrix(a, b [][]byte) [][]byte {
+	out := make([][]byte, len(a))
+	for r := range a {
+		out[r] = make([]byte, len(b[0]))
+		for c := range out[r] {
+			var v byte
+			for i := range b {
+				v ^= gfMul(a[r][i], b[i][c])
+			}
+			out[r][c] = v
+		}
+	}
+	return out
+}
+
+// gfInvertMatrix inverts a square matrix by Gauss-Jordan elimination.
+func gfInvertMatrix(m [][]byte) ([][]byte, error) {
+	n := len(m)
+	work := make([][]byte, n)
+	for r := range m {
+		if len(m[r]) != n {
+			return nil, fmt.Errorf("matrix is not square")
+		}
+		work[r] = make([]byte, 2*n)
+		copy(work[r], m[r])
+		work[r][n+r] = 1
+	}
+	for col := 0; col < n; col++ {
+		pivot := -1
+		for r := col; r < n; r++ {
+			if work[r][col] != 0 {
+				pivot = r
+				break
+			}
+		}
+		if pivot < 0 {
+			return nil, fmt.Errorf("matrix is singular")
+		}
+		work[col], work[pivot] = work[pivot], work[col]
+		if inv := gfInv(work[col][col]); inv != 1 {
+			for c := range work[col] {
+				work[col][c] = gfMul(work[col][c], inv)
+			}
+		}
+		for r := 0; r < n; r++ {
+			if r == col || work[r][col] == 0 {
+				continue
+			}
+			f := work[r][col]
+			for c := range work[r] {
+				work[r][c] ^= gfMul(f, work[col][c])
+			}
+		}
+	}
+	out := make([][]byte, n)
+	for r := range work {
+		out[r] = work[r][n:]
+	}
+	return out, nil
+}
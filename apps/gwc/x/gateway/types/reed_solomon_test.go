@@ -0,0 +1,69 @@
+package types
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestReedSolomonAnyRowsInvertible checks the MDS property of the encoding matrix: every set of dataShards
+// rows (i.e. every k of the k+m shards) is invertible. Small codes are checked exhaustively, large ones
+// (up to the 256-shard limit) on random row sets.
+func TestReedSolomonAnyRowsInvertible(t *testing.T) {
+	configs := []struct {
+		k, m    int
+		samples int // 0 = every row set
+	}{
+		{k: 4, m: 4}, {k: 12, m: 4}, {k: 10, m: 6},
+		{k: 32, m: 32, samples: 200},
+		{k: 200, m: 56, samples: 10},
+	}
+	for _, c := range configs {
+		t.Run(fmt.Sprintf("%d+%d", c.k, c.m), func(t *testing.T) {
+			rs, err := newReedSolomon(c.k, c.m)
+			require.NoError(t, err)
+			n := c.k + c.m
+
+			// the top rows are the identity (systematic code)
+			for r := 0; r < c.k; r++ {
+				for col := 0; col < c.k; col++ {
+					want := byte(0)
+					if r == col {
+						want = 1
+					}
+					require.Equal(t, want, rs.matrix[r][col])
+				}
+			}
+
+			check := func(rows []int) {
+				sub := make([][]byte, len(rows))
+				for i, r := range rows {
+					sub[i] = rs.matrix[r]
+				}
+				_, err := gfInvertMatrix(sub)
+				require.NoError(t, err, "rows %v", rows)
+			}
+			if c.samples == 0 {
+				for mask := 0; mask < 1<<n; mask++ {
+					var rows []int
+					for r := 0; r < n; r++ {
+						if mask&(1<<r) != 0 {
+							rows = append(rows, r)
+						}
+					}
+					if len(rows) == c.k {
+						check(rows)
+					}
+				}
+				return
+			}
+			rnd := rand.New(rand.NewSource(int64(n)))
+			for i := 0; i < c.samples; i++ {
+				rows := rnd.Perm(n)[:c.k]
+				check(rows)
+			}
+		})
+	}
+}
@@ -16,6 +16,37 @@ type sessionIDGetter interface {
 	GetSessionId() string
 }
 
+// payloadBytesGetter is implemented by messages that carry fragment payloads (bytes_remaining budget).
+type payloadBytesGetter interface {
+	PayloadBytes() uint64
+}
+
+var (
+	_ payloadBytesGetter = (*MsgDistributeBatch)(nil)
+	_ payloadBytesGetter = (*MsgRedistributeFragments)(nil)
+)
+
+// PayloadBytes returns the total fragment bytes carried by the batch.
+func (msg *MsgDistributeBatch) PayloadBytes() uint64 {
+	return sumFragmentBytes(msg.Items)
+}
+
+// PayloadBytes returns the total fragment bytes carried by the batch.
+func (msg *MsgRedistributeFragments) PayloadBytes() uint64 {
+	return sumFragmentBytes(msg.Items)
+}
+
+// sumFragmentBytes sums len(fragment_bytes) of the items. Dedup references (empty fragment_bytes with a content_id)
+// link to a copy an FDSC already stores, so they transfer and store no new bytes and are charged 0 against
+// bytes_remaining; a batch of references still consumes msgs_remaining.
+func sumFragmentBytes(items []DistributeItem) uint64 {
+	var n uint64
+	for _, it := range items {
+		n += uint64(len(it.FragmentBytes))
+	}
+	return n
+}
+
 func (a *SessionBoundAuthorization) MsgTypeURL() string {
 	return a.MsgTypeUrl
 }
@@ -27,13 +58,20 @@ func (a *SessionBoundAuthorization) ValidateBasic() error {
 	if a.MsgTypeUrl == "" {
 		return errorsmod.Wrap(sdkerrors.ErrInvalidRequest, "msg_type_url must not be empty")
 	}
+	if a.ExpiresUnix < 0 {
+		return errorsmod.Wrap(sdkerrors.ErrInvalidRequest, "expires_unix must be >= 0")
+	}
 	return nil
 }
 
 // Accept enforces:
 // 1) msg type url == a.msg_type_url
 // 2) msg has session_id and it equals a.session_id
-func (a *SessionBoundAuthorization) Accept(_ context.Context, msg sdk.Msg) (authz.AcceptResponse, error) {
+// 3) block time is before a.expires_unix (if set)
+// 4) the msg fits into a.bytes_remaining / a.msgs_remaining (if set)
+//
+// The budgets are decremented via Updated; the grant is deleted once a budget is used up.
+func (a *SessionBoundAuthorization) Accept(ctx context.Context, msg sdk.Msg) (authz.AcceptResponse, error) {
 	msgTypeURL := sdk.MsgTypeURL(msg)
 
 	if msgTypeURL != a.MsgTypeUrl {
@@ -51,6 +89,38 @@ func (a *SessionBoundAuthorization) Accept(_ context.Context, msg sdk.Msg) (auth
 			errorsmod.Wrapf(sdkerrors.ErrUnauthorized, "session_id mismatch: auth=%s msg=%s", a.SessionId, g.GetSessionId())
 	}
 
-	// keep the authorization; it will be revoked on Close (Issue6).
-	return authz.AcceptResponse{Accept: true, Delete: false, Updated: nil}, nil
+	if a.ExpiresUnix != 0 {
+		if now := sdk.UnwrapSDKContext(ctx).BlockTime().Unix(); now >= a.ExpiresUnix {
+			return authz.AcceptResponse{Accept: false, Delete: true, Updated: nil},
+				errorsmod.Wrapf(sdkerrors.ErrUnauthorized, "authorization expired: expires_unix=%d now=%d", a.ExpiresUnix, now)
+		}
+	}
+
+	if a.BytesRemaining == 0 && a.MsgsRemaining == 0 {
+		// keep the authorization; it will be revoked on Close (Issue6).
+		return authz.AcceptResponse{Accept: true, Delete: false, Updated: nil}, nil
+	}
+
+	updated := *a
+	exhausted := false
+	if a.BytesRemaining != 0 {
+		var n uint64
+		if p, ok := msg.(payloadBytesGetter); ok {
+			n = p.PayloadBytes()
+		}
+		if n > a.BytesRemaining {
+			return authz.AcceptResponse{Accept: false, Delete: false, Updated: nil},
+				errorsmod.Wrapf(sdkerrors.ErrUnauthorized, "byte budget exceeded: remaining=%d msg=%d", a.BytesRemaining, n)
+		}
+		updated.BytesRemaining -= n
+		exhausted = exhausted || updated.BytesRemaining == 0
+	}
+	if a.MsgsRemaining != 0 {
+		updated.MsgsRemaining--
+		exhausted = exhausted || updated.MsgsRemaining == 0
+	}
+	if exhausted {
+		return authz.AcceptResponse{Accept: true, Delete: true, Updated: nil}, nil
+	}
+	return authz.AcceptResponse{Accept: true, Delete: false, Updated: &updated}, nil
 }
@@ -0,0 +1,73 @@
+package types_test
+
+import (
+	"testing"
+	"time"
+
+	"gwc/x/gateway/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionBoundAuthorization_Accept(t *testing.T) {
+	ctx := sdk.Context{}.WithBlockTime(time.Unix(1_000, 0))
+	batch := func(sessionID string, sizes ...int) *types.MsgDistributeBatch {
+		msg := &types.MsgDistributeBatch{SessionId: sessionID}
+		for _, n := range sizes {
+			msg.Items = append(msg.Items, types.DistributeItem{FragmentBytes: make([]byte, n)})
+		}
+		return msg
+	}
+
+	t.Run("unbudgeted grant is kept", func(t *testing.T) {
+		a := &types.SessionBoundAuthorization{SessionId: "s1", MsgTypeUrl: types.MsgTypeURLDistributeBatch}
+		resp, err := a.Accept(ctx, batch("s1", 10))
+		require.NoError(t, err)
+		require.True(t, resp.Accept)
+		require.False(t, resp.Delete)
+		require.Nil(t, resp.Updated)
+	})
+
+	t.Run("session mismatch", func(t *testing.T) {
+		a := &types.SessionBoundAuthorization{SessionId: "s1", MsgTypeUrl: types.MsgTypeURLDistributeBatch}
+		resp, err := a.Accept(ctx, batch("s2", 10))
+		require.Error(t, err)
+		require.False(t, resp.Accept)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		a := &types.SessionBoundAuthorization{SessionId: "s1", MsgTypeUrl: types.MsgTypeURLDistributeBatch, ExpiresUnix: 1_000}
+		resp, err := a.Accept(ctx, batch("s1", 10))
+		require.Error(t, err)
+		require.False(t, resp.Accept)
+	})
+
+	t.Run("budgets are decremented", func(t *testing.T) {
+		a := &types.SessionBoundAuthorization{SessionId: "s1", MsgTypeUrl: types.MsgTypeURLDistributeBatch, BytesRemaining: 100, MsgsRemaining: 3}
+		resp, err := a.Accept(ctx, batch("s1", 10, 20))
+		require.NoError(t, err)
+		require.True(t, resp.Accept)
+		require.False(t, resp.Delete)
+		updated, ok := resp.Updated.(*types.SessionBoundAuthorization)
+		require.True(t, ok)
+		require.Equal(t, uint64(70), updated.BytesRemaining)
+		require.Equal(t, uint64(2), updated.MsgsRemaining)
+		require.Equal(t, uint64(100), a.BytesRemaining, "receiver must not be mutated")
+	})
+
+	t.Run("byte budget exceeded", func(t *testing.T) {
+		a := &types.SessionBoundAuthorization{SessionId: "s1", MsgTypeUrl: types.MsgTypeURLDistributeBatch, BytesRemaining: 15}
+		resp, err := a.Accept(ctx, batch("s1", 10, 10))
+		require.Error(t, err)
+		require.False(t, resp.Accept)
+	})
+
+	t.Run("exhausted budget deletes the grant", func(t *testing.T) {
+		a := &types.SessionBoundAuthorization{SessionId: "s1", MsgTypeUrl: types.MsgTypeURLDistributeBatch, MsgsRemaining: 1}
+		resp, err := a.Accept(ctx, batch("s1", 10))
+		require.NoError(t, err)
+		require.True(t, resp.Accept)
+		require.True(t, resp.Delete)
+	})
+}
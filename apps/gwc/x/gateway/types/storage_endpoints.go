@@ -0,0 +1,40 @@
+package types
+
+import (
+	"context"
+
+	"github.com/cosmos/cosmos-sdk/types/query"
+)
+
+// QueryAllStorageEndpoints pages through the StorageEndpoints query and returns every registered endpoint.
+// StorageEndpoints honours pagination, so callers that need the full topology must follow next_key.
+func QueryAllStorageEndpoints(ctx context.Context, queryClient QueryClient) ([]*StorageInfo, error) {
+	infos, _, err := QueryStorageTopology(ctx, queryClient)
+	return infos, err
+}
+
+// QueryStorageTopology is QueryAllStorageEndpoints plus the FDSC channel health reported with the endpoints
+// (keyed by channel_id; channels without a record are healthy).
+func QueryStorageTopology(ctx context.Context, queryClient QueryClient) ([]*StorageInfo, map[string]ChannelHealth, error) {
+	var (
+		out     []*StorageInfo
+		health  = make(map[string]ChannelHealth)
+		nextKey []byte
+	)
+	for {
+		res, err := queryClient.StorageEndpoints(ctx, &QueryStorageEndpointsRequest{
+			Pagination: &query.PageRequest{Key: nextKey},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		out = append(out, res.StorageInfos...)
+		for _, h := range res.ChannelHealth {
+			health[h.ChannelId] = h
+		}
+		if res.Pagination == nil || len(res.Pagination.NextKey) == 0 {
+			return out, health, nil
+		}
+		nextKey = res.Pagination.NextKey
+	}
+}
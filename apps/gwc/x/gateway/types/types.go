@@ -2,6 +2,7 @@ package types
 
 const (
 	MsgTypeURLDistributeBatch         = "/gwc.gateway.v1.MsgDistributeBatch"
+	MsgTypeURLRedistributeFragments   = "/gwc.gateway.v1.MsgRedistributeFragments"
 	MsgTypeURLFinalizeAndCloseSession = "/gwc.gateway.v1.MsgFinalizeAndCloseSession"
 	MsgTypeURLAbortAndCloseSession    = "/gwc.gateway.v1.MsgAbortAndCloseSession"
 )
@@ -9,6 +10,7 @@ const (
 func CSUAuthorizedMsgTypeURLs() []string {
 	return []string{
 		MsgTypeURLDistributeBatch,
+		MsgTypeURLRedistributeFragments,
 		MsgTypeURLFinalizeAndCloseSession,
 		MsgTypeURLAbortAndCloseSession,
 	}
@@ -1,17 +1,14 @@
 package types
 
 import (
-	"archive/zip"
-	"bytes"
 	"fmt"
-	"io"
 	"path"
-	"sort" // ソートのために追加
 	"strings"
 )
 
-// DecompressionLimit は解凍後のデータの合計サイズ制限を定義します（例：100MB）
-const DecompressionLimit = 100 * 1024 * 1024
+// DefaultDecompressionLimit は解凍後のデータの合計サイズ制限の既定値です（100MB）。
+// Executor の上限は app.toml の gwc.decompression_limit で変更できます（OpenArchiveStream の limit）。
+const DefaultDecompressionLimit = 100 * 1024 * 1024
 
 // ProcessedFile は解凍・分割処理されたファイルの構造体です
 type ProcessedFile struct {
@@ -28,76 +25,27 @@ func ProcessZipAndSplit(zipData []byte, chunkSize int) ([]ProcessedFile, error)
 		return nil, fmt.Errorf("chunk size must be greater than 0")
 	}
 
-	zipReader, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	archive, err := NewZipArchive(zipData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create zip reader: %w", err)
+		return nil, err
 	}
+	return ProcessArchiveAndSplit(archive, chunkSize)
+}
 
-	var processedFiles []ProcessedFile
-	var totalDecompressedSize int64
-
-	for _, file := range zipReader.File {
-		if file.FileInfo().IsDir() {
-			continue
-		}
-
-		// パスの正規化とセキュリティチェック（Zip Slip対策）
-		normalizedPath := strings.ReplaceAll(file.Name, "\\", "/")
-		cleanPath := path.Clean(normalizedPath)
-
-		// 絶対パスや上位ディレクトリへの参照を禁止
-		if path.IsAbs(cleanPath) || strings.HasPrefix(cleanPath, "../") {
-			return nil, fmt.Errorf("zip contains unsafe file path: %s", file.Name)
-		}
-
-		// 先頭の "./" や "/" を除去して正規化
-		cleanPath = strings.TrimPrefix(cleanPath, "/")
-		cleanPath = strings.TrimPrefix(cleanPath, "./")
-
-		// 解凍サイズ制限の事前チェック
-		if totalDecompressedSize+file.FileInfo().Size() > DecompressionLimit {
-			return nil, fmt.Errorf("decompression limit exceeded")
-		}
-
-		rc, err := file.Open()
-		if err != nil {
-			return nil, fmt.Errorf("failed to open file in zip: %w", err)
-		}
-
-		// 実際の読み込み時にサイズ制限を適用
-		limitReader := io.LimitReader(rc, DecompressionLimit-totalDecompressedSize+1)
-		content, err := io.ReadAll(limitReader)
-		rc.Close()
-		if err != nil {
-			return nil, fmt.Errorf("failed to read file: %w", err)
-		}
-
-		currentSize := int64(len(content))
-		totalDecompressedSize += currentSize
-		if totalDecompressedSize > DecompressionLimit {
-			return nil, fmt.Errorf("decompression limit exceeded")
-		}
-
-		// データを断片化
-		chunks, err := SplitDataIntoFragments(content, chunkSize)
-		if err != nil {
-			return nil, fmt.Errorf("failed to split file: %w", err)
-		}
+// normalizeArchivePath はアーカイブ内のパスを正規化し、安全性を検証します（Zip Slip対策）。
+func normalizeArchivePath(name string) (string, error) {
+	normalizedPath := strings.ReplaceAll(name, "\\", "/")
+	cleanPath := path.Clean(normalizedPath)
 
-		processedFiles = append(processedFiles, ProcessedFile{
-			Filename: file.Name,
-			Path:     cleanPath,
-			Content:  content,
-			Chunks:   chunks,
-		})
+	// 絶対パスや上位ディレクトリへの参照を禁止
+	if path.IsAbs(cleanPath) || cleanPath == ".." || strings.HasPrefix(cleanPath, "../") {
+		return "", fmt.Errorf("zip contains unsafe file path: %s", name)
 	}
 
-	// 決定論的な順序を保証するため、パスで昇順ソートを実行します
-	sort.Slice(processedFiles, func(i, j int) bool {
-		return processedFiles[i].Path < processedFiles[j].Path
-	})
-
-	return processedFiles, nil
+	// 先頭の "./" や "/" を除去して正規化
+	cleanPath = strings.TrimPrefix(cleanPath, "/")
+	cleanPath = strings.TrimPrefix(cleanPath, "./")
+	return cleanPath, nil
 }
 
 // SplitDataIntoFragments は指定されたバイトスライスを特定のサイズで分割します。
@@ -0,0 +1,169 @@
+package types_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"gwc/x/gateway/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeZip writes the entries (in the given order) to a ZIP in a temp dir and returns its path.
+func writeZip(t *testing.T, entries ...zipEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		require.NoError(t, err)
+		_, err = w.Write(e.data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	p := filepath.Join(t.TempDir(), "site.zip")
+	require.NoError(t, os.WriteFile(p, buf.Bytes(), 0o644))
+	return p
+}
+
+type zipEntry struct {
+	name string
+	data []byte
+}
+
+func patternBytes(n, seed int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i*seed + i/7)
+	}
+	return b
+}
+
+func sampleSite() []zipEntry {
+	return []zipEntry{
+		{"b/app.js", patternBytes(1000, 3)},
+		{"./index.html", []byte("<html>hello</html>")},
+		{"a/logo.png", patternBytes(333, 11)},
+		{"z/empty.txt", nil},
+	}
+}
+
+// TestZipStreamMatchesInMemory checks that the two-pass streaming pipeline produces the same RootProof,
+// totals, file proofs and fragments as the in-memory ProcessZipAndSplit → BuildCSUProofs path.
+func TestZipStreamMatchesInMemory(t *testing.T) {
+	layouts := []types.FragmentLayout{
+		{FragmentSize: 64},
+		{FragmentSize: 64, ErasureDataShards: 3, ErasureParityShards: 2},
+		{FragmentSize: 256, Compression: types.CompressionGzip},
+	}
+	zipPath := writeZip(t, sampleSite()...)
+	zipBytes, err := os.ReadFile(zipPath)
+	require.NoError(t, err)
+
+	for _, layout := range layouts {
+		files, err := types.ProcessZipAndSplit(zipBytes, layout.FragmentSize)
+		require.NoError(t, err)
+		if layout.Compression != "" {
+			require.NoError(t, types.ApplyCompression(files, layout.Compression, layout.FragmentSize))
+		}
+		if layout.ErasureDataShards > 0 {
+			require.NoError(t, types.ApplyErasureCoding(files, layout.ErasureDataShards, layout.ErasureParityShards))
+		}
+		want, err := types.BuildCSUProofs(files)
+		require.NoError(t, err)
+
+		stream, err := types.OpenZipStream(zipPath, layout, 0)
+		require.NoError(t, err)
+
+		require.Equal(t, want.RootProofHex, stream.RootProofHex)
+		require.Equal(t, uint64(len(want.Fragments)), stream.FragmentCount)
+		var totalBytes uint64
+		for _, frag := range want.Fragments {
+			totalBytes += uint64(len(frag.FragmentBytes))
+		}
+		require.Equal(t, totalBytes, stream.TotalBytes)
+		require.Equal(t, want.Files, stream.FileProofs())
+
+		// the second pass follows the archive order; the proofs list them by path
+		var got []types.CSUFragmentProofData
+		require.NoError(t, stream.ForEachFragment(nil, func(frag types.CSUFragmentProofData) error {
+			got = append(got, frag)
+			return nil
+		}))
+		sort.SliceStable(got, func(i, j int) bool { return got[i].Path < got[j].Path })
+		require.Equal(t, want.Fragments, got)
+
+		var ids []string
+		for _, frag := range want.Fragments {
+			ids = append(ids, types.MakeContentID(frag.FragmentBytes))
+		}
+		require.Equal(t, ids, stream.ContentIDs(nil))
+		require.NoError(t, stream.Close())
+	}
+}
+
+func TestZipStreamFilterAndFragment(t *testing.T) {
+	stream, err := types.OpenZipStream(writeZip(t, sampleSite()...), types.FragmentLayout{FragmentSize: 64}, 0)
+	require.NoError(t, err)
+	defer stream.Close()
+
+	// the filter skips whole files (archive order)
+	var paths []string
+	require.NoError(t, stream.ForEachFragment(func(p string) bool { return p != "b/app.js" }, func(frag types.CSUFragmentProofData) error {
+		if len(paths) == 0 || paths[len(paths)-1] != frag.Path {
+			paths = append(paths, frag.Path)
+		}
+		return nil
+	}))
+	require.Equal(t, []string{"index.html", "a/logo.png"}, paths)
+	require.Len(t, stream.ContentIDs(func(p string) bool { return p == "index.html" }), 1)
+
+	// a single fragment is re-read with its proof
+	var want types.CSUFragmentProofData
+	require.NoError(t, stream.ForEachFragment(nil, func(frag types.CSUFragmentProofData) error {
+		if frag.Path == "b/app.js" && frag.Index == 7 {
+			want = frag
+		}
+		return nil
+	}))
+	got, err := stream.Fragment("b/app.js", 7)
+	require.NoError(t, err)
+	require.Equal(t, want, *got)
+
+	_, err = stream.Fragment("b/app.js", 16)
+	require.Error(t, err)
+	_, err = stream.Fragment("z/empty.txt", 0)
+	require.Error(t, err)
+}
+
+func TestZipStreamRejects(t *testing.T) {
+	layout := types.FragmentLayout{FragmentSize: 64}
+
+	// decompression limit
+	_, err := types.OpenZipStream(writeZip(t, sampleSite()...), layout, 1000)
+	require.Error(t, err)
+	stream, err := types.OpenZipStream(writeZip(t, sampleSite()...), layout, 1000+18+333)
+	require.NoError(t, err)
+	require.NoError(t, stream.Close())
+
+	// unsafe and duplicate paths
+	_, err = types.OpenZipStream(writeZip(t, zipEntry{"../evil.txt", []byte("x")}), layout, 0)
+	require.Error(t, err)
+	_, err = types.OpenZipStream(writeZip(t, zipEntry{"a.txt", []byte("x")}, zipEntry{"./a.txt", []byte("y")}), layout, 0)
+	require.Error(t, err)
+
+	// encrypted sessions require chunks of at least the encryption overhead
+	_, err = types.OpenZipStream(writeZip(t, zipEntry{"a.txt", []byte("short")}), types.FragmentLayout{FragmentSize: 64, Encrypted: true}, 0)
+	require.Error(t, err)
+
+	// invalid layouts
+	_, err = types.OpenZipStream(writeZip(t, sampleSite()...), types.FragmentLayout{FragmentSize: 0}, 0)
+	require.Error(t, err)
+	_, err = types.OpenZipStream(writeZip(t, sampleSite()...), types.FragmentLayout{FragmentSize: 256, Compression: types.CompressionGzip, ErasureDataShards: 2, ErasureParityShards: 1}, 0)
+	require.Error(t, err)
+}
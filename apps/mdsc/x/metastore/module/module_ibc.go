@@ -146,6 +146,8 @@ func (im IBCModule) OnRecvPacket(
 
 		// 1. 既存または新規のManifestを取得
 		manifest, err := im.keeper.Manifest.Get(ctx, projectName)
+		// 再利用ファイルの照合用に、更新前のマニフェスト全体の fragment_size を保持します
+		prevFragmentSize := manifest.FragmentSize
 		if err != nil { // 新規作成
 			manifest = types.Manifest{
 				ProjectName: projectName,
@@ -156,6 +158,7 @@ func (im IBCModule) OnRecvPacket(
 				RootProof:    manifestData.RootProof,
 				SessionId:    manifestData.SessionId,
 				FragmentSize: manifestData.FragmentSize,
+				WrappedKeys:  manifestData.WrappedKeys,
 			}
 		} else { // 更新
 			manifest.Version = manifestData.Version
@@ -163,6 +166,10 @@ func (im IBCModule) OnRecvPacket(
 			manifest.RootProof = manifestData.RootProof
 			manifest.SessionId = manifestData.SessionId
 			manifest.FragmentSize = manifestData.FragmentSize
+			// 暗号化されたバージョンのみ鍵エントリを差し替えます（平文のバージョンでは既存ファイル用に残す）
+			if len(manifestData.WrappedKeys) > 0 {
+				manifest.WrappedKeys = manifestData.WrappedKeys
+			}
 
 			if manifest.Files == nil {
 				manifest.Files = make(map[string]*types.FileInfo)
@@ -175,10 +182,21 @@ func (im IBCModule) OnRecvPacket(
 			var storedFragments []*types.FragmentLocation
 			for _, f := range fileMeta.Fragments {
 				storedFragments = append(storedFragments, &types.FragmentLocation{
-					FdscId:     f.FdscId,
-					FragmentId: f.FragmentId,
+					FdscId:         f.FdscId,
+					FragmentId:     f.FragmentId,
+					ReplicaFdscIds: f.ReplicaFdscIds,
 				})
 			}
+			// 差分デプロイで変更のないファイルは、保存済みファイルの断片配置を引き継ぎます
+			if fileMeta.Reused {
+				fragments, err := types.ReusedFileFragments(manifest.Files[filePath], fileMeta, prevFragmentSize)
+				if err != nil {
+					errMsg := fmt.Errorf("invalid reused file %s in project %s: %w", filePath, projectName, err)
+					ctx.Logger().Error(errMsg.Error())
+					return channeltypes.NewErrorAcknowledgement(errMsg)
+				}
+				storedFragments = fragments
+			}
 
 			// FileInfoの作成
 			fileInfo := types.FileInfo{
@@ -186,6 +204,12 @@ func (im IBCModule) OnRecvPacket(
 				Size_:     fileMeta.Size_,
 				Fragments: storedFragments,
 				FileRoot:  fileMeta.FileRoot,
+
+				ErasureDataShards:   fileMeta.ErasureDataShards,
+				ErasureParityShards: fileMeta.ErasureParityShards,
+				CipherSuite:         fileMeta.CipherSuite,
+				FragmentSize:        fileMeta.FragmentSize,
+				Compression:         fileMeta.Compression,
 			}
 
 			// マップに登録（上書き）
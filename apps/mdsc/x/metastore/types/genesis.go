@@ -23,9 +23,22 @@ func (gs GenesisState) Validate() error {
 
 	for _, elem := range gs.ManifestMap {
 		index := fmt.Sprint(elem.ProjectName)
+		if index == "" {
+			return fmt.Errorf("manifest with empty project_name")
+		}
 		if _, ok := manifestIndexMap[index]; ok {
 			return fmt.Errorf("duplicated index for manifest")
 		}
+		for path, file := range elem.Files {
+			if path == "" || file == nil {
+				return fmt.Errorf("manifest %s has an empty file entry", index)
+			}
+			for i, frag := range file.Fragments {
+				if frag == nil || frag.FragmentId == "" {
+					return fmt.Errorf("manifest %s file %s has an empty fragment location at %d", index, path, i)
+				}
+			}
+		}
 		manifestIndexMap[index] = struct{}{}
 	}
 
@@ -37,3 +37,44 @@ func ValidateManifestPacketIdentity(p *ManifestPacket) error {
 
 	return nil
 }
+
+// ReusedFileFragments returns the fragment locations of a file that an incremental deploy reuses from the
+// stored manifest. GWC has proven (path, size, file_root) against the new root_proof; the stored file must
+// have the same file_root, size and encoding, so its fragments are exactly the ones the new version commits to.
+// prevFragmentSize is the manifest-level fragment_size before the update (stored files without their own
+// fragment_size were written by that version).
+func ReusedFileFragments(stored *FileInfo, meta *FileMetadata, prevFragmentSize uint64) ([]*FragmentLocation, error) {
+	if stored == nil {
+		return nil, fmt.Errorf("no stored file to reuse")
+	}
+	if len(meta.Fragments) > 0 {
+		return nil, fmt.Errorf("a reused file must not carry fragments")
+	}
+	if meta.FileRoot == "" || stored.FileRoot != meta.FileRoot {
+		return nil, fmt.Errorf("file_root %q does not match the stored %q", meta.FileRoot, stored.FileRoot)
+	}
+	if stored.Size_ != meta.Size_ {
+		return nil, fmt.Errorf("size %d does not match the stored %d", meta.Size_, stored.Size_)
+	}
+	storedFragmentSize := stored.FragmentSize
+	if storedFragmentSize == 0 {
+		storedFragmentSize = prevFragmentSize
+	}
+	if storedFragmentSize != meta.FragmentSize {
+		return nil, fmt.Errorf("fragment_size %d does not match the stored %d", meta.FragmentSize, storedFragmentSize)
+	}
+	if stored.ErasureDataShards != meta.ErasureDataShards || stored.ErasureParityShards != meta.ErasureParityShards {
+		return nil, fmt.Errorf("erasure coding %d+%d does not match the stored %d+%d",
+			meta.ErasureDataShards, meta.ErasureParityShards, stored.ErasureDataShards, stored.ErasureParityShards)
+	}
+	if stored.CipherSuite != meta.CipherSuite {
+		return nil, fmt.Errorf("cipher_suite %q does not match the stored %q", meta.CipherSuite, stored.CipherSuite)
+	}
+	if stored.Compression != meta.Compression {
+		return nil, fmt.Errorf("compression %q does not match the stored %q", meta.Compression, stored.Compression)
+	}
+	if len(stored.Fragments) == 0 {
+		return nil, fmt.Errorf("the stored file has no fragments")
+	}
+	return stored.Fragments, nil
+}
@@ -121,4 +121,22 @@ export async function buildProjectMerkleRoot(
   log(`[Merkle/Sync] Result RootProof: ${rootProof}`);
 
   return rootProof;
-}
\ No newline at end of file
+}
+
+/**
+ * MsgCommitRootProof で宣言する断片数と総バイト数を計算します。
+ * BuildCSUProofs と同様に空ファイルは断片を持ちません。
+ */
+export function countFragments(
+  files: { path: string, data: Uint8Array }[],
+  fragSize: number
+): { fragmentCount: number, totalBytes: number } {
+  let fragmentCount = 0;
+  let totalBytes = 0;
+  for (const f of files) {
+    if (f.data.length === 0) continue;
+    fragmentCount += Math.ceil(f.data.length / fragSize);
+    totalBytes += f.data.length;
+  }
+  return { fragmentCount, totalBytes };
+}
@@ -5,7 +5,7 @@
  */
 import { runCmd, log } from "./common.ts";
 import { CONFIG } from "./config.ts";
-import { buildProjectMerkleRoot } from "./merkle.ts";
+import { buildProjectMerkleRoot, countFragments } from "./merkle.ts";
 import { crypto } from "@std/crypto";
 import { walk } from "@std/fs/walk";
 import { relative, resolve } from "@std/path";
@@ -95,58 +95,6 @@ async function executeTx(args: string[], from: string): Promise<any> {
   );
 }
 
-async function hasFeegrant(owner: string, exec: string): Promise<boolean> {
-  try {
-    const out = await runCmd([
-      CONFIG.BIN.GWC,
-      "q",
-      "feegrant",
-      "grant",
-      owner,
-      exec,
-      "--node",
-      CONFIG.GWC_RPC,
-      "-o",
-      "json",
-    ]);
-    const data = JSON.parse(out);
-    return !!(data.allowance || data.grant);
-  } catch (_e) {
-    return false;
-  }
-}
-
-async function hasAuthz(
-  owner: string,
-  exec: string,
-  msgType: string,
-): Promise<boolean> {
-  try {
-    const out = await runCmd([
-      CONFIG.BIN.GWC,
-      "q",
-      "authz",
-      "grants",
-      owner,
-      exec,
-      "--node",
-      CONFIG.GWC_RPC,
-      "-o",
-      "json",
-    ]);
-    const data = JSON.parse(out);
-    if (!data.grants || !Array.isArray(data.grants)) return false;
-    return data.grants.some(
-      (g: any) =>
-        g.authorization?.msg === msgType ||
-        (g.authorization?.["@type"]?.includes("GenericAuthorization") &&
-          g.authorization?.msg === msgType),
-    );
-  } catch (_e) {
-    return false;
-  }
-}
-
 /**
  * GWC CSUへのアップロードを実行する。
  * @param numFdscChains 使用するFDSCチェーン数 (0の場合は全チェーンを使用)
@@ -173,11 +121,32 @@ export async function uploadToGwcCsu(
   }
 
   const rootHex = await buildProjectMerkleRoot(files, fragSize);
+  const { fragmentCount, totalBytes } = countFragments(files, fragSize);
+
+  // アップロードトークンはクライアント側で乱数生成し、ハッシュのみをチェーンに登録します
+  const toHex = (b: Uint8Array) =>
+    Array.from(b).map((x) => x.toString(16).padStart(2, "0")).join("");
+  const token = toHex(crypto.getRandomValues(new Uint8Array(32)));
+  const tokenHash = toHex(
+    new Uint8Array(
+      await crypto.subtle.digest("SHA-256", new TextEncoder().encode(token)),
+    ),
+  );
 
   log("Step 2: セッションの初期化中...");
   // 新しく追加された numFdscChains 引数を CLI コマンドに反映
   const initRes = await executeTx(
-    ["gateway", "init-session", fragSize.toString(), "0", numFdscChains.toString()],
+    [
+      "gateway",
+      "init-session",
+      fragSize.toString(),
+      "0",
+      numFdscChains.toString(),
+      "--upload-token-hash",
+      tokenHash,
+      "--declared-bytes",
+      String(totalBytes),
+    ],
     "alice",
   );
 
@@ -185,84 +154,27 @@ export async function uploadToGwcCsu(
   const sid = (
     event.attributes.find((a: any) => a.key === "session_id").value as string
   ).replace(/^"|"$/g, "");
-  const exec = (
-    event.attributes.find((a: any) => a.key === "executor").value as string
-  ).replace(/^"|"$/g, "");
-  const owner = (
-    event.attributes.find((a: any) => a.key === "owner").value as string
-  ).replace(/^"|"$/g, "");
-
-  log(`Step 3: 権限（Authz/Feegrant）の確認と設定中...`);
-
-  const expirationDate = new Date();
-  expirationDate.setHours(expirationDate.getHours() + 1);
-  const expirationISO = expirationDate.toISOString().replace(/\.\d{3}Z$/, "Z");
-  const expirationUnix = Math.floor(expirationDate.getTime() / 1000).toString();
-
-  if (!(await hasFeegrant(owner, exec))) {
-    try {
-      log("  - Feegrant を付与中...");
-      await executeTx(
-        ["feegrant", "grant", owner, exec, "--expiration", expirationISO],
-        "alice",
-      );
-    } catch (e) {
-      if (!String(e).includes("already exists"))
-        log(`  - Feegrant付与失敗: ${e}`);
-    }
-  } else {
-    log("  - Feegrant は既に設定済みです。");
-  }
 
-  const msgTypes = [
-    "MsgDistributeBatch",
-    "MsgFinalizeAndCloseSession",
-    "MsgAbortAndCloseSession",
-  ];
-  for (const m of msgTypes) {
-    const typeUrl = `/gwc.gateway.v1.${m}`;
-    if (!(await hasAuthz(owner, exec, typeUrl))) {
-      try {
-        log(`  - Authz (${m}) を付与中...`);
-        await executeTx(
-          [
-            "authz",
-            "grant",
-            exec,
-            "generic",
-            "--msg-type",
-            typeUrl,
-            "--expiration",
-            expirationUnix,
-          ],
-          "alice",
-        );
-      } catch (e) {
-        if (!String(e).includes("already exists"))
-          log(`  - Authz(${m})付与失敗: ${e}`);
-      }
-    } else {
-      log(`  - Authz (${m}) は既に設定済みです。`);
-    }
-  }
+  log(`Step 3: 権限（セッション束縛 Authz/Feegrant）を付与中...`);
+  // grant-session は executor 用の SessionBoundAuthorization 一式と feegrant（未設定時のみ）を 1 Tx で付与します
+  await executeTx(
+    ["gateway", "grant-session", sid, "--max-bytes", String(totalBytes)],
+    "alice",
+  );
 
   log("Step 4: マークルルートのコミット中...");
-  await executeTx(["gateway", "commit-root-proof", sid, rootHex], "alice");
+  await executeTx(
+    ["gateway", "commit-root-proof", sid, rootHex, String(fragmentCount), String(totalBytes)],
+    "alice",
+  );
 
   const zipData = await Deno.readFile(zipPath);
-  const tokenRaw = await crypto.subtle.digest(
-    "SHA-256",
-    new TextEncoder().encode(`upload_token:${sid}`),
-  );
-  const token = Array.from(new Uint8Array(tokenRaw))
-    .map((b) => b.toString(16).padStart(2, "0"))
-    .join("");
   const endPrep = performance.now();
 
   // --- Phase 2: Client Upload ---
   const startClientUpload = performance.now();
   log(`Step 5: TUSプロトコルによるZIPアップロード中: ${zipPath}`);
-  const meta = `session_id ${btoa(sid)},project_name ${btoa(projectName)},version ${btoa(version)}`;
+  const meta = `session_id ${btoa(sid)},upload_token ${btoa(token)},project_name ${btoa(projectName)},version ${btoa(version)}`;
   const postResponse = await fetch(`${CONFIG.GWC_API}/upload/tus-stream`, {
     method: "POST",
     headers: {
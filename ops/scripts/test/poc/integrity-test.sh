@@ -28,6 +28,7 @@ WORKDIR="${WORKDIR:-/tmp/csu-tus-test}"
 TEST_DIR="${WORKDIR}/site"
 ZIP_FILE="${WORKDIR}/site.zip"
 ROOT_PROOF_FILE="${WORKDIR}/root_proof.txt"
+TOTALS_FILE="${WORKDIR}/totals.txt"
 
 # 動的変数
 SESSION_ID=""
@@ -89,32 +90,31 @@ phase_session() {
   OWNER_ADDR=$("${BINARY}" keys show "${OWNER_KEY}" -a ${KEYRING} 2>/dev/null)
   [[ -z "${OWNER_ADDR}" ]] && fail "Key '${OWNER_KEY}' が見つかりません。"
 
+  # アップロードトークンはクライアント側で乱数生成し、ハッシュのみをチェーンに登録します
+  UPLOAD_TOKEN=$(head -c 32 /dev/urandom | sha256sum | awk '{print $1}')
+  local token_hash
+  token_hash=$(echo -n "${UPLOAD_TOKEN}" | sha256sum | awk '{print $1}')
+
   local tx_res
-  tx_res=$(execute_tx "${BINARY} tx gateway init-session ${FRAGMENT_SIZE} 0 --from ${OWNER_KEY} ${KEYRING} --chain-id ${CHAIN_ID} --node ${NODE_URL} -y")
+  tx_res=$(execute_tx "${BINARY} tx gateway init-session ${FRAGMENT_SIZE} 0 --upload-token-hash ${token_hash} --from ${OWNER_KEY} ${KEYRING} --chain-id ${CHAIN_ID} --node ${NODE_URL} -y")
 
   SESSION_ID=$(safe_jq "${tx_res}" '.events[] | select(.type=="csu_init_session") | .attributes[] | select(.key=="session_id") | .value')
   EXECUTOR_ADDR=$(safe_jq "${tx_res}" '.events[] | select(.type=="csu_init_session") | .attributes[] | select(.key=="executor") | .value')
-  
-  UPLOAD_TOKEN=$(echo -n "upload_token:${SESSION_ID}" | sha256sum | awk '{print $1}')
+
 
   log_info "Session ID: ${SESSION_ID}"
   log_info "Executor  : ${EXECUTOR_ADDR}"
 
   log_info "権限(Authz/Feegrant)を委譲中..."
   local common="--from ${OWNER_KEY} ${KEYRING} --chain-id ${CHAIN_ID} --node ${NODE_URL} -y"
-  
-  # Feegrantの付与
-  execute_tx "${BINARY} tx feegrant grant ${OWNER_ADDR} ${EXECUTOR_ADDR} ${common}" >/dev/null
 
-  # Authzの付与 (配布、完了、および異常終了時のためのAbort権限も追加)
-  execute_tx "${BINARY} tx authz grant ${EXECUTOR_ADDR} generic --msg-type /gwc.gateway.v1.MsgDistributeBatch ${common}" >/dev/null
-  execute_tx "${BINARY} tx authz grant ${EXECUTOR_ADDR} generic --msg-type /gwc.gateway.v1.MsgFinalizeAndCloseSession ${common}" >/dev/null
-  execute_tx "${BINARY} tx authz grant ${EXECUTOR_ADDR} generic --msg-type /gwc.gateway.v1.MsgAbortAndCloseSession ${common}" >/dev/null
+  # セッション束縛のAuthz (配布、再配布、完了、Abort) と Feegrant を1Txで付与
+  execute_tx "${BINARY} tx gateway grant-session ${SESSION_ID} ${common}" >/dev/null
 }
 
 phase_merkle() {
   log_step "Step 3: Merkle Root コミット"
-  export TEST_DIR FRAGMENT_SIZE ROOT_PROOF_FILE
+  export TEST_DIR FRAGMENT_SIZE ROOT_PROOF_FILE TOTALS_FILE
   python3 -c '
 import hashlib, os
 def sha256(b): return hashlib.sha256(b).digest()
@@ -128,6 +128,8 @@ def merkle(leaves):
         level = [parent(level[i], level[i+1]) for i in range(0, level, 2)]
     return level[0] if level else b""
 files = []
+frag_count = 0
+total_bytes = 0
 for dp, _, fns in os.walk(os.environ["TEST_DIR"]):
     for fn in fns:
         full = os.path.join(dp, fn)
@@ -136,14 +138,19 @@ for dp, _, fns in os.walk(os.environ["TEST_DIR"]):
         fsize = len(data)
         frag_size = int(os.environ["FRAGMENT_SIZE"])
         frags = [data[i:i+frag_size] for i in range(0, fsize, frag_size)] or [b""]
+        if fsize > 0:
+            frag_count += len(frags)
+            total_bytes += fsize
         froot = merkle([hash_frag(rel, i, b) for i, b in enumerate(frags)])
         files.append((rel, hash_file(rel, fsize, froot)))
 files.sort(key=lambda x: x[0])
 root = merkle([f[1] for f in files])
 with open(os.environ["ROOT_PROOF_FILE"], "w") as f: f.write(root.hex())
+with open(os.environ["TOTALS_FILE"], "w") as f: f.write(f"{frag_count} {total_bytes}")
 '
   local root_hex=$(cat "${ROOT_PROOF_FILE}")
-  execute_tx "${BINARY} tx gateway commit-root-proof ${SESSION_ID} ${root_hex} --from ${OWNER_KEY} ${KEYRING} --chain-id ${CHAIN_ID} --node ${NODE_URL} -y" >/dev/null
+  local totals=$(cat "${TOTALS_FILE}")
+  execute_tx "${BINARY} tx gateway commit-root-proof ${SESSION_ID} ${root_hex} ${totals} --from ${OWNER_KEY} ${KEYRING} --chain-id ${CHAIN_ID} --node ${NODE_URL} -y" >/dev/null
 }
 
 phase_upload() {
@@ -156,8 +163,9 @@ phase_upload() {
   local sess_b64=$(echo -n "${SESSION_ID}" | base64 | tr -d '\n')
   local proj_b64=$(echo -n "${PROJECT_NAME}" | base64 | tr -d '\n')
   local ver_b64=$(echo -n "${PROJECT_VERSION}" | base64 | tr -d '\n')
+  local token_b64=$(echo -n "${UPLOAD_TOKEN}" | base64 | tr -d '\n')
   
-  local metadata="session_id ${sess_b64},project_name ${proj_b64},version ${ver_b64}"
+  local metadata="session_id ${sess_b64},upload_token ${token_b64},project_name ${proj_b64},version ${ver_b64}"
   
   log_info "POST: ${base_url}"
   log_info "Metadata: Project=${PROJECT_NAME}, Version=${PROJECT_VERSION}"
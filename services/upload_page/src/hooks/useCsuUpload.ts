@@ -4,12 +4,13 @@ import { SigningStargateClient } from '@cosmjs/stargate';
 import Long from 'long';
 import * as tus from 'tus-js-client';
 import { MsgInitSessionResponse } from '../lib/proto/gwc/gateway/v1/tx';
+import { SessionBoundAuthorization } from '../lib/proto/gwc/gateway/v1/authorization';
 import { MsgGrant } from 'cosmjs-types/cosmos/authz/v1beta1/tx';
-import { GenericAuthorization } from 'cosmjs-types/cosmos/authz/v1beta1/authz';
 import { MsgGrantAllowance } from 'cosmjs-types/cosmos/feegrant/v1beta1/tx';
 import { BasicAllowance } from 'cosmjs-types/cosmos/feegrant/v1beta1/feegrant';
 import { MerkleTreeCalculator, type InputFile } from '../lib/merkle';
 import { createZipBlob } from '../lib/zip';
+import { bytesToHex, sha256, stringToBytes } from '../lib/utils';
 import { CONFIG } from '../constants/config';
 import { SessionState, sessionStateToJSON } from '../lib/proto/gwc/gateway/v1/types';
 
@@ -127,19 +128,25 @@ export function useCsuUpload(client: SigningStargateClient | null, address: stri
             addLog(`Step 1: Merkle Rootの計算とZIP圧縮を開始 (Fragment Size: ${fragmentSize} bytes)...`);
             const merkleCalc = new MerkleTreeCalculator();
             const rootProof = await merkleCalc.calculateRootProof(files, fragmentSize);
+            const { fragmentCount, totalBytes } = merkleCalc.countFragments(files, fragmentSize);
             const zipBlob = await createZipBlob(files);
             addLog(`ZIPファイル作成完了: ${(zipBlob.size / 1024).toFixed(2)} KB`);
 
             // Step 2: セッションの初期化 (On-chain)
             addLog(`Step 2: セッションの初期化 (On-chain, FDSC Chains: ${numFdscChains || 'All'})...`);
             const deadline = Math.floor(Date.now() / 1000) + 3600;
+            // upload token はブラウザ内で生成し、チェーンには sha256 だけを送ります（Tx結果は公開されるため）
+            const uploadToken = bytesToHex(crypto.getRandomValues(new Uint8Array(32)));
+            const uploadTokenHashHex = bytesToHex(await sha256(stringToBytes(uploadToken)));
             const initRes = await client.signAndBroadcast(address, [{
                 typeUrl: '/gwc.gateway.v1.MsgInitSession',
                 value: {
                     owner: address,
                     fragmentSize: Long.fromNumber(fragmentSize),
                     deadlineUnix: Long.fromNumber(deadline),
-                    numFdscChains: numFdscChains
+                    numFdscChains: numFdscChains,
+                    uploadTokenHashHex,
+                    declaredTotalBytes: Long.fromNumber(totalBytes)
                 }
             }], { amount: [{ denom: CONFIG.denom, amount: '2000' }], gas: '200000' });
 
@@ -149,16 +156,16 @@ export function useCsuUpload(client: SigningStargateClient | null, address: stri
             const executor = initRes.events.find(e => e.type === 'csu_init_session')
                 ?.attributes.find(a => a.key === 'executor')?.value.replace(/^"|"$/g, '') || "";
 
-            // Step 3: Executorへの権限委譲
+            // Step 3: Executorへの権限委譲 (session_id に束縛された SessionBoundAuthorization)
             addLog('Step 3: Executorへの権限委譲...');
-            const grantMsgs = ['MsgDistributeBatch', 'MsgFinalizeAndCloseSession', 'MsgAbortAndCloseSession'].map(type => ({
+            const grantMsgs = ['MsgDistributeBatch', 'MsgRedistributeFragments', 'MsgFinalizeAndCloseSession', 'MsgAbortAndCloseSession'].map(type => ({
                 typeUrl: '/cosmos.authz.v1beta1.MsgGrant',
                 value: MsgGrant.fromPartial({
                     granter: address, grantee: executor,
                     grant: {
                         authorization: {
-                            typeUrl: '/cosmos.authz.v1beta1.GenericAuthorization',
-                            value: GenericAuthorization.encode({ msg: `/gwc.gateway.v1.${type}` }).finish()
+                            typeUrl: '/gwc.gateway.v1.SessionBoundAuthorization',
+                            value: SessionBoundAuthorization.encode({ sessionId: initData.sessionId, msgTypeUrl: `/gwc.gateway.v1.${type}` }).finish()
                         },
                         expiration: { seconds: BigInt(Math.floor(Date.now() / 1000) + 3600), nanos: 0 }
                     }
@@ -181,7 +188,13 @@ export function useCsuUpload(client: SigningStargateClient | null, address: stri
             addLog('Step 4: Root Proofのコミット...');
             await client.signAndBroadcast(address, [{
                 typeUrl: '/gwc.gateway.v1.MsgCommitRootProof',
-                value: { owner: address, sessionId: initData.sessionId, rootProofHex: rootProof }
+                value: {
+                    owner: address,
+                    sessionId: initData.sessionId,
+                    rootProofHex: rootProof,
+                    expectedFragmentCount: Long.fromNumber(fragmentCount),
+                    expectedTotalBytes: Long.fromNumber(totalBytes)
+                }
             }], { amount: [{ denom: CONFIG.denom, amount: '2000' }], gas: '200000' });
 
             // Step 5: TUSプロトコルによるZIPファイルのアップロード
@@ -192,9 +205,10 @@ export function useCsuUpload(client: SigningStargateClient | null, address: stri
             const tusUpload = new tus.Upload(zipBlob, {
                 endpoint: `${CONFIG.restEndpoint}/upload/tus-stream/`,
                 retryDelays: [0, 1000, 3000],
-                headers: { Authorization: `Bearer ${initData.sessionUploadToken}` },
+                headers: { Authorization: `Bearer ${uploadToken}` },
                 metadata: {
                     session_id: initData.sessionId,
+                    upload_token: uploadToken,
                     project_name: projectName,
                     version: projectVersion
                 },
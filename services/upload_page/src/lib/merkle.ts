@@ -136,4 +136,19 @@ export class MerkleTreeCalculator {
 
         return bytesToHex(rootHash);
     }
+
+    /**
+     * MsgCommitRootProof で宣言する断片数と総バイト数を計算します。
+     * チェーン側 (BuildCSUProofs) と同様に空ファイルは断片を持ちません。
+     */
+    public countFragments(files: InputFile[], fragmentSize: number = 1024): { fragmentCount: number; totalBytes: number } {
+        let fragmentCount = 0;
+        let totalBytes = 0;
+        for (const file of files) {
+            if (file.data.length === 0) continue;
+            fragmentCount += Math.ceil(file.data.length / fragmentSize);
+            totalBytes += file.data.length;
+        }
+        return { fragmentCount, totalBytes };
+    }
 }
\ No newline at end of file
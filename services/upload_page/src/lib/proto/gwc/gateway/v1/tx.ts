@@ -38,12 +38,16 @@ export interface MsgInitSession {
   fragmentSize: Long;
   /** deadline_unix is the session deadline (unix seconds). 0 means "use chain default". */
   deadlineUnix: Long;
+  /**
+   * upload_token_hash_hex is the hex(sha256(token)) of a client-generated upload token (required).
+   * The chain only stores this hash and never sees the plaintext token; the client sends the token
+   * itself as the "upload_token" key of TUS Upload-Metadata.
+   */
+  uploadTokenHashHex: string;
 }
 
 export interface MsgInitSessionResponse {
   sessionId: string;
-  /** session_upload_token is used by TUS/HTTP upload endpoint (off-chain) to authorize the upload. */
-  sessionUploadToken: string;
   /** resolved_deadline_unix is the deadline actually stored in chain state. */
   resolvedDeadlineUnix: Long;
 }
@@ -334,7 +338,7 @@ export const MsgRegisterStorageResponse: MessageFns<MsgRegisterStorageResponse>
 };
 
 function createBaseMsgInitSession(): MsgInitSession {
-  return { owner: "", fragmentSize: Long.UZERO, deadlineUnix: Long.ZERO };
+  return { owner: "", fragmentSize: Long.UZERO, deadlineUnix: Long.ZERO, uploadTokenHashHex: "" };
 }
 
 export const MsgInitSession: MessageFns<MsgInitSession> = {
@@ -348,6 +352,9 @@ export const MsgInitSession: MessageFns<MsgInitSession> = {
     if (!message.deadlineUnix.equals(Long.ZERO)) {
       writer.uint32(32).int64(message.deadlineUnix.toString());
     }
+    if (message.uploadTokenHashHex !== "") {
+      writer.uint32(50).string(message.uploadTokenHashHex);
+    }
     return writer;
   },
 
@@ -382,6 +389,14 @@ export const MsgInitSession: MessageFns<MsgInitSession> = {
           message.deadlineUnix = Long.fromString(reader.int64().toString());
           continue;
         }
+        case 6: {
+          if (tag !== 50) {
+            break;
+          }
+
+          message.uploadTokenHashHex = reader.string();
+          continue;
+        }
       }
       if ((tag & 7) === 4 || tag === 0) {
         break;
@@ -404,6 +419,11 @@ export const MsgInitSession: MessageFns<MsgInitSession> = {
         : isSet(object.deadline_unix)
         ? Long.fromValue(object.deadline_unix)
         : Long.ZERO,
+      uploadTokenHashHex: isSet(object.uploadTokenHashHex)
+        ? globalThis.String(object.uploadTokenHashHex)
+        : isSet(object.upload_token_hash_hex)
+        ? globalThis.String(object.upload_token_hash_hex)
+        : "",
     };
   },
 
@@ -418,6 +438,9 @@ export const MsgInitSession: MessageFns<MsgInitSession> = {
     if (!message.deadlineUnix.equals(Long.ZERO)) {
       obj.deadlineUnix = (message.deadlineUnix || Long.ZERO).toString();
     }
+    if (message.uploadTokenHashHex !== "") {
+      obj.uploadTokenHashHex = message.uploadTokenHashHex;
+    }
     return obj;
   },
 
@@ -433,12 +456,13 @@ export const MsgInitSession: MessageFns<MsgInitSession> = {
     message.deadlineUnix = (object.deadlineUnix !== undefined && object.deadlineUnix !== null)
       ? Long.fromValue(object.deadlineUnix)
       : Long.ZERO;
+    message.uploadTokenHashHex = object.uploadTokenHashHex ?? "";
     return message;
   },
 };
 
 function createBaseMsgInitSessionResponse(): MsgInitSessionResponse {
-  return { sessionId: "", sessionUploadToken: "", resolvedDeadlineUnix: Long.ZERO };
+  return { sessionId: "", resolvedDeadlineUnix: Long.ZERO };
 }
 
 export const MsgInitSessionResponse: MessageFns<MsgInitSessionResponse> = {
@@ -446,9 +470,6 @@ export const MsgInitSessionResponse: MessageFns<MsgInitSessionResponse> = {
     if (message.sessionId !== "") {
       writer.uint32(10).string(message.sessionId);
     }
-    if (message.sessionUploadToken !== "") {
-      writer.uint32(18).string(message.sessionUploadToken);
-    }
     if (!message.resolvedDeadlineUnix.equals(Long.ZERO)) {
       writer.uint32(24).int64(message.resolvedDeadlineUnix.toString());
     }
@@ -470,14 +491,6 @@ export const MsgInitSessionResponse: MessageFns<MsgInitSessionResponse> = {
           message.sessionId = reader.string();
           continue;
         }
-        case 2: {
-          if (tag !== 18) {
-            break;
-          }
-
-          message.sessionUploadToken = reader.string();
-          continue;
-        }
         case 3: {
           if (tag !== 24) {
             break;
@@ -502,11 +515,6 @@ export const MsgInitSessionResponse: MessageFns<MsgInitSessionResponse> = {
         : isSet(object.session_id)
         ? globalThis.String(object.session_id)
         : "",
-      sessionUploadToken: isSet(object.sessionUploadToken)
-        ? globalThis.String(object.sessionUploadToken)
-        : isSet(object.session_upload_token)
-        ? globalThis.String(object.session_upload_token)
-        : "",
       resolvedDeadlineUnix: isSet(object.resolvedDeadlineUnix)
         ? Long.fromValue(object.resolvedDeadlineUnix)
         : isSet(object.resolved_deadline_unix)
@@ -520,9 +528,6 @@ export const MsgInitSessionResponse: MessageFns<MsgInitSessionResponse> = {
     if (message.sessionId !== "") {
       obj.sessionId = message.sessionId;
     }
-    if (message.sessionUploadToken !== "") {
-      obj.sessionUploadToken = message.sessionUploadToken;
-    }
     if (!message.resolvedDeadlineUnix.equals(Long.ZERO)) {
       obj.resolvedDeadlineUnix = (message.resolvedDeadlineUnix || Long.ZERO).toString();
     }
@@ -535,7 +540,6 @@ export const MsgInitSessionResponse: MessageFns<MsgInitSessionResponse> = {
   fromPartial<I extends Exact<DeepPartial<MsgInitSessionResponse>, I>>(object: I): MsgInitSessionResponse {
     const message = createBaseMsgInitSessionResponse();
     message.sessionId = object.sessionId ?? "";
-    message.sessionUploadToken = object.sessionUploadToken ?? "";
     message.resolvedDeadlineUnix = (object.resolvedDeadlineUnix !== undefined && object.resolvedDeadlineUnix !== null)
       ? Long.fromValue(object.resolvedDeadlineUnix)
       : Long.ZERO;
@@ -1112,7 +1116,7 @@ export const MsgAbortAndCloseSessionResponse: MessageFns<MsgAbortAndCloseSession
 /** Msg defines the Msg service. */
 export interface Msg {
   UpdateParams(request: MsgUpdateParams): Promise<MsgUpdateParamsResponse>;
-  /** Step 1: Initialize a CSU session (creates session_id and returns session_upload_token for TUS/HTTP upload) */
+  /** Step 1: Initialize a CSU session (creates session_id and binds the client's upload token hash for TUS/HTTP upload) */
   InitSession(request: MsgInitSession): Promise<MsgInitSessionResponse>;
   /** Step 2: Commit RootProof (computed from the extracted zip contents) */
   CommitRootProof(request: MsgCommitRootProof): Promise<MsgCommitRootProofResponse>;